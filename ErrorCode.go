@@ -56,4 +56,5 @@ const (
 	CommonDbError
 	CommonDbInsertError
 	CommonDbUpdateError
+	CommonTooManyRequests //请求过于频繁
 )
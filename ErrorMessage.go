@@ -30,4 +30,5 @@ var CodeMapMessage = map[int]string{
 	CommonDbError:               "系统错误",
 	CommonDbInsertError:         "数据保存失败",
 	CommonDbUpdateError:         "数据更新失败",
+	CommonTooManyRequests:       "请求过于频繁，请稍后再试",
 }
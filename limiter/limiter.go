@@ -0,0 +1,101 @@
+// Package limiter 限流器
+// 支持固定窗口、滑动窗口(有序集合)与令牌桶(Lua)三种算法，均提供redis与进程内两种实现
+// New 创建的限流器以redis为主，redis不可用时退回进程内实现
+// 示例
+//
+//	login := limiter.New(redis.Default(), limiter.FixedWindow, limiter.PerMinute(5))
+//	if res, err := login.Allow(ctx, "login:"+account); err == nil && !res.Allowed {
+//		return common.NewError(common.CommonTooManyRequests)
+//	}
+//	router.Use(limiter.GinMiddleware(limiter.New(redis.Default(), limiter.TokenBucket, limiter.PerSecond(20)), nil))
+package limiter
+
+import (
+	"context"
+	"time"
+
+	"github.com/XingMenTech/common/redis"
+)
+
+// Algorithm 限流算法
+type Algorithm string
+
+const (
+	// FixedWindow 固定窗口计数，窗口边界处可能短时通过2倍请求
+	FixedWindow Algorithm = "fixed"
+	// SlidingWindow 滑动窗口，记录窗口内每次请求的时间，精确但占用内存与请求数成正比
+	SlidingWindow Algorithm = "sliding"
+	// TokenBucket 令牌桶，按速率补充令牌，允许不超过 Burst 的突发
+	TokenBucket Algorithm = "bucket"
+)
+
+// Rate 限流速率，每 Period 最多 Limit 次；Burst 仅用于令牌桶，默认等于 Limit
+type Rate struct {
+	Limit  int64
+	Period time.Duration
+	Burst  int64
+}
+
+// PerSecond 每秒 n 次
+func PerSecond(n int64) Rate {
+	return Rate{Limit: n, Period: time.Second}
+}
+
+// PerMinute 每分钟 n 次
+func PerMinute(n int64) Rate {
+	return Rate{Limit: n, Period: time.Minute}
+}
+
+// PerHour 每小时 n 次
+func PerHour(n int64) Rate {
+	return Rate{Limit: n, Period: time.Hour}
+}
+
+func (r Rate) burst() int64 {
+	if r.Burst > 0 {
+		return r.Burst
+	}
+	return r.Limit
+}
+
+// Result 限流结果
+type Result struct {
+	Allowed    bool
+	Limit      int64
+	Remaining  int64
+	RetryAfter time.Duration // 未通过时距下次可能通过的时长
+}
+
+// Limiter 限流器，key 区分限流对象(如用户、IP、接口)
+type Limiter interface {
+	Allow(ctx context.Context, key string) (Result, error)
+	AllowN(ctx context.Context, key string, n int64) (Result, error)
+}
+
+// New 创建以redis为主、进程内实现为后备的限流器
+func New(c *redis.Client, algorithm Algorithm, rate Rate) Limiter {
+	return WithFallback(NewRedis(c, algorithm, rate), NewMemory(algorithm, rate))
+}
+
+type fallbackLimiter struct {
+	primary  Limiter
+	fallback Limiter
+}
+
+// WithFallback primary 返回错误时使用 fallback 的结果
+// 多副本部署时后备限流只在本进程内计数，redis故障期间整体放行量会放大为副本数倍
+func WithFallback(primary, fallback Limiter) Limiter {
+	return &fallbackLimiter{primary: primary, fallback: fallback}
+}
+
+func (l *fallbackLimiter) Allow(ctx context.Context, key string) (Result, error) {
+	return l.AllowN(ctx, key, 1)
+}
+
+func (l *fallbackLimiter) AllowN(ctx context.Context, key string, n int64) (Result, error) {
+	res, err := l.primary.AllowN(ctx, key, n)
+	if err == nil {
+		return res, nil
+	}
+	return l.fallback.AllowN(ctx, key, n)
+}
@@ -0,0 +1,142 @@
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// memoryState 单个key的限流状态
+type memoryState struct {
+	count    int64     // 固定窗口计数
+	expireAt time.Time // 固定窗口结束时间，或状态可被清理的时间
+	times    []time.Time
+	tokens   float64
+	ts       time.Time
+}
+
+type memoryLimiter struct {
+	algorithm Algorithm
+	rate      Rate
+	now       func() time.Time
+
+	mu        sync.Mutex
+	states    map[string]*memoryState
+	lastSweep time.Time
+}
+
+// NewMemory 创建进程内限流器，仅在本进程内计数
+func NewMemory(algorithm Algorithm, rate Rate) Limiter {
+	return &memoryLimiter{
+		algorithm: algorithm,
+		rate:      rate,
+		now:       time.Now,
+		states:    make(map[string]*memoryState),
+	}
+}
+
+func (l *memoryLimiter) Allow(ctx context.Context, key string) (Result, error) {
+	return l.AllowN(ctx, key, 1)
+}
+
+func (l *memoryLimiter) AllowN(_ context.Context, key string, n int64) (Result, error) {
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweep(now)
+
+	state, ok := l.states[key]
+	if !ok {
+		state = &memoryState{}
+		l.states[key] = state
+	}
+
+	switch l.algorithm {
+	case FixedWindow:
+		return l.fixedWindow(state, now, n), nil
+	case SlidingWindow:
+		return l.slidingWindow(state, now, n), nil
+	case TokenBucket:
+		return l.tokenBucket(state, now, n), nil
+	default:
+		return Result{}, fmt.Errorf("limiter: unknown algorithm %q", l.algorithm)
+	}
+}
+
+func (l *memoryLimiter) fixedWindow(state *memoryState, now time.Time, n int64) Result {
+	if !now.Before(state.expireAt) {
+		state.count = 0
+		state.expireAt = now.Add(l.rate.Period)
+	}
+	state.count += n
+
+	res := Result{Allowed: state.count <= l.rate.Limit, Limit: l.rate.Limit, Remaining: max(l.rate.Limit-state.count, 0)}
+	if !res.Allowed {
+		res.RetryAfter = state.expireAt.Sub(now)
+	}
+	return res
+}
+
+func (l *memoryLimiter) slidingWindow(state *memoryState, now time.Time, n int64) Result {
+	start := now.Add(-l.rate.Period)
+	i := 0
+	for i < len(state.times) && !state.times[i].After(start) {
+		i++
+	}
+	state.times = state.times[i:]
+	state.expireAt = now.Add(l.rate.Period)
+
+	count := int64(len(state.times))
+	if count+n <= l.rate.Limit {
+		for j := int64(0); j < n; j++ {
+			state.times = append(state.times, now)
+		}
+		return Result{Allowed: true, Limit: l.rate.Limit, Remaining: l.rate.Limit - count - n}
+	}
+
+	res := Result{Limit: l.rate.Limit, Remaining: max(l.rate.Limit-count, 0), RetryAfter: l.rate.Period}
+	if n <= l.rate.Limit {
+		res.RetryAfter = state.times[count+n-l.rate.Limit-1].Add(l.rate.Period).Sub(now)
+	}
+	return res
+}
+
+func (l *memoryLimiter) tokenBucket(state *memoryState, now time.Time, n int64) Result {
+	capacity := float64(l.rate.burst())
+	perNs := float64(l.rate.Limit) / float64(l.rate.Period)
+	if state.ts.IsZero() {
+		state.tokens = capacity
+		state.ts = now
+	}
+	if now.After(state.ts) {
+		state.tokens = math.Min(capacity, state.tokens+float64(now.Sub(state.ts))*perNs)
+		state.ts = now
+	}
+	state.expireAt = now.Add(time.Duration(capacity/perNs) + time.Second)
+
+	res := Result{Limit: l.rate.burst()}
+	if state.tokens >= float64(n) {
+		state.tokens -= float64(n)
+		res.Allowed = true
+	} else {
+		res.RetryAfter = time.Duration(math.Ceil((float64(n) - state.tokens) / perNs))
+	}
+	res.Remaining = int64(state.tokens)
+	return res
+}
+
+// sweep 每个周期清理一次已过期的key
+func (l *memoryLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.rate.Period {
+		return
+	}
+	l.lastSweep = now
+	for key, state := range l.states {
+		if now.After(state.expireAt) {
+			delete(l.states, key)
+		}
+	}
+}
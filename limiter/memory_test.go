@@ -0,0 +1,101 @@
+package limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestMemory(algorithm Algorithm, rate Rate) (*memoryLimiter, *time.Time) {
+	now := time.Unix(1700000000, 0)
+	l := NewMemory(algorithm, rate).(*memoryLimiter)
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func TestMemoryFixedWindow(t *testing.T) {
+	ctx := context.Background()
+	l, now := newTestMemory(FixedWindow, PerSecond(2))
+
+	for i := 0; i < 2; i++ {
+		res, _ := l.Allow(ctx, "k")
+		assert.True(t, res.Allowed)
+	}
+	res, _ := l.Allow(ctx, "k")
+	assert.False(t, res.Allowed)
+	assert.Equal(t, time.Second, res.RetryAfter)
+
+	other, _ := l.Allow(ctx, "other")
+	assert.True(t, other.Allowed, "keys are limited separately")
+
+	*now = now.Add(time.Second)
+	res, _ = l.Allow(ctx, "k")
+	assert.True(t, res.Allowed)
+	assert.Equal(t, int64(1), res.Remaining)
+}
+
+func TestMemorySlidingWindow(t *testing.T) {
+	ctx := context.Background()
+	l, now := newTestMemory(SlidingWindow, PerSecond(2))
+
+	l.Allow(ctx, "k")
+	*now = now.Add(600 * time.Millisecond)
+	l.Allow(ctx, "k")
+	res, _ := l.Allow(ctx, "k")
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 400*time.Millisecond, res.RetryAfter)
+
+	// 窗口滑过第一次请求后放行一次
+	*now = now.Add(400 * time.Millisecond)
+	res, _ = l.Allow(ctx, "k")
+	assert.True(t, res.Allowed)
+	res, _ = l.Allow(ctx, "k")
+	assert.False(t, res.Allowed)
+
+	res, _ = l.AllowN(ctx, "big", 3)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, time.Second, res.RetryAfter)
+}
+
+func TestMemoryTokenBucket(t *testing.T) {
+	ctx := context.Background()
+	l, now := newTestMemory(TokenBucket, Rate{Limit: 10, Period: time.Second, Burst: 5})
+
+	res, _ := l.AllowN(ctx, "k", 5)
+	assert.True(t, res.Allowed, "burst is available at once")
+	assert.Equal(t, int64(0), res.Remaining)
+	res, _ = l.Allow(ctx, "k")
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 100*time.Millisecond, res.RetryAfter)
+
+	*now = now.Add(250 * time.Millisecond)
+	res, _ = l.AllowN(ctx, "k", 2)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, int64(0), res.Remaining)
+
+	// 令牌不超过容量
+	*now = now.Add(time.Hour)
+	res, _ = l.AllowN(ctx, "k", 6)
+	assert.False(t, res.Allowed)
+}
+
+type failingLimiter struct{}
+
+func (failingLimiter) Allow(ctx context.Context, key string) (Result, error) {
+	return Result{}, assert.AnError
+}
+
+func (failingLimiter) AllowN(ctx context.Context, key string, n int64) (Result, error) {
+	return Result{}, assert.AnError
+}
+
+func TestFallback(t *testing.T) {
+	l := WithFallback(failingLimiter{}, NewMemory(FixedWindow, PerMinute(1)))
+	res, err := l.Allow(context.Background(), "k")
+	assert.NoError(t, err)
+	assert.True(t, res.Allowed)
+	res, _ = l.Allow(context.Background(), "k")
+	assert.False(t, res.Allowed)
+}
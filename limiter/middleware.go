@@ -0,0 +1,72 @@
+package limiter
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/XingMenTech/common"
+	"github.com/XingMenTech/common/fasthttp/routing"
+	"github.com/gin-gonic/gin"
+)
+
+// GinMiddleware gin限流中间件，keyFunc 为nil时按客户端IP与请求路径限流
+// 超出限制时返回429及 CommonTooManyRequests，限流器出错时放行
+func GinMiddleware(l Limiter, keyFunc func(*gin.Context) string) gin.HandlerFunc {
+	if nil == keyFunc {
+		keyFunc = func(c *gin.Context) string {
+			return c.ClientIP() + ":" + c.FullPath()
+		}
+	}
+	return func(c *gin.Context) {
+		res, err := l.Allow(c.Request.Context(), keyFunc(c))
+		if err != nil {
+			c.Next()
+			return
+		}
+		writeHeaders(c.Header, res)
+		if res.Allowed {
+			c.Next()
+			return
+		}
+
+		returnData := new(common.DataResponse)
+		returnData.Code = common.CommonTooManyRequests
+		returnData.Message = common.CodeMapMessage[common.CommonTooManyRequests]
+		c.Header("Cache-Control", "no-cache, no-store, must-revalidate")
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, returnData)
+	}
+}
+
+// RoutingHandler fasthttp路由限流处理器，keyFunc 为nil时按客户端IP与请求路径限流
+// 超出限制时返回429及 CommonTooManyRequests，限流器出错时放行
+func RoutingHandler(l Limiter, keyFunc func(*routing.Context) string) routing.Handler {
+	if nil == keyFunc {
+		keyFunc = func(c *routing.Context) string {
+			return c.RemoteIP() + ":" + string(c.Path())
+		}
+	}
+	return func(c *routing.Context) common.Error {
+		res, err := l.Allow(c, keyFunc(c))
+		if err != nil {
+			return nil
+		}
+		writeHeaders(func(key, value string) {
+			c.Response.Header.Set(key, value)
+		}, res)
+		if res.Allowed {
+			return nil
+		}
+		c.SetStatusCode(http.StatusTooManyRequests)
+		return common.NewError(common.CommonTooManyRequests)
+	}
+}
+
+// writeHeaders 写入限流响应头，Retry-After 以秒为单位向上取整
+func writeHeaders(set func(key, value string), res Result) {
+	set("X-RateLimit-Limit", strconv.FormatInt(res.Limit, 10))
+	set("X-RateLimit-Remaining", strconv.FormatInt(res.Remaining, 10))
+	if !res.Allowed {
+		set("Retry-After", strconv.FormatInt(int64(math.Ceil(res.RetryAfter.Seconds())), 10))
+	}
+}
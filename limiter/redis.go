@@ -0,0 +1,139 @@
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/XingMenTech/common/redis"
+	"github.com/XingMenTech/common/utils"
+)
+
+const keyPrefix = "limiter:"
+
+var (
+	// fixedWindowScript 计数加 n，首次计数时设置窗口过期时间，返回 {计数, 窗口剩余毫秒}
+	fixedWindowScript = redis.NewScript(`
+local count = redis.call("INCRBY", KEYS[1], ARGV[1])
+local ttl = redis.call("PTTL", KEYS[1])
+if ttl < 0 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+	ttl = tonumber(ARGV[2])
+end
+return {count, ttl}`)
+
+	// slidingWindowScript 移除窗口外的记录，容量足够时记录本次请求，返回 {是否通过, 剩余次数, 重试毫秒}
+	slidingWindowScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+local n = tonumber(ARGV[4])
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", now - period)
+local count = redis.call("ZCARD", KEYS[1])
+if count + n <= limit then
+	for i = 1, n do
+		redis.call("ZADD", KEYS[1], now, ARGV[5] .. ":" .. i)
+	end
+	redis.call("PEXPIRE", KEYS[1], period)
+	return {1, limit - count - n, 0}
+end
+local retry = period
+if n <= limit then
+	local oldest = redis.call("ZRANGE", KEYS[1], count + n - limit - 1, count + n - limit - 1, "WITHSCORES")
+	if oldest[2] then
+		retry = tonumber(oldest[2]) + period - now
+	end
+end
+return {0, math.max(limit - count, 0), retry}`)
+
+	// tokenBucketScript 按经过的时间补充令牌，令牌足够时扣除，返回 {是否通过, 剩余令牌, 重试毫秒}
+	tokenBucketScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local capacity = tonumber(ARGV[3])
+local n = tonumber(ARGV[4])
+local state = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+	tokens = capacity
+	ts = now
+end
+if now > ts then
+	tokens = math.min(capacity, tokens + (now - ts) * rate)
+	ts = now
+end
+local allowed = 0
+local retry = 0
+if tokens >= n then
+	tokens = tokens - n
+	allowed = 1
+else
+	retry = math.ceil((n - tokens) / rate)
+end
+redis.call("HMSET", KEYS[1], "tokens", tostring(tokens), "ts", ts)
+redis.call("PEXPIRE", KEYS[1], math.ceil(capacity / rate) + 1000)
+return {allowed, math.floor(tokens), retry}`)
+)
+
+type redisLimiter struct {
+	c         *redis.Client
+	algorithm Algorithm
+	rate      Rate
+	now       func() time.Time
+}
+
+// NewRedis 创建基于redis的限流器，多副本共享计数
+func NewRedis(c *redis.Client, algorithm Algorithm, rate Rate) Limiter {
+	return &redisLimiter{c: c, algorithm: algorithm, rate: rate, now: time.Now}
+}
+
+func (l *redisLimiter) Allow(ctx context.Context, key string) (Result, error) {
+	return l.AllowN(ctx, key, 1)
+}
+
+func (l *redisLimiter) AllowN(ctx context.Context, key string, n int64) (Result, error) {
+	keys := []string{keyPrefix + string(l.algorithm) + ":" + key}
+	now := l.now().UnixMilli()
+	period := l.rate.Period.Milliseconds()
+
+	switch l.algorithm {
+	case FixedWindow:
+		vals, err := fixedWindowScript.RunOn(ctx, l.c, keys, n, period).Int64Slice()
+		if err != nil {
+			return Result{}, err
+		}
+		res := Result{Allowed: vals[0] <= l.rate.Limit, Limit: l.rate.Limit, Remaining: max(l.rate.Limit-vals[0], 0)}
+		if !res.Allowed {
+			res.RetryAfter = time.Duration(vals[1]) * time.Millisecond
+		}
+		return res, nil
+	case SlidingWindow:
+		member := fmt.Sprintf("%d-%s", now, utils.RandomString(8))
+		vals, err := slidingWindowScript.RunOn(ctx, l.c, keys, now, period, l.rate.Limit, n, member).Int64Slice()
+		if err != nil {
+			return Result{}, err
+		}
+		return l.result(vals, l.rate.Limit), nil
+	case TokenBucket:
+		perMs := strconv.FormatFloat(float64(l.rate.Limit)/float64(period), 'g', -1, 64)
+		vals, err := tokenBucketScript.RunOn(ctx, l.c, keys, now, perMs, l.rate.burst(), n).Int64Slice()
+		if err != nil {
+			return Result{}, err
+		}
+		return l.result(vals, l.rate.burst()), nil
+	default:
+		return Result{}, fmt.Errorf("limiter: unknown algorithm %q", l.algorithm)
+	}
+}
+
+// result 将 {是否通过, 剩余, 重试毫秒} 转换为 Result
+func (l *redisLimiter) result(vals []int64, limit int64) Result {
+	return Result{
+		Allowed:    1 == vals[0],
+		Limit:      limit,
+		Remaining:  vals[1],
+		RetryAfter: time.Duration(vals[2]) * time.Millisecond,
+	}
+}
@@ -0,0 +1,114 @@
+package limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/XingMenTech/common/redis"
+	"github.com/XingMenTech/common/redis/redistest"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRedis(t *testing.T, algorithm Algorithm, rate Rate) (*redisLimiter, *redistest.Server, *time.Time) {
+	srv := redistest.Run(t)
+	c, err := redis.NewClient(&redis.Config{Prefix: "limiter", Host: srv.Addr()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+
+	now := time.Unix(1700000000, 0)
+	l := NewRedis(c, algorithm, rate).(*redisLimiter)
+	l.now = func() time.Time { return now }
+	return l, srv, &now
+}
+
+func TestRedisFixedWindow(t *testing.T) {
+	ctx := context.Background()
+	l, srv, _ := newTestRedis(t, FixedWindow, PerSecond(2))
+
+	for i := 0; i < 2; i++ {
+		res, err := l.Allow(ctx, "k")
+		assert.NoError(t, err)
+		assert.True(t, res.Allowed)
+		assert.Equal(t, int64(1-i), res.Remaining)
+	}
+	res, _ := l.Allow(ctx, "k")
+	assert.False(t, res.Allowed)
+	assert.Equal(t, time.Second, res.RetryAfter)
+
+	other, _ := l.Allow(ctx, "other")
+	assert.True(t, other.Allowed, "keys are limited separately")
+
+	// 窗口结束前仍被限制，结束后重置
+	srv.FastForward(999 * time.Millisecond)
+	res, _ = l.Allow(ctx, "k")
+	assert.False(t, res.Allowed)
+	assert.Equal(t, time.Millisecond, res.RetryAfter)
+	srv.FastForward(time.Millisecond)
+	res, _ = l.Allow(ctx, "k")
+	assert.True(t, res.Allowed)
+	assert.Equal(t, int64(1), res.Remaining)
+}
+
+func TestRedisSlidingWindow(t *testing.T) {
+	ctx := context.Background()
+	l, _, now := newTestRedis(t, SlidingWindow, PerSecond(2))
+
+	l.Allow(ctx, "k")
+	*now = now.Add(600 * time.Millisecond)
+	l.Allow(ctx, "k")
+	res, _ := l.Allow(ctx, "k")
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 400*time.Millisecond, res.RetryAfter)
+
+	// 第一次请求滑出窗口前仍被限制，滑出后放行一次
+	*now = now.Add(399 * time.Millisecond)
+	res, _ = l.Allow(ctx, "k")
+	assert.False(t, res.Allowed)
+	assert.Equal(t, time.Millisecond, res.RetryAfter)
+	*now = now.Add(time.Millisecond)
+	res, _ = l.Allow(ctx, "k")
+	assert.True(t, res.Allowed)
+	assert.Equal(t, int64(0), res.Remaining)
+	res, _ = l.Allow(ctx, "k")
+	assert.False(t, res.Allowed)
+
+	res, _ = l.AllowN(ctx, "big", 3)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, time.Second, res.RetryAfter)
+}
+
+func TestRedisTokenBucket(t *testing.T) {
+	ctx := context.Background()
+	l, _, now := newTestRedis(t, TokenBucket, Rate{Limit: 10, Period: time.Second, Burst: 5})
+
+	res, _ := l.AllowN(ctx, "k", 5)
+	assert.True(t, res.Allowed, "burst is available at once")
+	assert.Equal(t, int64(0), res.Remaining)
+	res, _ = l.Allow(ctx, "k")
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 100*time.Millisecond, res.RetryAfter)
+
+	// 补充满一个令牌前仍被限制，令牌为浮点数，重试时间向上取整
+	*now = now.Add(99 * time.Millisecond)
+	res, _ = l.Allow(ctx, "k")
+	assert.False(t, res.Allowed)
+	assert.LessOrEqual(t, res.RetryAfter, 2*time.Millisecond)
+	*now = now.Add(time.Millisecond)
+	res, _ = l.Allow(ctx, "k")
+	assert.True(t, res.Allowed)
+
+	*now = now.Add(250 * time.Millisecond)
+	res, _ = l.AllowN(ctx, "k", 2)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, int64(0), res.Remaining)
+
+	// 令牌不超过容量
+	*now = now.Add(time.Hour)
+	res, _ = l.AllowN(ctx, "k", 5)
+	assert.True(t, res.Allowed)
+	res, _ = l.AllowN(ctx, "k", 6)
+	assert.False(t, res.Allowed)
+}
@@ -0,0 +1,257 @@
+package redis
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/XingMenTech/common/utils"
+	"github.com/go-redis/redis/v8"
+	"golang.org/x/sync/singleflight"
+)
+
+// Client redis客户端实例，KEY 自动添加 Config.Prefix 前缀
+// 一个服务可同时持有多个实例(如缓存库与会话库)，所有方法均接收 context 以支持超时与取消
+// Go 方法不支持类型参数，泛型读取通过 As[T](client) 得到的 Typed[T] 完成
+type Client struct {
+	rdb     redis.UniversalClient
+	prefix  string
+	hashTag bool
+	cluster bool
+	loads   singleflight.Group
+	localMu sync.RWMutex
+	local   *LocalCache
+	codec   Codec
+}
+
+// NewClient 根据配置创建客户端并检查连接
+func NewClient(config *Config) (*Client, error) {
+	opts, err := universalOptions(config)
+	if err != nil {
+		return nil, err
+	}
+
+	var rdb redis.UniversalClient
+	switch config.Mode {
+	case ModeCluster:
+		rdb = redis.NewClusterClient(opts.Cluster())
+	case ModeSentinel:
+		if "" == opts.MasterName {
+			return nil, errors.New("redis sentinel mode requires masterName")
+		}
+		rdb = redis.NewFailoverClient(opts.Failover())
+	case "", ModeStandalone:
+		rdb = redis.NewClient(opts.Simple())
+	default:
+		return nil, fmt.Errorf("unknown redis mode %q", config.Mode)
+	}
+
+	if err = rdb.Ping(context.Background()).Err(); err != nil {
+		rdb.Close()
+		return nil, errors.New(fmt.Sprintf("can't connect redis service %v", err))
+	}
+	return &Client{
+		rdb:     rdb,
+		prefix:  config.Prefix,
+		hashTag: config.HashTagPrefix,
+		cluster: ModeCluster == config.Mode,
+		codec:   JSONCodec,
+	}, nil
+}
+
+func universalOptions(config *Config) (*redis.UniversalOptions, error) {
+	addrs := config.Addrs
+	if 0 == len(addrs) {
+		addrs = []string{config.Host}
+	}
+	opts := &redis.UniversalOptions{
+		Addrs:            addrs,
+		DB:               utils.StringToInt(config.DbNum),
+		Password:         config.Password,
+		MasterName:       config.MasterName,
+		SentinelPassword: config.SentinelPassword,
+		PoolSize:         config.PoolSize,
+		MinIdleConns:     config.MinIdleConns,
+		DialTimeout:      time.Duration(config.DialTimeout) * time.Millisecond,
+		ReadTimeout:      time.Duration(config.ReadTimeout) * time.Millisecond,
+		WriteTimeout:     time.Duration(config.WriteTimeout) * time.Millisecond,
+		PoolTimeout:      time.Duration(config.PoolTimeout) * time.Millisecond,
+	}
+	if config.TLS {
+		tlsConfig, err := newTLSConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		opts.TLSConfig = tlsConfig
+	}
+	return opts, nil
+}
+
+func newTLSConfig(config *Config) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: config.TLSSkipVerify,
+	}
+	if "" != config.TLSCAFile {
+		pem, err := os.ReadFile(config.TLSCAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in %s", config.TLSCAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if "" != config.TLSCertFile {
+		cert, err := tls.LoadX509KeyPair(config.TLSCertFile, config.TLSKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// Default 返回 InitRedisCache 初始化的默认实例，包级函数均委托给该实例
+func Default() *Client {
+	return defaultClient
+}
+
+// Redis 返回底层 go-redis 客户端，集群模式下为 *redis.ClusterClient
+func (c *Client) Redis() redis.UniversalClient {
+	return c.rdb
+}
+
+// Prefix 返回KEY前缀
+func (c *Client) Prefix() string {
+	return c.prefix
+}
+
+// Key 返回添加前缀后的完整KEY
+func (c *Client) Key(originKey interface{}) string {
+	if c.hashTag {
+		return fmt.Sprintf("{%s}:%s", c.prefix, originKey)
+	}
+	return fmt.Sprintf("%s:%s", c.prefix, originKey)
+}
+
+func (c *Client) keys(originKeys []string) []string {
+	arr := make([]string, len(originKeys))
+	for i, v := range originKeys {
+		arr[i] = c.Key(v)
+	}
+	return arr
+}
+
+// SetCodec 设置复杂类型的编码方式，默认 JSONCodec，应在读写前设置
+func (c *Client) SetCodec(codec Codec) {
+	c.codec = codec
+}
+
+// Codec 返回复杂类型的编码方式
+func (c *Client) Codec() Codec {
+	return c.codec
+}
+
+func (c *Client) encode(val interface{}) (string, error) {
+	return encodeWith(c.codec, val)
+}
+
+func (c *Client) encodeAll(vals []interface{}) ([]interface{}, error) {
+	arr := make([]interface{}, len(vals))
+	for i, v := range vals {
+		val, err := c.encode(v)
+		if err != nil {
+			return nil, err
+		}
+		arr[i] = val
+	}
+	return arr, nil
+}
+
+// Close 关闭连接
+func (c *Client) Close() error {
+	return c.rdb.Close()
+}
+
+// IsExist check if cached value exists or not.
+func (c *Client) IsExist(ctx context.Context, key string) bool {
+	val := c.rdb.Exists(ctx, c.Key(key)).Val()
+	return val != 0
+}
+
+// Delete delete cached value by key.
+func (c *Client) Delete(ctx context.Context, key string) error {
+	fullKey := c.Key(key)
+	return c.changed(ctx, c.rdb.Del(ctx, fullKey).Err(), fullKey)
+}
+
+// Subscribe 订阅主题
+func (c *Client) Subscribe(ctx context.Context, channel ...string) *redis.PubSub {
+	return c.rdb.Subscribe(ctx, channel...)
+}
+
+// PSubscribe 订阅主题
+func (c *Client) PSubscribe(ctx context.Context, channel ...string) *redis.PubSub {
+	return c.rdb.PSubscribe(ctx, channel...)
+}
+
+// Publish 发布主题消息
+func (c *Client) Publish(ctx context.Context, channel string, msg interface{}) error {
+	msgByte, err := c.encode(msg)
+	if err != nil {
+		return err
+	}
+	return c.rdb.Publish(ctx, channel, msgByte).Err()
+}
+
+// ClearAll 以 UNLINK 分批删除当前前缀下的所有KEY，不影响共享实例上的其他数据，清空整个实例使用 FlushAll
+func (c *Client) ClearAll(ctx context.Context) error {
+	_, err := c.unlinkMatched(ctx, "*", false)
+	return c.changed(ctx, err)
+}
+
+// ExpireAt 设置KEY在指定时间过期
+func (c *Client) ExpireAt(ctx context.Context, key string, t time.Time) error {
+	return c.rdb.ExpireAt(ctx, c.Key(key), t).Err()
+}
+
+// ExpireIn 设置KEY在指定时长后过期
+func (c *Client) ExpireIn(ctx context.Context, key string, d time.Duration) error {
+	return c.rdb.Expire(ctx, c.Key(key), d).Err()
+}
+
+// Typed 客户端的泛型视图，读取结果解码为T
+type Typed[T any] struct {
+	c     *Client
+	codec Codec
+}
+
+// As 返回客户端的泛型视图
+func As[T any](c *Client) Typed[T] {
+	return Typed[T]{c: c, codec: c.codec}
+}
+
+// WithCodec 返回使用 codec 编解码的泛型视图，不影响客户端的默认编码
+func (t Typed[T]) WithCodec(codec Codec) Typed[T] {
+	t.codec = codec
+	return t
+}
+
+func (t Typed[T]) encode(val interface{}) (string, error) {
+	return encodeWith(t.codec, val)
+}
+
+func (t Typed[T]) decode(data string) (T, error) {
+	return decodeValWith[T](t.codec, data)
+}
+
+func (t Typed[T]) decodeArr(data []string) ([]T, error) {
+	return decodeArrWith[T](t.codec, data)
+}
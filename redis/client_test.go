@@ -0,0 +1,51 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestClientInstances 测试同一服务使用多个实例
+func TestClientInstances(t *testing.T) {
+	session, err := NewClient(&Config{
+		Prefix: "session",
+		Host:   testAddr,
+		DbNum:  "1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err = session.Set(ctx, "test_instance", &args{Name: "session"}, 10); err != nil {
+		t.Fatal(err)
+	}
+	if err = Set("test_instance", &args{Name: "default"}, 10); err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := As[args](session).Get(ctx, "test_instance")
+	if err != nil || v.Name != "session" {
+		t.Errorf("Expected session value, got %v %v", v, err)
+	}
+	v, err = Get[args]("test_instance")
+	if err != nil || v.Name != "default" {
+		t.Errorf("Expected default value, got %v %v", v, err)
+	}
+	if session.Key("test_instance") != "session:test_instance" {
+		t.Errorf("unexpected key %s", session.Key("test_instance"))
+	}
+
+	done, stop := context.WithCancel(context.Background())
+	stop()
+	if err = session.Set(done, "test_instance", "x", 10); err == nil {
+		t.Error("Expected error for a cancelled context")
+	}
+
+	session.Delete(ctx, "test_instance")
+	Delete("test_instance")
+}
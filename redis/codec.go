@@ -0,0 +1,358 @@
+// 值编解码
+// 字符串、数字、布尔与 []byte 始终按原样写入，保证 INCR/APPEND 等命令可用，其余类型交由 Codec 序列化
+// Codec 可按客户端设置(Client.SetCodec)，也可按次调用指定：写入时用 Coded 包装值，读取时用 Typed.WithCodec
+// 示例
+// c.SetCodec(redis.Compress(redis.MsgpackCodec, redis.CompressSnappy, 1024))
+// c.Set(ctx, "user", redis.Coded(user, redis.GobCodec), 60)
+// user, err := redis.As[User](c).WithCodec(redis.GobCodec).Get(ctx, "user")
+
+package redis
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+
+	"github.com/klauspost/compress/snappy"
+	ugorji "github.com/ugorji/go/codec"
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrNotProtoMessage ProtobufCodec 的值不是 proto.Message
+var ErrNotProtoMessage = errors.New("redis codec: value is not a proto.Message")
+
+// Codec 复杂类型的序列化方式
+type Codec interface {
+	Name() string
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+var (
+	// JSONCodec 默认编码
+	JSONCodec Codec = jsonCodec{}
+	// MsgpackCodec msgpack编码，字段名取 codec 或 json 标签
+	MsgpackCodec Codec = msgpackCodec{}
+	// ProtobufCodec protobuf编码，值须为 proto.Message
+	ProtobufCodec Codec = protobufCodec{}
+	// GobCodec gob编码，仅适用于Go服务之间
+	GobCodec Codec = gobCodec{}
+)
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string                       { return "json" }
+func (jsonCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+var msgpackHandle = &ugorji.MsgpackHandle{}
+
+func init() {
+	msgpackHandle.RawToString = true
+	msgpackHandle.WriteExt = true
+}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Name() string { return "msgpack" }
+
+func (msgpackCodec) Marshal(v any) ([]byte, error) {
+	var data []byte
+	err := ugorji.NewEncoderBytes(&data, msgpackHandle).Encode(v)
+	return data, err
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v any) error {
+	return ugorji.NewDecoderBytes(data, msgpackHandle).Decode(v)
+}
+
+type protobufCodec struct{}
+
+func (protobufCodec) Name() string { return "protobuf" }
+
+func (protobufCodec) Marshal(v any) ([]byte, error) {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return nil, ErrNotProtoMessage
+	}
+	return proto.Marshal(msg)
+}
+
+func (protobufCodec) Unmarshal(data []byte, v any) error {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		// 目标为 **Message 时分配消息
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Ptr {
+			return ErrNotProtoMessage
+		}
+		if rv.Elem().IsNil() {
+			rv.Elem().Set(reflect.New(rv.Elem().Type().Elem()))
+		}
+		if msg, ok = rv.Elem().Interface().(proto.Message); !ok {
+			return ErrNotProtoMessage
+		}
+	}
+	return proto.Unmarshal(data, msg)
+}
+
+type gobCodec struct{}
+
+func (gobCodec) Name() string { return "gob" }
+
+func (gobCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(v)
+	return buf.Bytes(), err
+}
+
+func (gobCodec) Unmarshal(data []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// Compression 压缩算法
+type Compression byte
+
+// 压缩后的数据以1字节标记开头，标记未知时按未压缩数据处理，兼容开启压缩前写入的值
+const (
+	CompressNone Compression = iota
+	CompressGzip
+	CompressSnappy
+)
+
+type compressCodec struct {
+	codec     Codec
+	algo      Compression
+	threshold int
+}
+
+// Compress 在 codec 之上增加压缩，序列化结果不小于 threshold 字节时压缩
+func Compress(codec Codec, algo Compression, threshold int) Codec {
+	return &compressCodec{codec: codec, algo: algo, threshold: threshold}
+}
+
+func (c *compressCodec) Name() string {
+	switch c.algo {
+	case CompressGzip:
+		return c.codec.Name() + "+gzip"
+	case CompressSnappy:
+		return c.codec.Name() + "+snappy"
+	default:
+		return c.codec.Name()
+	}
+}
+
+func (c *compressCodec) Marshal(v any) ([]byte, error) {
+	data, err := c.codec.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < c.threshold {
+		return append([]byte{byte(CompressNone)}, data...), nil
+	}
+
+	switch c.algo {
+	case CompressGzip:
+		var buf bytes.Buffer
+		buf.WriteByte(byte(CompressGzip))
+		w := gzip.NewWriter(&buf)
+		if _, err = w.Write(data); err != nil {
+			return nil, err
+		}
+		if err = w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case CompressSnappy:
+		return append([]byte{byte(CompressSnappy)}, snappy.Encode(nil, data)...), nil
+	default:
+		return append([]byte{byte(CompressNone)}, data...), nil
+	}
+}
+
+func (c *compressCodec) Unmarshal(data []byte, v any) error {
+	if 0 == len(data) {
+		return c.codec.Unmarshal(data, v)
+	}
+
+	var err error
+	switch Compression(data[0]) {
+	case CompressNone:
+		data = data[1:]
+	case CompressGzip:
+		var r *gzip.Reader
+		if r, err = gzip.NewReader(bytes.NewReader(data[1:])); err != nil {
+			return err
+		}
+		data, err = io.ReadAll(r)
+		r.Close()
+	case CompressSnappy:
+		data, err = snappy.Decode(nil, data[1:])
+	}
+	if err != nil {
+		return err
+	}
+	return c.codec.Unmarshal(data, v)
+}
+
+// codedValue 指定了编码方式的值
+type codedValue struct {
+	v     any
+	codec Codec
+}
+
+// Coded 包装写入的值，本次写入使用 codec 编码
+func Coded(v any, codec Codec) any {
+	return codedValue{v: v, codec: codec}
+}
+
+// encodeWith 将值编码为写入redis的字符串，基本类型直接格式化，其余类型使用 codec
+func encodeWith(codec Codec, data any) (string, error) {
+	if cv, ok := data.(codedValue); ok {
+		return encodeWith(cv.codec, cv.v)
+	}
+	if nil == data {
+		return "", errors.New("data is nil")
+	}
+
+	// 如果是指针，获取其指向的值
+	val := reflect.ValueOf(data)
+	for val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return "", errors.New("data is nil")
+		}
+		val = val.Elem()
+	}
+
+	switch val.Kind() {
+	case reflect.String:
+		return val.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(val.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(val.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(val.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(val.Float(), 'g', -1, val.Type().Bits()), nil
+	case reflect.Slice:
+		// 检查是否为 []byte 类型
+		if val.Type().Elem().Kind() == reflect.Uint8 {
+			return string(val.Bytes()), nil
+		}
+	}
+
+	if nil == codec {
+		codec = JSONCodec
+	}
+	bytes, err := codec.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	return string(bytes), nil
+}
+
+// decodeWith 将 encodeWith 得到的字符串解码到指针 v 指向的值
+func decodeWith(codec Codec, data string, v any) error {
+	if data == "" {
+		return errors.New("data is nil")
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("decode target must be a non-nil pointer, got %T", v)
+	}
+
+	// 指向基本类型的指针，分配后按基本类型处理
+	target := rv.Elem()
+	if target.Kind() == reflect.Ptr && isPlain(target.Type().Elem()) {
+		if target.IsNil() {
+			target.Set(reflect.New(target.Type().Elem()))
+		}
+		return decodeWith(codec, data, target.Interface())
+	}
+
+	// 处理基本类型，按 Kind 赋值以支持自定义的命名类型
+	switch target.Kind() {
+	case reflect.String:
+		target.SetString(data)
+		return nil
+	case reflect.Bool:
+		target.SetBool(data == "true" || data == "1" || data == "True" || data == "TRUE")
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(data, 10, target.Type().Bits())
+		if err != nil {
+			return err
+		}
+		target.SetInt(n)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(data, 10, target.Type().Bits())
+		if err != nil {
+			return err
+		}
+		target.SetUint(n)
+		return nil
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(data, target.Type().Bits())
+		if err != nil {
+			return err
+		}
+		target.SetFloat(f)
+		return nil
+	case reflect.Slice:
+		// 检查是否为 []byte 类型
+		if target.Type().Elem().Kind() == reflect.Uint8 {
+			target.SetBytes([]byte(data))
+			return nil
+		}
+	}
+
+	if nil == codec {
+		codec = JSONCodec
+	}
+	return codec.Unmarshal([]byte(data), v)
+}
+
+// isPlain 是否为按原样写入的基本类型
+func isPlain(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.Uint8
+	}
+	return false
+}
+
+// decodeValWith 将字符串解码为T
+func decodeValWith[T any](codec Codec, data string) (T, error) {
+	var res T
+	if err := decodeWith(codec, data, &res); err != nil {
+		var zero T
+		return zero, err
+	}
+	return res, nil
+}
+
+// decodeArrWith 将字符串数组解码为T数组
+func decodeArrWith[T any](codec Codec, data []string) ([]T, error) {
+	res := make([]T, len(data))
+	for i, v := range data {
+		val, err := decodeValWith[T](codec, v)
+		if err != nil {
+			return nil, err
+		}
+		res[i] = val
+	}
+	return res, nil
+}
@@ -0,0 +1,132 @@
+package redis
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+type status string
+
+func testCodecs() []Codec {
+	return []Codec{
+		JSONCodec,
+		MsgpackCodec,
+		GobCodec,
+		Compress(JSONCodec, CompressGzip, 16),
+		Compress(MsgpackCodec, CompressSnappy, 16),
+		Compress(GobCodec, CompressGzip, 1<<20),
+	}
+}
+
+func roundTrip[T any](t *testing.T, codec Codec, in T) {
+	data, err := encodeWith(codec, in)
+	if err != nil {
+		t.Fatalf("%s encode %T: %v", codec.Name(), in, err)
+	}
+	out, err := decodeValWith[T](codec, data)
+	if err != nil {
+		t.Fatalf("%s decode %T: %v", codec.Name(), in, err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("%s round trip %T: %v != %v", codec.Name(), in, out, in)
+	}
+}
+
+// TestCodecRoundTrip 各编码方式的编解码往返
+func TestCodecRoundTrip(t *testing.T) {
+	long := &args{Name: strings.Repeat("name", 64), Age: 18, Phone: "phone"}
+	for _, codec := range testCodecs() {
+		roundTrip(t, codec, "plain")
+		roundTrip(t, codec, status("named"))
+		roundTrip(t, codec, []byte("bytes"))
+		roundTrip(t, codec, 42)
+		roundTrip(t, codec, int8(-8))
+		roundTrip(t, codec, uint32(32))
+		roundTrip(t, codec, float32(0.1))
+		roundTrip(t, codec, 3.14)
+		roundTrip(t, codec, true)
+		roundTrip(t, codec, args{Name: "name", Age: 1})
+		roundTrip(t, codec, long)
+		roundTrip(t, codec, []args{{Name: "a"}, {Name: "b"}})
+		roundTrip(t, codec, map[string]int{"a": 1})
+	}
+
+	in := &structpb.Struct{Fields: map[string]*structpb.Value{"name": structpb.NewStringValue("pb")}}
+	for _, codec := range []Codec{ProtobufCodec, Compress(ProtobufCodec, CompressSnappy, 0)} {
+		data, err := encodeWith(codec, in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := decodeValWith[*structpb.Struct](codec, data)
+		if err != nil || !proto.Equal(in, out) {
+			t.Errorf("%s round trip: %v %v", codec.Name(), out, err)
+		}
+	}
+	if _, err := encodeWith(ProtobufCodec, args{}); err != ErrNotProtoMessage {
+		t.Errorf("expect ErrNotProtoMessage, got %v", err)
+	}
+}
+
+// TestDecodePlain decode 对 string 与 []byte 目标赋值
+func TestDecodePlain(t *testing.T) {
+	var s string
+	if err := decode("value", &s); err != nil || s != "value" {
+		t.Errorf("decode string: %q %v", s, err)
+	}
+	var b []byte
+	if err := decode("value", &b); err != nil || string(b) != "value" {
+		t.Errorf("decode bytes: %q %v", b, err)
+	}
+	var p *int
+	if err := decode("7", &p); err != nil || p == nil || *p != 7 {
+		t.Errorf("decode *int: %v %v", p, err)
+	}
+	if err := decode("x", s); err == nil {
+		t.Error("expect error for a non-pointer target")
+	}
+}
+
+// TestClientCodec 通过客户端与泛型读取函数使用各编码方式
+func TestClientCodec(t *testing.T) {
+	ctx := context.Background()
+	c, err := NewClient(&Config{Prefix: "codec", Host: testAddr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	in := args{Name: strings.Repeat("n", 100), Age: 3}
+	for _, codec := range testCodecs() {
+		c.SetCodec(codec)
+		if err = c.Set(ctx, "test_codec", in, 10); err != nil {
+			t.Fatal(err)
+		}
+		if out, err := As[args](c).Get(ctx, "test_codec"); err != nil || out != in {
+			t.Errorf("%s client codec: %v %v", codec.Name(), out, err)
+		}
+		if err = c.HSet(ctx, "test_codec_h", "f", &in); err != nil {
+			t.Fatal(err)
+		}
+		if out, err := As[*args](c).HGet(ctx, "test_codec_h", "f"); err != nil || *out != in {
+			t.Errorf("%s client codec hash: %v %v", codec.Name(), out, err)
+		}
+	}
+
+	// 按次调用指定编码
+	c.SetCodec(JSONCodec)
+	if err = c.RPush(ctx, "test_codec_l", Coded(in, GobCodec)); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := As[args](c).WithCodec(GobCodec).LPop(ctx, "test_codec_l"); err != nil || out != in {
+		t.Errorf("per call codec: %v %v", out, err)
+	}
+
+	c.Delete(ctx, "test_codec")
+	c.Delete(ctx, "test_codec_h")
+	c.Delete(ctx, "test_codec_l")
+}
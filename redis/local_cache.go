@@ -0,0 +1,376 @@
+// 进程内二级缓存
+// LocalCache 以LRU+TTL的方式在进程内缓存 Get/HGet 读取到的原始值，命中时不再访问redis
+// 通过所属 Client 修改KEY(Set/Delete/HSet 等)时立即淘汰本地副本，并通过 Publish 广播给其他副本淘汰
+// 只有开启了本地缓存的 Client 才会广播，未开启时写入不产生额外的 PUBLISH
+// 会触发失效的写入：字符串与哈希的写方法(Set/SetEX/Incr/HSet/HDel 等)、Delete/DeleteByPattern/ClearAll/FlushAll、Pipeline.Exec、布隆过滤器、GetOrLoad 回源写回
+// 不会触发失效的写入：Lock、Script、DurableQueue、Stream、列表/集合/有序集合方法以及通过 Redis() 直接执行的命令
+// 以上方式修改被本地缓存的KEY后，需调用 Invalidate 通知各副本
+// 示例
+// local, err := redis.EnableLocalCache(redis.WithLocalSize(1000), redis.WithLocalTTL(30*time.Second))
+// conf, err := redis.LocalGet[*SiteConfig]("site_config")
+// redis.Set("site_config", conf, 0) // 所有副本的本地缓存同时失效
+
+package redis
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	defaultLocalSize   = 10000
+	defaultLocalTTL    = time.Minute
+	localChannelSuffix = "__local_invalidate"
+	localRetryInterval = time.Second
+	localInvalidateAll = "*"
+)
+
+// ErrLocalCacheEnabled Client 已开启本地缓存
+var ErrLocalCacheEnabled = errors.New("redis local cache: already enabled")
+
+// LocalOption 本地缓存选项
+type LocalOption func(*localOptions)
+
+type localOptions struct {
+	size    int
+	ttl     time.Duration
+	keyTTL  map[string]time.Duration
+	channel string
+}
+
+// WithLocalSize 最多缓存的KEY数量，超出后淘汰最久未使用的KEY，默认10000
+func WithLocalSize(size int) LocalOption {
+	return func(o *localOptions) {
+		o.size = size
+	}
+}
+
+// WithLocalTTL 本地副本的默认有效期，默认1分钟
+func WithLocalTTL(ttl time.Duration) LocalOption {
+	return func(o *localOptions) {
+		o.ttl = ttl
+	}
+}
+
+// WithLocalKeyTTL 为指定KEY单独设置本地副本有效期，为0时该KEY不做本地缓存
+func WithLocalKeyTTL(key string, ttl time.Duration) LocalOption {
+	return func(o *localOptions) {
+		o.keyTTL[key] = ttl
+	}
+}
+
+// WithInvalidateChannel 失效广播使用的频道，默认为 前缀:__local_invalidate
+func WithInvalidateChannel(channel string) LocalOption {
+	return func(o *localOptions) {
+		o.channel = channel
+	}
+}
+
+// localEntry 一个KEY的本地副本，字符串值与哈希字段分别缓存
+type localEntry struct {
+	key      string
+	val      string
+	hasVal   bool
+	fields   map[string]string
+	expireAt time.Time
+}
+
+// invalidateMessage 失效广播消息，Keys 为完整KEY
+type invalidateMessage struct {
+	Origin string   `json:"origin"`
+	Keys   []string `json:"keys"`
+}
+
+// LocalCache 进程内LRU缓存，位于 Client 的读取方法之前
+type LocalCache struct {
+	c       *Client
+	opts    *localOptions
+	nodeID  string
+	mu      sync.Mutex
+	ll      *list.List
+	items   map[string]*list.Element
+	gen     uint64 // 每次失效递增，避免回源期间的失效被旧值覆盖
+	pubsub  *redis.PubSub
+	stopped chan struct{}
+	done    chan struct{}
+}
+
+// EnableLocalCache 为客户端开启本地缓存并订阅失效广播，每个客户端只能开启一次
+func (c *Client) EnableLocalCache(opts ...LocalOption) (*LocalCache, error) {
+	o := &localOptions{
+		size:    defaultLocalSize,
+		ttl:     defaultLocalTTL,
+		keyTTL:  make(map[string]time.Duration),
+		channel: c.Key(localChannelSuffix),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	l := &LocalCache{
+		c:       c,
+		opts:    o,
+		nodeID:  NewNodeID(),
+		ll:      list.New(),
+		items:   make(map[string]*list.Element),
+		stopped: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	c.localMu.Lock()
+	defer c.localMu.Unlock()
+	if nil != c.local {
+		return nil, ErrLocalCacheEnabled
+	}
+	l.pubsub = c.rdb.Subscribe(context.Background(), o.channel)
+	c.local = l
+	go l.listen()
+	return l, nil
+}
+
+// Local 返回客户端已开启的本地缓存，未开启时返回nil
+func (c *Client) Local() *LocalCache {
+	c.localMu.RLock()
+	defer c.localMu.RUnlock()
+	return c.local
+}
+
+// Close 停止接收失效广播并清空本地缓存
+func (l *LocalCache) Close() error {
+	l.c.localMu.Lock()
+	if l.c.local == l {
+		l.c.local = nil
+	}
+	l.c.localMu.Unlock()
+
+	select {
+	case <-l.stopped:
+		return nil
+	default:
+		close(l.stopped)
+	}
+	err := l.pubsub.Close()
+	<-l.done
+	l.evict(nil)
+	return err
+}
+
+// Len 当前缓存的KEY数量
+func (l *LocalCache) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.ll.Len()
+}
+
+// Invalidate 淘汰本地及其他副本中的KEY，KEY为空时清空全部
+func (l *LocalCache) Invalidate(ctx context.Context, keys ...string) error {
+	if 0 == len(keys) {
+		return l.broadcast(ctx, nil)
+	}
+	return l.broadcast(ctx, l.c.keys(keys))
+}
+
+// broadcast 淘汰本地副本并通知其他副本，fullKeys 为nil时表示全部
+func (l *LocalCache) broadcast(ctx context.Context, fullKeys []string) error {
+	l.evict(fullKeys)
+	keys := fullKeys
+	if nil == keys {
+		keys = []string{localInvalidateAll}
+	}
+	payload, err := json.Marshal(invalidateMessage{Origin: l.nodeID, Keys: keys})
+	if err != nil {
+		return err
+	}
+	return l.c.rdb.Publish(ctx, l.opts.channel, payload).Err()
+}
+
+// listen 接收其他副本的失效广播，连接断开时 go-redis 会自动重新订阅
+func (l *LocalCache) listen() {
+	defer close(l.done)
+	for {
+		msg, err := l.pubsub.ReceiveMessage(context.Background())
+		if err != nil {
+			select {
+			case <-l.stopped:
+				return
+			case <-time.After(localRetryInterval):
+			}
+			// 断线期间可能错过广播，清空本地缓存
+			l.evict(nil)
+			continue
+		}
+		var m invalidateMessage
+		if err = json.Unmarshal([]byte(msg.Payload), &m); err != nil || m.Origin == l.nodeID {
+			continue
+		}
+		if 1 == len(m.Keys) && localInvalidateAll == m.Keys[0] {
+			l.evict(nil)
+		} else {
+			l.evict(m.Keys)
+		}
+	}
+}
+
+// evict 淘汰本地副本，fullKeys 为nil时清空全部
+func (l *LocalCache) evict(fullKeys []string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.gen++
+	if nil == fullKeys {
+		l.ll.Init()
+		l.items = make(map[string]*list.Element)
+		return
+	}
+	for _, k := range fullKeys {
+		if el, ok := l.items[k]; ok {
+			l.ll.Remove(el)
+			delete(l.items, k)
+		}
+	}
+}
+
+// ttl 返回KEY的本地有效期
+func (l *LocalCache) ttl(key string) time.Duration {
+	if d, ok := l.opts.keyTTL[key]; ok {
+		return d
+	}
+	return l.opts.ttl
+}
+
+// lookup 读取未过期的本地副本，field 为空时读取字符串值
+func (l *LocalCache) lookup(fullKey, field string) (string, bool, uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	el, ok := l.items[fullKey]
+	if !ok {
+		return "", false, l.gen
+	}
+	e := el.Value.(*localEntry)
+	if time.Now().After(e.expireAt) {
+		l.ll.Remove(el)
+		delete(l.items, fullKey)
+		return "", false, l.gen
+	}
+	l.ll.MoveToFront(el)
+	if "" == field {
+		return e.val, e.hasVal, l.gen
+	}
+	val, ok := e.fields[field]
+	return val, ok, l.gen
+}
+
+// store 写入本地副本，读取后发生过失效则放弃写入
+func (l *LocalCache) store(key, fullKey, field, val string, gen uint64) {
+	ttl := l.ttl(key)
+	if ttl <= 0 || l.opts.size <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if gen != l.gen {
+		return
+	}
+
+	var e *localEntry
+	if el, ok := l.items[fullKey]; ok {
+		e = el.Value.(*localEntry)
+		l.ll.MoveToFront(el)
+	} else {
+		e = &localEntry{key: fullKey, expireAt: time.Now().Add(ttl)}
+		l.items[fullKey] = l.ll.PushFront(e)
+		for l.ll.Len() > l.opts.size {
+			oldest := l.ll.Back()
+			l.ll.Remove(oldest)
+			delete(l.items, oldest.Value.(*localEntry).key)
+		}
+	}
+	if "" == field {
+		e.val, e.hasVal = val, true
+		return
+	}
+	if nil == e.fields {
+		e.fields = make(map[string]string)
+	}
+	e.fields[field] = val
+}
+
+// changed Client 修改KEY成功后调用，淘汰本地缓存并广播，fullKeys 为nil时表示全部
+// 未开启本地缓存时直接返回，不发送广播
+func (c *Client) changed(ctx context.Context, err error, fullKeys ...string) error {
+	if err != nil {
+		return err
+	}
+	l := c.Local()
+	if nil == l {
+		return nil
+	}
+	// 广播失败时其他副本最迟在本地有效期后更新，不影响写入结果
+	l.broadcast(ctx, fullKeys)
+	return nil
+}
+
+// LocalTyped 本地缓存的泛型视图
+type LocalTyped[T any] struct {
+	l *LocalCache
+}
+
+// LocalAs 返回本地缓存的泛型视图
+func LocalAs[T any](l *LocalCache) LocalTyped[T] {
+	return LocalTyped[T]{l: l}
+}
+
+// Get 优先读取本地副本，未命中时 GET 并缓存到本地
+func (t LocalTyped[T]) Get(ctx context.Context, key string) (v T, err error) {
+	fullKey := t.l.c.Key(key)
+	data, ok, gen := t.l.lookup(fullKey, "")
+	if !ok {
+		if data, err = t.l.c.rdb.Get(ctx, fullKey).Result(); err != nil {
+			return
+		}
+		t.l.store(key, fullKey, "", data, gen)
+	}
+	return decodeValWith[T](t.l.c.codec, data)
+}
+
+// HGet 优先读取本地副本，未命中时 HGET 并缓存到本地
+func (t LocalTyped[T]) HGet(ctx context.Context, key, field string) (v T, err error) {
+	fullKey := t.l.c.Key(key)
+	data, ok, gen := t.l.lookup(fullKey, field)
+	if !ok {
+		if data, err = t.l.c.rdb.HGet(ctx, fullKey, field).Result(); err != nil {
+			return
+		}
+		t.l.store(key, fullKey, field, data, gen)
+	}
+	return decodeValWith[T](t.l.c.codec, data)
+}
+
+// 以下包级函数委托给默认实例
+
+// EnableLocalCache 为默认实例开启本地缓存
+func EnableLocalCache(opts ...LocalOption) (*LocalCache, error) {
+	return defaultClient.EnableLocalCache(opts...)
+}
+
+// LocalGet 通过默认实例的本地缓存读取，未开启本地缓存时直接 GET
+func LocalGet[T any](key string) (T, error) {
+	if l := defaultClient.Local(); nil != l {
+		return LocalAs[T](l).Get(ctx, key)
+	}
+	return As[T](defaultClient).Get(ctx, key)
+}
+
+// LocalHGet 通过默认实例的本地缓存读取哈希字段，未开启本地缓存时直接 HGET
+func LocalHGet[T any](key, field string) (T, error) {
+	if l := defaultClient.Local(); nil != l {
+		return LocalAs[T](l).HGet(ctx, key, field)
+	}
+	return As[T](defaultClient).HGet(ctx, key, field)
+}
@@ -0,0 +1,128 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestLocalCache 测试本地缓存命中与跨副本失效
+func TestLocalCache(t *testing.T) {
+	ctx := context.Background()
+	newReplica := func() (*Client, *LocalCache) {
+		c, err := NewClient(&Config{Prefix: "local", Host: testAddr})
+		if err != nil {
+			t.Fatal(err)
+		}
+		l, err := c.EnableLocalCache(WithLocalSize(2), WithLocalTTL(time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c, l
+	}
+	a, la := newReplica()
+	defer a.Close()
+	defer la.Close()
+	b, lb := newReplica()
+	defer b.Close()
+	defer lb.Close()
+
+	if _, err := a.EnableLocalCache(); err != ErrLocalCacheEnabled {
+		t.Errorf("expect ErrLocalCacheEnabled, got %v", err)
+	}
+
+	if err := a.Set(ctx, "test_local", "v1", 10); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := LocalAs[string](lb).Get(ctx, "test_local"); err != nil || v != "v1" {
+		t.Fatalf("expect v1, got %v %v", v, err)
+	}
+	if lb.Len() != 1 {
+		t.Errorf("expect 1 local entry, got %d", lb.Len())
+	}
+
+	// 绕过客户端直接修改，本地副本仍然命中
+	a.Redis().Set(ctx, a.Key("test_local"), "raw", 10*time.Second)
+	if v, _ := LocalAs[string](lb).Get(ctx, "test_local"); v != "v1" {
+		t.Errorf("expect cached v1, got %v", v)
+	}
+
+	// 通过客户端修改，其他副本收到广播后淘汰
+	if err := a.Set(ctx, "test_local", "v2", 10); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for lb.Len() != 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if v, _ := LocalAs[string](lb).Get(ctx, "test_local"); v != "v2" {
+		t.Errorf("expect v2 after invalidation, got %v", v)
+	}
+
+	// LRU 容量
+	a.HSet(ctx, "test_local_h", "f", 1)
+	a.Set(ctx, "test_local_2", 2, 10)
+	LocalAs[int](lb).HGet(ctx, "test_local_h", "f")
+	LocalAs[int](lb).Get(ctx, "test_local_2")
+	if lb.Len() != 2 {
+		t.Errorf("expect size limited to 2, got %d", lb.Len())
+	}
+
+	// 回源写回同样广播失效
+	a.Redis().Set(ctx, a.Key("test_local_load"), "1", 10*time.Second)
+	if v, _ := LocalAs[int](lb).Get(ctx, "test_local_load"); v != 1 {
+		t.Fatalf("expect 1, got %v", v)
+	}
+	a.Redis().Del(ctx, a.Key("test_local_load"))
+	As[int](a).GetOrLoad(ctx, "test_local_load", time.Minute, func() (int, error) {
+		return 2, nil
+	})
+	deadline = time.Now().Add(time.Second)
+	for lb.Len() == 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if v, _ := LocalAs[int](lb).Get(ctx, "test_local_load"); v != 2 {
+		t.Errorf("expect 2 after load, got %v", v)
+	}
+
+	a.Delete(ctx, "test_local")
+	a.Delete(ctx, "test_local_h")
+	a.Delete(ctx, "test_local_2")
+	a.Delete(ctx, "test_local_load")
+}
+
+// TestLocalCacheNoPublish 未开启本地缓存的客户端写入时不广播
+func TestLocalCacheNoPublish(t *testing.T) {
+	ctx := context.Background()
+	c, err := NewClient(&Config{Prefix: "local_plain", Host: testAddr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	sub := c.Redis().Subscribe(ctx, c.Key(localChannelSuffix))
+	defer sub.Close()
+	if _, err = sub.Receive(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = c.Set(ctx, "test_plain", "v", 10); err != nil {
+		t.Fatal(err)
+	}
+	if msg, err := sub.ReceiveTimeout(ctx, 100*time.Millisecond); err == nil {
+		t.Errorf("unexpected invalidation %v", msg)
+	}
+
+	l, err := c.EnableLocalCache()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if err = c.Set(ctx, "test_plain", "v", 10); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sub.ReceiveTimeout(ctx, time.Second); err != nil {
+		t.Errorf("expect invalidation after enabling local cache, got %v", err)
+	}
+	c.Delete(ctx, "test_plain")
+}
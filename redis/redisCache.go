@@ -2,20 +2,26 @@ package redis
 
 import (
 	"context"
-	"encoding/json"
-	"errors"
 	"fmt"
-	"reflect"
 	"time"
 
-	"github.com/XingMenTech/common/utils"
 	"github.com/go-redis/redis/v8"
 )
 
 var (
-	client *redis.Client
-	ctx    context.Context
-	Key    string
+	defaultClient *Client
+	ctx           context.Context
+	Key           string
+)
+
+// Nil KEY不存在时返回的错误
+const Nil = redis.Nil
+
+// 部署模式
+const (
+	ModeStandalone = "standalone"
+	ModeSentinel   = "sentinel"
+	ModeCluster    = "cluster"
 )
 
 type Config struct {
@@ -23,203 +29,122 @@ type Config struct {
 	Host     string `yaml:"host" json:"host" comment:"主机名"`
 	Password string `yaml:"password" json:"password" comment:"密码"`
 	DbNum    string `yaml:"dbNum" json:"dbNum" comment:"数据库"`
+
+	Mode             string   `yaml:"mode" json:"mode" comment:"部署模式: standalone/sentinel/cluster，默认standalone"`
+	Addrs            []string `yaml:"addrs" json:"addrs" comment:"哨兵或集群节点地址，为空时使用Host"`
+	MasterName       string   `yaml:"masterName" json:"masterName" comment:"哨兵模式的主节点名称"`
+	SentinelPassword string   `yaml:"sentinelPassword" json:"sentinelPassword" comment:"哨兵密码"`
+	// HashTagPrefix 为 true 时前缀写为 {prefix}，该实例的所有KEY落在同一slot，集群模式下多KEY命令不再受slot限制
+	HashTagPrefix bool `yaml:"hashTagPrefix" json:"hashTagPrefix" comment:"前缀作为hash tag"`
+
+	PoolSize     int `yaml:"poolSize" json:"poolSize" comment:"连接池大小，默认每CPU 10个"`
+	MinIdleConns int `yaml:"minIdleConns" json:"minIdleConns" comment:"最小空闲连接数"`
+	DialTimeout  int `yaml:"dialTimeout" json:"dialTimeout" comment:"连接超时(毫秒)"`
+	ReadTimeout  int `yaml:"readTimeout" json:"readTimeout" comment:"读超时(毫秒)"`
+	WriteTimeout int `yaml:"writeTimeout" json:"writeTimeout" comment:"写超时(毫秒)"`
+	PoolTimeout  int `yaml:"poolTimeout" json:"poolTimeout" comment:"获取连接超时(毫秒)"`
+
+	TLS           bool   `yaml:"tls" json:"tls" comment:"启用TLS"`
+	TLSSkipVerify bool   `yaml:"tlsSkipVerify" json:"tlsSkipVerify" comment:"跳过证书校验"`
+	TLSCAFile     string `yaml:"tlsCaFile" json:"tlsCaFile" comment:"CA证书文件"`
+	TLSCertFile   string `yaml:"tlsCertFile" json:"tlsCertFile" comment:"客户端证书文件"`
+	TLSKeyFile    string `yaml:"tlsKeyFile" json:"tlsKeyFile" comment:"客户端私钥文件"`
 }
 
+// InitRedisCache 初始化默认实例
 func InitRedisCache(config *Config) error {
 	ctx = context.Background()
 	Key = config.Prefix
 
-	dbNum := utils.StringToInt(config.DbNum)
-
-	cli, err := startAndGC(config.Host, config.Password, dbNum)
+	cli, err := NewClient(config)
 	if err != nil {
-		return errors.New(fmt.Sprintf("can't connect redis service %v", err))
+		return err
 	}
 
-	client = cli
+	defaultClient = cli
 	return nil
 }
 
 func associate(originKey interface{}) string {
-	return fmt.Sprintf("%s:%s", Key, originKey)
-}
-
-// start gc routine based on config string settings.
-func startAndGC(host, passWord string, dbNum int) (*redis.Client, error) {
-
-	cli := redis.NewClient(&redis.Options{
-		Addr:     host,
-		Password: passWord,
-		DB:       dbNum,
-	})
-	cmd := cli.Ping(ctx)
-	if cmd.Err() != nil {
-		return nil, errors.New(fmt.Sprintf("redis connect errors: %v \n", cmd.Err()))
+	if nil != defaultClient {
+		return defaultClient.Key(originKey)
 	}
-
-	return cli, nil
+	return fmt.Sprintf("%s:%s", Key, originKey)
 }
 
 // IsExist check if cached value exists or not.
 func IsExist(key string) bool {
-	val := client.Exists(ctx, associate(key)).Val()
-	return val != 0
+	return defaultClient.IsExist(ctx, key)
 }
 
 // Delete delete cached value by key.
 func Delete(key string) error {
-	return client.Del(ctx, associate(key)).Err()
+	return defaultClient.Delete(ctx, key)
 }
 
 // Subscribe 订阅主题
 func Subscribe(channel ...string) *redis.PubSub {
-	return client.Subscribe(ctx, channel...)
+	return defaultClient.Subscribe(ctx, channel...)
 }
 
 // PSubscribe 订阅主题
 func PSubscribe(channel ...string) *redis.PubSub {
-	return client.PSubscribe(ctx, channel...)
+	return defaultClient.PSubscribe(ctx, channel...)
 }
 
 // Publish 发布主题消息
 func Publish(channel string, msg interface{}) error {
-	msgByte, err := encode(msg)
-	if err != nil {
-		return err
-	}
-	return client.Publish(ctx, channel, msgByte).Err()
+	return defaultClient.Publish(ctx, channel, msg)
 }
 
 func ReceiveMessage(pubSub *redis.PubSub) (*redis.Message, error) {
 	return pubSub.ReceiveMessage(ctx)
 }
 
-// ClearAll clear all cache.
+// ClearAll 删除当前前缀下的所有KEY
 func ClearAll() error {
-	return client.FlushAll(ctx).Err()
+	return defaultClient.ClearAll(ctx)
 }
 
 func ExpireAt(key string, t time.Time) error {
-	return client.ExpireAt(ctx, associate(key), t).Err()
+	return defaultClient.ExpireAt(ctx, key, t)
 }
 func ExpireIn(key string, d time.Duration) error {
-	return client.Expire(ctx, associate(key), d).Err()
+	return defaultClient.ExpireIn(ctx, key, d)
 }
 
-func encode(data interface{}) (string, error) {
-	// 使用反射来处理各种类型
-	val := reflect.ValueOf(data)
-
-	// 如果是指针，获取其指向的值
-	for val.Kind() == reflect.Ptr {
-		if val.IsNil() {
-			return "", errors.New("data is nil")
-		}
-		val = val.Elem()
-	}
+// Encode 将值编码为写入redis的字符串，基本类型直接格式化，其余类型使用JSON
+func Encode(data interface{}) (string, error) {
+	return encode(data)
+}
 
-	switch val.Kind() {
-	case reflect.String:
-		return val.String(), nil
-	case reflect.Bool:
-		if val.Bool() {
-			return "true", nil
-		}
-		return "false", nil
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		return fmt.Sprintf("%d", val.Int()), nil
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		return fmt.Sprintf("%d", val.Uint()), nil
-	case reflect.Float32, reflect.Float64:
-		return fmt.Sprintf("%g", val.Float()), nil
-	case reflect.Slice:
-		// 检查是否为 []byte 类型
-		if val.Type().Elem().Kind() == reflect.Uint8 {
-			return string(val.Bytes()), nil
-		}
-		// 其他切片类型使用 JSON 序列化
-		fallthrough
-	default:
-		// 其他复杂类型使用 JSON 序列化
-		bytes, err := json.Marshal(data)
-		if err != nil {
-			return "", err
-		}
-		return string(bytes), nil
-	}
+// Decode 将Encode得到的字符串解码为T
+func Decode[T any](data string) (T, error) {
+	return decodeVal[T](data)
 }
-func decode(data string, v any) error {
-	if data == "" {
-		return errors.New("data is nil")
-	}
 
-	switch v.(type) {
-	case string:
-		v = data
-		return nil
-	case []byte:
-		v = []byte(data)
-		return nil
-	default:
-		return json.Unmarshal([]byte(data), v)
-	}
+// EncodeWith 同 Encode，非基本类型使用 codec 编码
+func EncodeWith(codec Codec, data interface{}) (string, error) {
+	return encodeWith(codec, data)
 }
 
-func decodeVal[T any](data string) (T, error) {
-	if data == "" {
-		var zero T
-		return zero, errors.New("data is nil")
-	}
+// DecodeWith 将 EncodeWith 得到的字符串解码为T
+func DecodeWith[T any](codec Codec, data string) (T, error) {
+	return decodeValWith[T](codec, data)
+}
 
-	var res = new(T)
-
-	// 获取目标类型的反射类型
-	targetType := reflect.TypeOf(res).Elem()
-
-	// 处理基本类型
-	switch targetType.Kind() {
-	case reflect.String:
-		str := data
-		return any(str).(T), nil
-	case reflect.Int:
-		intVal := utils.StringToInt(data)
-		return any(intVal).(T), nil
-	case reflect.Int64:
-		int64Val := utils.StringToInt64(data)
-		return any(int64Val).(T), nil
-	case reflect.Float64:
-		floatVal := utils.StringToFloat64(data)
-		return any(floatVal).(T), nil
-	case reflect.Bool:
-		boolVal := data == "true" || data == "1" || data == "True" || data == "TRUE"
-		return any(boolVal).(T), nil
-	case reflect.Slice:
-		// 检查是否为 []byte 类型
-		if targetType.Elem().Kind() == reflect.Uint8 { // []byte 实际上是 []uint8
-			byteSlice := []byte(data)
-			return any(byteSlice).(T), nil
-		}
-		// 对于其他切片类型，使用 JSON 反序列化
-		fallthrough
-	default:
-		// 对于复杂类型，使用 JSON 反序列化
-		err := json.Unmarshal([]byte(data), res)
-		if err != nil {
-			var zero T
-			return zero, err
-		}
-		return *res, nil
-	}
+func encode(data interface{}) (string, error) {
+	return encodeWith(JSONCodec, data)
+}
+
+func decode(data string, v any) error {
+	return decodeWith(JSONCodec, data, v)
+}
+
+func decodeVal[T any](data string) (T, error) {
+	return decodeValWith[T](JSONCodec, data)
 }
 
 func decodeArr[T any](data []string) ([]T, error) {
-	res := make([]T, len(data))
-	for i, v := range data {
-		val, err := decodeVal[T](v)
-		if err != nil {
-			return nil, err
-		}
-		res[i] = val
-	}
-	return res, nil
+	return decodeArrWith[T](JSONCodec, data)
 }
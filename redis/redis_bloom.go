@@ -0,0 +1,171 @@
+// 布隆过滤器
+// 基于位图实现，位置在客户端通过双重哈希计算，不依赖 RedisBloom 模块
+// 判断不存在时一定不存在，判断存在时有 fpRate 概率误判；不支持删除单个元素
+// 示例
+//
+//	bf, err := redis.NewBloomFilter("order_no", 1000000, 0.001)
+//	if added, err := bf.Add(ctx, orderNo); err == nil && !added {
+//		// 订单号可能重复，再查库确认
+//	}
+
+package redis
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"math"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// maxBloomBits 单个位图最多 2^32 位(512MB)
+const maxBloomBits = 1 << 32
+
+// ErrBloomParams 预期元素数量须大于0，误判率须在(0,1)之间
+var ErrBloomParams = errors.New("redis bloom: expected must be positive and fpRate in (0,1)")
+
+// BloomFilter 布隆过滤器
+type BloomFilter struct {
+	c    *Client
+	key  string
+	bits uint64
+	hash uint64
+}
+
+// NewBloomFilter 创建布隆过滤器，按预期元素数量 expected 与误判率 fpRate 计算位数与哈希次数
+// 同一 key 的参数须保持一致，否则已写入的数据无法正确判断
+func (c *Client) NewBloomFilter(key string, expected uint64, fpRate float64) (*BloomFilter, error) {
+	if 0 == expected || fpRate <= 0 || 1 <= fpRate {
+		return nil, ErrBloomParams
+	}
+	bits, hash := bloomParams(expected, fpRate)
+	return &BloomFilter{c: c, key: c.Key(key), bits: bits, hash: hash}, nil
+}
+
+// bloomParams 计算最优位数 m=-n*ln(p)/ln(2)^2 与哈希次数 k=m/n*ln(2)
+func bloomParams(n uint64, p float64) (bits, hash uint64) {
+	m := math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2))
+	bits = uint64(math.Min(m, maxBloomBits))
+	hash = uint64(math.Max(1, math.Round(float64(bits)/float64(n)*math.Ln2)))
+	return
+}
+
+// Bits 位图的位数
+func (b *BloomFilter) Bits() uint64 {
+	return b.bits
+}
+
+// Hashes 每个元素的哈希次数
+func (b *BloomFilter) Hashes() uint64 {
+	return b.hash
+}
+
+// positions 以 FNV-128a 的高低64位做双重哈希，计算元素在位图中的位置
+func (b *BloomFilter) positions(data string) []int64 {
+	h := fnv.New128a()
+	h.Write([]byte(data))
+	sum := h.Sum(nil)
+	var h1, h2 uint64
+	for i := 0; i < 8; i++ {
+		h1 = h1<<8 | uint64(sum[i])
+		h2 = h2<<8 | uint64(sum[8+i])
+	}
+	h2 |= 1
+
+	pos := make([]int64, b.hash)
+	for i := uint64(0); i < b.hash; i++ {
+		pos[i] = int64((h1 + i*h2) % b.bits)
+	}
+	return pos
+}
+
+// Add 添加元素，元素此前不存在时返回true
+func (b *BloomFilter) Add(ctx context.Context, val interface{}) (bool, error) {
+	res, err := b.AddMulti(ctx, val)
+	if err != nil {
+		return false, err
+	}
+	return res[0], nil
+}
+
+// AddMulti 通过 pipeline 批量添加元素，返回各元素此前是否不存在
+func (b *BloomFilter) AddMulti(ctx context.Context, vals ...interface{}) ([]bool, error) {
+	cmds, err := b.exec(ctx, vals, func(pipe redis.Pipeliner, pos int64) *redis.IntCmd {
+		return pipe.SetBit(ctx, b.key, pos, 1)
+	})
+	if err = b.c.changed(ctx, err, b.key); err != nil {
+		return nil, err
+	}
+	// SETBIT 返回原值，任意一位原为0即为新元素
+	return b.collect(cmds, 0), nil
+}
+
+// Exists 判断元素是否可能存在
+func (b *BloomFilter) Exists(ctx context.Context, val interface{}) (bool, error) {
+	res, err := b.ExistsMulti(ctx, val)
+	if err != nil {
+		return false, err
+	}
+	return res[0], nil
+}
+
+// ExistsMulti 通过 pipeline 批量判断元素是否可能存在
+func (b *BloomFilter) ExistsMulti(ctx context.Context, vals ...interface{}) ([]bool, error) {
+	cmds, err := b.exec(ctx, vals, func(pipe redis.Pipeliner, pos int64) *redis.IntCmd {
+		return pipe.GetBit(ctx, b.key, pos)
+	})
+	if err != nil {
+		return nil, err
+	}
+	// 任意一位为0即一定不存在
+	res := b.collect(cmds, 0)
+	for i := range res {
+		res[i] = !res[i]
+	}
+	return res, nil
+}
+
+// Clear 删除位图
+func (b *BloomFilter) Clear(ctx context.Context) error {
+	return b.c.changed(ctx, b.c.rdb.Del(ctx, b.key).Err(), b.key)
+}
+
+// exec 对每个元素的每个位置执行 fn，返回按元素分组的命令
+func (b *BloomFilter) exec(ctx context.Context, vals []interface{}, fn func(pipe redis.Pipeliner, pos int64) *redis.IntCmd) ([][]*redis.IntCmd, error) {
+	cmds := make([][]*redis.IntCmd, len(vals))
+	_, err := b.c.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, v := range vals {
+			data, err := b.c.encode(v)
+			if err != nil {
+				return err
+			}
+			for _, pos := range b.positions(data) {
+				cmds[i] = append(cmds[i], fn(pipe, pos))
+			}
+		}
+		return nil
+	})
+	return cmds, err
+}
+
+// collect 返回每个元素是否有位置的值等于 bit
+func (b *BloomFilter) collect(cmds [][]*redis.IntCmd, bit int64) []bool {
+	res := make([]bool, len(cmds))
+	for i, group := range cmds {
+		for _, cmd := range group {
+			if bit == cmd.Val() {
+				res[i] = true
+				break
+			}
+		}
+	}
+	return res
+}
+
+// 以下包级函数委托给默认实例
+
+// NewBloomFilter 创建布隆过滤器，按预期元素数量 expected 与误判率 fpRate 计算位数与哈希次数
+func NewBloomFilter(key string, expected uint64, fpRate float64) (*BloomFilter, error) {
+	return defaultClient.NewBloomFilter(key, expected, fpRate)
+}
@@ -0,0 +1,75 @@
+package redis
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestBloomParams(t *testing.T) {
+	bits, hash := bloomParams(1000000, 0.01)
+	if bits != 9585059 || hash != 7 {
+		t.Errorf("bloomParams = %d, %d", bits, hash)
+	}
+	if bits, _ := bloomParams(1<<40, 0.0001); bits != maxBloomBits {
+		t.Errorf("bits not capped: %d", bits)
+	}
+}
+
+func TestBloomPositions(t *testing.T) {
+	b := &BloomFilter{bits: 1000, hash: 5}
+	pos := b.positions("order:1")
+	if len(pos) != 5 {
+		t.Fatalf("positions len = %d", len(pos))
+	}
+	for i, p := range b.positions("order:1") {
+		if p != pos[i] || p < 0 || p >= 1000 {
+			t.Errorf("position %d = %d, want %d", i, p, pos[i])
+		}
+	}
+}
+
+func TestBloomFilter(t *testing.T) {
+	bf, err := NewBloomFilter("test_bloom", 1000, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bf.Clear(ctx)
+
+	if added, err := bf.Add(ctx, "order:1"); err != nil || !added {
+		t.Errorf("Add = %v, %v", added, err)
+	}
+	if added, err := bf.Add(ctx, "order:1"); err != nil || added {
+		t.Errorf("Add duplicate = %v, %v", added, err)
+	}
+
+	vals := make([]interface{}, 100)
+	for i := range vals {
+		vals[i] = fmt.Sprintf("order:%d", i+2)
+	}
+	if _, err := bf.AddMulti(ctx, vals...); err != nil {
+		t.Fatal(err)
+	}
+	res, err := bf.ExistsMulti(ctx, vals...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, ok := range res {
+		if !ok {
+			t.Errorf("%v not found", vals[i])
+		}
+	}
+	if ok, err := bf.Exists(ctx, "order:missing"); err != nil || ok {
+		t.Errorf("Exists missing = %v, %v", ok, err)
+	}
+}
+
+func TestPFCount(t *testing.T) {
+	Delete("test_pf_1")
+	Delete("test_pf_2")
+	PFAdd("test_pf_1", "a", "b", "c")
+	PFAdd("test_pf_2", "c", "d")
+	// 基数为估算值，允许少量误差
+	if n, err := PFCount("test_pf_1", "test_pf_2"); err != nil || n < 4 || n > 5 {
+		t.Errorf("PFCount = %d, %v", n, err)
+	}
+}
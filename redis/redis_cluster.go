@@ -0,0 +1,84 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// ErrCrossSlot 集群模式下多KEY命令的KEY不在同一个哈希槽
+var ErrCrossSlot = errors.New("redis: keys in request don't hash to the same slot")
+
+// clusterSlots redis cluster 哈希槽总数
+const clusterSlots = 16384
+
+// SlotKey 以 tag 作为哈希标签生成KEY，集群模式下相同 tag 的KEY落在同一个哈希槽
+// 例如 SlotKey("user:1", "follow") 与 SlotKey("user:1", "fans") 可用于 SInterStore
+func SlotKey(tag, key string) string {
+	return fmt.Sprintf("{%s}:%s", tag, key)
+}
+
+// hashSlot 计算KEY所在的哈希槽，KEY包含 {tag} 时只对 tag 计算
+func hashSlot(key string) int {
+	if s := strings.IndexByte(key, '{'); s > -1 {
+		if e := strings.IndexByte(key[s+1:], '}'); e > 0 {
+			key = key[s+1 : s+1+e]
+		}
+	}
+	return int(crc16(key) % clusterSlots)
+}
+
+// crc16 CRC16-CCITT (XMODEM)，与 redis cluster 一致
+func crc16(key string) uint16 {
+	var crc uint16
+	for i := 0; i < len(key); i++ {
+		crc ^= uint16(key[i]) << 8
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// sameSlot 集群模式下检查完整KEY是否落在同一个哈希槽，非集群模式直接通过
+func (c *Client) sameSlot(fullKeys ...string) error {
+	if !c.cluster || len(fullKeys) < 2 {
+		return nil
+	}
+	slot := hashSlot(fullKeys[0])
+	for _, k := range fullKeys[1:] {
+		if hashSlot(k) != slot {
+			return ErrCrossSlot
+		}
+	}
+	return nil
+}
+
+// storeKeys 返回 destination 与源KEY的完整KEY，并检查哈希槽
+func (c *Client) storeKeys(destination string, keys []string) (string, []string, error) {
+	dst, src := c.Key(destination), c.keys(keys)
+	return dst, src, c.sameSlot(append([]string{dst}, src...)...)
+}
+
+// msetBySlot 集群模式下按哈希槽分组，通过 pipeline 分别执行 MSET
+func (c *Client) msetBySlot(ctx context.Context, args []interface{}) error {
+	groups := make(map[int][]interface{})
+	for i := 0; i+1 < len(args); i += 2 {
+		slot := hashSlot(args[i].(string))
+		groups[slot] = append(groups[slot], args[i], args[i+1])
+	}
+	_, err := c.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, group := range groups {
+			pipe.MSet(ctx, group...)
+		}
+		return nil
+	})
+	return err
+}
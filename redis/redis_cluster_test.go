@@ -0,0 +1,34 @@
+package redis
+
+import "testing"
+
+func TestHashSlot(t *testing.T) {
+	cases := map[string]int{
+		"foo":         12182,
+		"bar":         5061,
+		"123456789":   12739,
+		"{foo}:other": 12182,
+	}
+	for key, slot := range cases {
+		if got := hashSlot(key); got != slot {
+			t.Errorf("hashSlot(%q) = %d, want %d", key, got, slot)
+		}
+	}
+	if hashSlot("{user1000}.following") != hashSlot("{user1000}.followers") {
+		t.Errorf("keys with the same hash tag should share a slot")
+	}
+	if hashSlot(SlotKey("user:1", "follow")) != hashSlot(SlotKey("user:1", "fans")) {
+		t.Errorf("SlotKey with the same tag should share a slot")
+	}
+}
+
+func TestSameSlot(t *testing.T) {
+	c := &Client{prefix: "app", hashTag: true, cluster: true}
+	if err := c.sameSlot(c.keys([]string{"a", "b", "c"})...); err != nil {
+		t.Errorf("hash tag prefix should keep keys in one slot, got %v", err)
+	}
+	c.hashTag = false
+	if err := c.sameSlot(c.Key("foo"), c.Key("bar")); err != ErrCrossSlot {
+		t.Errorf("expected ErrCrossSlot, got %v", err)
+	}
+}
@@ -1,67 +1,72 @@
 package redis
 
+import "context"
+
 // HDel key field1 [field2] 删除一个或多个哈希表字段
-func HDel(key string, fields ...string) error {
-	return client.HDel(ctx, associate(key), fields...).Err()
+func (c *Client) HDel(ctx context.Context, key string, fields ...string) error {
+	fullKey := c.Key(key)
+	return c.changed(ctx, c.rdb.HDel(ctx, fullKey, fields...).Err(), fullKey)
 }
 
 // HExists HEXISTS key field 查看哈希表 key 中，指定的字段是否存在。
-func HExists(key, field string) (bool, error) {
-	exists := client.HExists(ctx, associate(key), field)
+func (c *Client) HExists(ctx context.Context, key, field string) (bool, error) {
+	exists := c.rdb.HExists(ctx, c.Key(key), field)
 	return exists.Val(), exists.Err()
 }
 
 // HGet HGET key field 获取存储在哈希表中指定字段的值。
-func HGet[T any](key string, field string) (val T, err error) {
-	cmd := client.HGet(ctx, associate(key), field)
+func (t Typed[T]) HGet(ctx context.Context, key string, field string) (val T, err error) {
+	cmd := t.c.rdb.HGet(ctx, t.c.Key(key), field)
 	if cmd.Err() != nil {
 		err = cmd.Err()
 		return
 	}
 
-	return decodeVal[T](cmd.Val())
+	return t.decode(cmd.Val())
 }
 
 // HGetAll HGETALL key 获取在哈希表中指定 key 的所有字段和值
-func HGetAll[T any](key string) (error, map[string]T) {
-	cmd := client.HGetAll(ctx, associate(key))
+func (t Typed[T]) HGetAll(ctx context.Context, key string) (map[string]T, error) {
+	cmd := t.c.rdb.HGetAll(ctx, t.c.Key(key))
 	if cmd.Err() != nil {
-		return cmd.Err(), nil
+		return nil, cmd.Err()
 	}
 
 	result := make(map[string]T)
 	for k, v := range cmd.Val() {
-		val, err := decodeVal[T](v)
+		val, err := t.decode(v)
 		if err != nil {
 			continue
 		}
 		result[k] = val
 	}
 
-	return nil, result
+	return result, nil
 }
 
 // HIncrBy HINCRBY key field increment 为哈希表 key 中的指定字段的整数值加上增量 increment 。
-func HIncrBy(key string, field string, incr int64) (int64, error) {
-	cmd := client.HIncrBy(ctx, associate(key), field, incr)
-	return cmd.Val(), cmd.Err()
+func (c *Client) HIncrBy(ctx context.Context, key string, field string, incr int64) (int64, error) {
+	fullKey := c.Key(key)
+	cmd := c.rdb.HIncrBy(ctx, fullKey, field, incr)
+	return cmd.Val(), c.changed(ctx, cmd.Err(), fullKey)
 }
 
 // HIncrByFloat HINCRBYFLOAT key field increment 为哈希表 key 中的指定字段的浮点数值加上增量 increment 。
-func HIncrByFloat(key string, field string, incr float64) (float64, error) {
-	cmd := client.HIncrByFloat(ctx, associate(key), field, incr)
-	return cmd.Val(), cmd.Err()
+func (c *Client) HIncrByFloat(ctx context.Context, key string, field string, incr float64) (float64, error) {
+	fullKey := c.Key(key)
+	cmd := c.rdb.HIncrByFloat(ctx, fullKey, field, incr)
+	return cmd.Val(), c.changed(ctx, cmd.Err(), fullKey)
 }
 
 // HKeys 7	HKEYS key 获取哈希表中的所有字段
-func HKeys(key string) ([]string, error) {
-	cmd := client.HKeys(ctx, associate(key))
+func (c *Client) HKeys(ctx context.Context, key string) ([]string, error) {
+	cmd := c.rdb.HKeys(ctx, c.Key(key))
 	return cmd.Val(), cmd.Err()
 }
 
 // HLen 8	HLEN key 获取哈希表中字段的数量
-func HLen(key string) int64 {
-	hLen := client.HLen(ctx, associate(key))
+func (c *Client) HLen(ctx context.Context, key string) int64 {
+	hLen := c.rdb.HLen(ctx, c.Key(key))
 	if hLen.Err() != nil {
 		return 0
 	}
@@ -69,14 +74,18 @@ func HLen(key string) int64 {
 }
 
 // HMGet 9	HMGET key field1 [field2] 获取所有给定字段的值
-func HMGet[T any](key string, fields ...string) []T {
-	cmd := client.HMGet(ctx, associate(key), fields...)
+func (t Typed[T]) HMGet(ctx context.Context, key string, fields ...string) []T {
+	cmd := t.c.rdb.HMGet(ctx, t.c.Key(key), fields...)
 	if cmd.Err() != nil {
 		return []T{}
 	}
 	result := make([]T, len(cmd.Val()))
 	for i, v := range cmd.Val() {
-		val, err := decodeVal[T](v.(string))
+		str, ok := v.(string)
+		if !ok {
+			continue
+		}
+		val, err := t.decode(str)
 		if err != nil {
 			continue
 		}
@@ -86,43 +95,117 @@ func HMGet[T any](key string, fields ...string) []T {
 }
 
 // HMSet 10	HMSET key field1 value1 [field2 value2 ] 同时将多个 field-value (域-值)对设置到哈希表 key 中。
-func HMSet(key string, fields map[string]interface{}) error {
+func (c *Client) HMSet(ctx context.Context, key string, fields map[string]interface{}) error {
 	args := make([]interface{}, 0)
 	for k, v := range fields {
-		val, err := encode(v)
+		val, err := c.encode(v)
 		if err != nil {
 			return err
 		}
 		args = append(args, k, val)
 	}
-	return client.HMSet(ctx, associate(key), args).Err()
-
+	fullKey := c.Key(key)
+	return c.changed(ctx, c.rdb.HMSet(ctx, fullKey, args).Err(), fullKey)
 }
 
 // HSet 11	HSET key field value 将哈希表 key 中的字段 field 的值设为 value 。
-func HSet(key string, field string, val interface{}) error {
-	valByte, err := encode(val)
+func (c *Client) HSet(ctx context.Context, key string, field string, val interface{}) error {
+	valByte, err := c.encode(val)
 	if err != nil {
 		return err
 	}
-	return client.HSet(ctx, associate(key), field, valByte).Err()
+	fullKey := c.Key(key)
+	return c.changed(ctx, c.rdb.HSet(ctx, fullKey, field, valByte).Err(), fullKey)
 }
 
 // HSetnx 12	HSETNX key field value 只有在字段 field 不存在时，设置哈希表字段的值。
-func HSetnx(key string, field string, val interface{}) (bool, error) {
-	valByte, err := encode(val)
+func (c *Client) HSetnx(ctx context.Context, key string, field string, val interface{}) (bool, error) {
+	valByte, err := c.encode(val)
 	if err != nil {
 		return false, err
 	}
-	return client.HSetNX(ctx, associate(key), field, valByte).Result()
+	fullKey := c.Key(key)
+	ok, err := c.rdb.HSetNX(ctx, fullKey, field, valByte).Result()
+	if !ok {
+		return false, err
+	}
+	return true, c.changed(ctx, err, fullKey)
 }
 
 // HVals 13	HVALS key 获取哈希表中所有值。
-func HVals[T any](key string) ([]T, error) {
-	cmd := client.HVals(ctx, associate(key))
+func (t Typed[T]) HVals(ctx context.Context, key string) ([]T, error) {
+	cmd := t.c.rdb.HVals(ctx, t.c.Key(key))
 	if cmd.Err() != nil {
 		return nil, cmd.Err()
 	}
 
-	return decodeArr[T](cmd.Val())
+	return t.decodeArr(cmd.Val())
+}
+
+// 以下包级函数委托给默认实例
+
+// HDel key field1 [field2] 删除一个或多个哈希表字段
+func HDel(key string, fields ...string) error {
+	return defaultClient.HDel(ctx, key, fields...)
+}
+
+// HExists HEXISTS key field 查看哈希表 key 中，指定的字段是否存在。
+func HExists(key, field string) (bool, error) {
+	return defaultClient.HExists(ctx, key, field)
+}
+
+// HGet HGET key field 获取存储在哈希表中指定字段的值。
+func HGet[T any](key string, field string) (val T, err error) {
+	return As[T](defaultClient).HGet(ctx, key, field)
+}
+
+// HGetAll HGETALL key 获取在哈希表中指定 key 的所有字段和值
+func HGetAll[T any](key string) (error, map[string]T) {
+	result, err := As[T](defaultClient).HGetAll(ctx, key)
+	return err, result
+}
+
+// HIncrBy HINCRBY key field increment 为哈希表 key 中的指定字段的整数值加上增量 increment 。
+func HIncrBy(key string, field string, incr int64) (int64, error) {
+	return defaultClient.HIncrBy(ctx, key, field, incr)
+}
+
+// HIncrByFloat HINCRBYFLOAT key field increment 为哈希表 key 中的指定字段的浮点数值加上增量 increment 。
+func HIncrByFloat(key string, field string, incr float64) (float64, error) {
+	return defaultClient.HIncrByFloat(ctx, key, field, incr)
+}
+
+// HKeys 7	HKEYS key 获取哈希表中的所有字段
+func HKeys(key string) ([]string, error) {
+	return defaultClient.HKeys(ctx, key)
+}
+
+// HLen 8	HLEN key 获取哈希表中字段的数量
+func HLen(key string) int64 {
+	return defaultClient.HLen(ctx, key)
+}
+
+// HMGet 9	HMGET key field1 [field2] 获取所有给定字段的值
+func HMGet[T any](key string, fields ...string) []T {
+	return As[T](defaultClient).HMGet(ctx, key, fields...)
+}
+
+// HMSet 10	HMSET key field1 value1 [field2 value2 ] 同时将多个 field-value (域-值)对设置到哈希表 key 中。
+func HMSet(key string, fields map[string]interface{}) error {
+	return defaultClient.HMSet(ctx, key, fields)
+}
+
+// HSet 11	HSET key field value 将哈希表 key 中的字段 field 的值设为 value 。
+func HSet(key string, field string, val interface{}) error {
+	return defaultClient.HSet(ctx, key, field, val)
+}
+
+// HSetnx 12	HSETNX key field value 只有在字段 field 不存在时，设置哈希表字段的值。
+func HSetnx(key string, field string, val interface{}) (bool, error) {
+	return defaultClient.HSetnx(ctx, key, field, val)
+}
+
+// HVals 13	HVALS key 获取哈希表中所有值。
+func HVals[T any](key string) ([]T, error) {
+	return As[T](defaultClient).HVals(ctx, key)
 }
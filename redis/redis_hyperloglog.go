@@ -0,0 +1,49 @@
+package redis
+
+import "context"
+
+// PFAdd 1	PFADD key element [element ...] 添加指定元素到 HyperLogLog 中，基数估算值变化时返回true
+func (c *Client) PFAdd(ctx context.Context, key string, els ...interface{}) (bool, error) {
+	arr, err := c.encodeAll(els)
+	if err != nil {
+		return false, err
+	}
+	cmd := c.rdb.PFAdd(ctx, c.Key(key), arr...)
+	return 1 == cmd.Val(), cmd.Err()
+}
+
+// PFCount 2	PFCOUNT key [key ...] 返回给定 HyperLogLog 的基数估算值，多个KEY时返回并集的估算值
+func (c *Client) PFCount(ctx context.Context, keys ...string) (int64, error) {
+	fullKeys := c.keys(keys)
+	if err := c.sameSlot(fullKeys...); err != nil {
+		return 0, err
+	}
+	cmd := c.rdb.PFCount(ctx, fullKeys...)
+	return cmd.Val(), cmd.Err()
+}
+
+// PFMerge 3	PFMERGE destkey sourcekey [sourcekey ...] 将多个 HyperLogLog 合并到 destkey
+func (c *Client) PFMerge(ctx context.Context, destination string, keys ...string) error {
+	dst, src, err := c.storeKeys(destination, keys)
+	if err != nil {
+		return err
+	}
+	return c.rdb.PFMerge(ctx, dst, src...).Err()
+}
+
+// 以下包级函数委托给默认实例
+
+// PFAdd 1	PFADD key element [element ...] 添加指定元素到 HyperLogLog 中，基数估算值变化时返回true
+func PFAdd(key string, els ...interface{}) (bool, error) {
+	return defaultClient.PFAdd(ctx, key, els...)
+}
+
+// PFCount 2	PFCOUNT key [key ...] 返回给定 HyperLogLog 的基数估算值，多个KEY时返回并集的估算值
+func PFCount(keys ...string) (int64, error) {
+	return defaultClient.PFCount(ctx, keys...)
+}
+
+// PFMerge 3	PFMERGE destkey sourcekey [sourcekey ...] 将多个 HyperLogLog 合并到 destkey
+func PFMerge(destination string, keys ...string) error {
+	return defaultClient.PFMerge(ctx, destination, keys...)
+}
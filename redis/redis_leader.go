@@ -0,0 +1,221 @@
+// 基于redis租约的主节点选举
+// 各节点以 SETNX + 过期时间 抢占租约KEY，主节点定期续约，续约失败即失去主节点身份
+// 每次请求最长等待 ttl/2，超过上次成功续约的租约期限后 IsLeader 即返回 false，避免请求阻塞时出现两个主节点
+
+package redis
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/XingMenTech/common/utils"
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	leaderKeyPrefix       = "leader:"
+	defaultLeaderLeaseTTL = 15 * time.Second
+)
+
+var (
+	// 仅当租约仍属于自己时续约
+	renewLeaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+	// 仅当租约仍属于自己时释放
+	releaseLeaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+)
+
+// LeaderChangeHandler /主节点身份变化回调
+type LeaderChangeHandler func(name, nodeID string, isLeader bool)
+
+// LeaderElection /主节点选举
+type LeaderElection struct {
+	c        *Client
+	name     string
+	key      string
+	nodeID   string
+	ttl      time.Duration
+	interval time.Duration
+	isLeader int32
+	expireAt int64 // 租约期限(UnixNano)，以最近一次成功请求的发出时间计算
+	onChange LeaderChangeHandler
+	priority int
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        *sync.WaitGroup
+}
+
+// NewLeaderElection /工厂方法，使用默认实例，Start 时才读取默认实例，可在 InitRedisCache 之前创建
+// ttl 为租约时长，每 ttl/3 尝试抢占或续约
+func NewLeaderElection(name string, ttl time.Duration, onChange LeaderChangeHandler) *LeaderElection {
+	return newLeaderElection(nil, name, ttl, onChange)
+}
+
+// NewLeaderElection /工厂方法，ttl 为租约时长，每 ttl/3 尝试抢占或续约
+func (c *Client) NewLeaderElection(name string, ttl time.Duration, onChange LeaderChangeHandler) *LeaderElection {
+	return newLeaderElection(c, name, ttl, onChange)
+}
+
+func newLeaderElection(c *Client, name string, ttl time.Duration, onChange LeaderChangeHandler) *LeaderElection {
+	if ttl <= 0 {
+		ttl = defaultLeaderLeaseTTL
+	}
+	return &LeaderElection{
+		c:        c,
+		name:     name,
+		nodeID:   NewNodeID(),
+		ttl:      ttl,
+		interval: ttl / 3,
+		onChange: onChange,
+		priority: 3,
+		wg:       &sync.WaitGroup{},
+	}
+}
+
+// NewNodeID /生成节点标识: 主机名-进程号-随机串
+func NewNodeID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), utils.RandomString(8))
+}
+
+// Start /启动选举循环，ctx 结束或 Stop 时退出
+func (object *LeaderElection) Start(ctx context.Context) {
+	object.startOnce.Do(func() {
+		if nil == object.c {
+			object.c = defaultClient
+		}
+		object.key = object.c.Key(leaderKeyPrefix + object.name)
+		object.ctx, object.cancel = context.WithCancel(ctx)
+		object.wg.Add(1)
+		go object.loop()
+	})
+}
+
+// Stop /停止选举并释放租约
+func (object *LeaderElection) Stop() {
+	object.stopOnce.Do(func() {
+		if nil == object.cancel {
+			return
+		}
+		object.cancel()
+		object.wg.Wait()
+		if 1 == atomic.LoadInt32(&object.isLeader) {
+			releaseLeaseScript.Run(context.Background(), object.c.rdb, []string{object.key}, object.nodeID)
+			object.setLeader(false)
+		}
+	})
+}
+
+// IsLeader /当前节点是否为主节点，租约期限已过时返回 false
+func (object *LeaderElection) IsLeader() bool {
+	return 1 == atomic.LoadInt32(&object.isLeader) &&
+		time.Now().UnixNano() < atomic.LoadInt64(&object.expireAt)
+}
+
+// NodeID /当前节点标识
+func (object *LeaderElection) NodeID() string {
+	return object.nodeID
+}
+
+// Leader /当前主节点标识，无主节点或未启动时返回空串
+func (object *LeaderElection) Leader(ctx context.Context) (string, error) {
+	if "" == object.key {
+		return "", nil
+	}
+	val, err := object.c.rdb.Get(ctx, object.key).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return val, err
+}
+
+func (object *LeaderElection) loop() {
+	defer object.wg.Done()
+
+	ticker := time.NewTicker(object.interval)
+	defer ticker.Stop()
+
+	object.campaign()
+	for {
+		select {
+		case <-object.ctx.Done():
+			return
+		case <-ticker.C:
+			object.campaign()
+		}
+	}
+}
+
+// campaign /抢占或续约租约
+func (object *LeaderElection) campaign() {
+	ctx, cancel := context.WithTimeout(object.ctx, object.ttl/2)
+	defer cancel()
+
+	start := time.Now()
+	// 租约可能仍属于自己(续约请求超时等情况)，先尝试续约
+	n, err := renewLeaseScript.Run(ctx, object.c.rdb, []string{object.key}, object.nodeID, object.ttl.Milliseconds()).Int64()
+	ok := err == nil && n == 1
+	if err == nil && !ok {
+		ok, err = object.c.rdb.SetNX(ctx, object.key, object.nodeID, object.ttl).Result()
+	}
+	if err != nil {
+		// redis 不可用时无法确认租约，主动放弃主节点身份
+		object.setLeader(false)
+		return
+	}
+	if ok {
+		atomic.StoreInt64(&object.expireAt, start.Add(object.ttl).UnixNano())
+	}
+	object.setLeader(ok)
+}
+
+func (object *LeaderElection) setLeader(isLeader bool) {
+	var v int32
+	if isLeader {
+		v = 1
+	}
+	if atomic.SwapInt32(&object.isLeader, v) != v && nil != object.onChange {
+		object.onChange(object.name, object.nodeID, isLeader)
+	}
+}
+
+// Name /名字
+func (object *LeaderElection) Name() string {
+	return "LeaderElection"
+}
+
+// SetShutdownPriority /设置关闭优先级
+func (object *LeaderElection) SetShutdownPriority(priority int) {
+	object.priority = priority
+}
+
+// ShutdownPriority /关闭优先级
+func (object *LeaderElection) ShutdownPriority() int {
+	return object.priority
+}
+
+// BeforeShutdown /关闭之前
+func (object *LeaderElection) BeforeShutdown() {
+	object.Stop()
+}
+
+// AfterShutdown /关闭之后
+func (object *LeaderElection) AfterShutdown() {}
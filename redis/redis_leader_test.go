@@ -0,0 +1,102 @@
+package redis
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func waitFor(t *testing.T, cond func() bool, msg string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal(msg)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestLeaderElection 测试选举、续约、失去租约与主动让出
+func TestLeaderElection(t *testing.T) {
+	background := context.Background()
+	var mu sync.Mutex
+	changes := make(map[string][]bool)
+	onChange := func(name, nodeID string, isLeader bool) {
+		mu.Lock()
+		changes[nodeID] = append(changes[nodeID], isLeader)
+		mu.Unlock()
+	}
+
+	c, err := NewClient(&Config{Prefix: "election", Host: testAddr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	c.Delete(background, leaderKeyPrefix+"test")
+
+	a := c.NewLeaderElection("test", 150*time.Millisecond, onChange)
+	b := c.NewLeaderElection("test", 150*time.Millisecond, onChange)
+	a.Start(background)
+	waitFor(t, a.IsLeader, "a not elected")
+	b.Start(background)
+	defer b.Stop()
+
+	// 续约期间租约不会被抢占
+	time.Sleep(300 * time.Millisecond)
+	if !a.IsLeader() || b.IsLeader() {
+		t.Fatalf("leadership changed while renewing: a=%v b=%v", a.IsLeader(), b.IsLeader())
+	}
+	if leader, err := b.Leader(background); err != nil || leader != a.NodeID() {
+		t.Errorf("Leader = %s, %v", leader, err)
+	}
+
+	// 续约未在租约期限内完成时不再视为主节点
+	atomic.StoreInt64(&a.expireAt, time.Now().Add(-time.Millisecond).UnixNano())
+	if a.IsLeader() {
+		t.Error("leader after the lease deadline passed")
+	}
+	// 下一次续约成功后恢复
+	waitFor(t, a.IsLeader, "a not leader after renewal")
+
+	// 租约被他人占用时下台
+	c.Redis().Set(background, c.Key(leaderKeyPrefix+"test"), "other", time.Second)
+	waitFor(t, func() bool { return !a.IsLeader() }, "a did not step down after losing the lease")
+	c.Delete(background, leaderKeyPrefix+"test")
+	waitFor(t, func() bool { return a.IsLeader() || b.IsLeader() }, "no leader after lease released")
+
+	// 主动让出后由另一节点接任
+	leader, follower := a, b
+	if b.IsLeader() {
+		leader, follower = b, a
+	}
+	leader.Stop()
+	if leader.IsLeader() {
+		t.Error("leader still leader after Stop")
+	}
+	waitFor(t, follower.IsLeader, "follower did not take over")
+	a.Stop()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got := changes[a.NodeID()]; len(got) < 2 || !got[0] || got[1] {
+		t.Errorf("unexpected changes for a: %v", got)
+	}
+}
+
+// TestLeaderElectionDeferredClient 在 InitRedisCache 前创建的选举器在 Start 时使用默认实例
+func TestLeaderElectionDeferredClient(t *testing.T) {
+	saved := defaultClient
+	defaultClient = nil
+	e := NewLeaderElection("test_deferred", time.Second, nil)
+	defaultClient = saved
+
+	e.Start(context.Background())
+	defer e.Stop()
+	if e.key != defaultClient.Key(leaderKeyPrefix+"test_deferred") {
+		t.Errorf("unexpected key %s", e.key)
+	}
+	waitFor(t, e.IsLeader, "not elected")
+}
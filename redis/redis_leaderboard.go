@@ -0,0 +1,311 @@
+// 排行榜
+// 基于有序集合，分数从高到低排名，名次从1开始
+// 按日/周/月分桶时每个周期使用独立的KEY，写入时设置过期时间，过期的周期自动删除
+// 示例
+//
+//	lb := redis.NewLeaderboard[int64](redis.Default(), "agent_profit", redis.WithLeaderboardPeriod(redis.PeriodDaily))
+//	lb.Incr(ctx, agentId, amount)
+//	page, total, err := lb.Page(ctx, 1, 20)
+//	around, err := lb.Around(ctx, agentId, 5)
+//	yesterday, err := lb.At(time.Now().AddDate(0, 0, -1)).Top(ctx, 10)
+
+package redis
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/XingMenTech/common/utils"
+	"github.com/go-redis/redis/v8"
+)
+
+// Period 排行榜周期
+type Period string
+
+const (
+	// PeriodAll 总榜，不分桶不过期
+	PeriodAll Period = ""
+	// PeriodDaily 日榜
+	PeriodDaily Period = "daily"
+	// PeriodWeekly 周榜，周日为每周第一天，与 utils.CurWeekStart 一致
+	PeriodWeekly Period = "weekly"
+	// PeriodMonthly 月榜
+	PeriodMonthly Period = "monthly"
+)
+
+// start 返回 t 所在周期的开始时间
+func (p Period) start(t time.Time) time.Time {
+	switch p {
+	case PeriodDaily:
+		return utils.StartByTime(t)
+	case PeriodWeekly:
+		return utils.WeekStartByTime(t)
+	case PeriodMonthly:
+		return utils.MonthStartByTime(t)
+	}
+	return time.Time{}
+}
+
+// add 返回 start 之后第 n 个周期的开始时间
+func (p Period) add(start time.Time, n int) time.Time {
+	switch p {
+	case PeriodDaily:
+		return start.AddDate(0, 0, n)
+	case PeriodWeekly:
+		return start.AddDate(0, 0, 7*n)
+	case PeriodMonthly:
+		return start.AddDate(0, n, 0)
+	}
+	return start
+}
+
+// suffix 返回周期KEY后缀，日榜与周榜为开始日期，月榜为月份
+func (p Period) suffix(start time.Time) string {
+	switch p {
+	case PeriodDaily, PeriodWeekly:
+		return string(p) + ":" + start.Format("20060102")
+	case PeriodMonthly:
+		return string(p) + ":" + start.Format("200601")
+	}
+	return "all"
+}
+
+// Entry 排行榜条目
+type Entry[T any] struct {
+	Member T
+	Score  float64
+	Rank   int64
+}
+
+type leaderboardOptions struct {
+	period     Period
+	keep       int
+	sharedRank bool
+	now        func() time.Time
+}
+
+// LeaderboardOption 排行榜配置
+type LeaderboardOption func(o *leaderboardOptions)
+
+// WithLeaderboardPeriod 按周期分桶，默认 PeriodAll
+func WithLeaderboardPeriod(p Period) LeaderboardOption {
+	return func(o *leaderboardOptions) {
+		o.period = p
+	}
+}
+
+// WithLeaderboardKeep 周期结束后保留的周期数，默认1即保留上一周期，<0 时不过期
+func WithLeaderboardKeep(n int) LeaderboardOption {
+	return func(o *leaderboardOptions) {
+		o.keep = n
+	}
+}
+
+// WithSharedRank 同分同名次(1,2,2,4)，默认同分按成员字典序倒序依次排名
+func WithSharedRank() LeaderboardOption {
+	return func(o *leaderboardOptions) {
+		o.sharedRank = true
+	}
+}
+
+// Leaderboard 排行榜
+type Leaderboard[T any] struct {
+	t     Typed[T]
+	name  string
+	opts  *leaderboardOptions
+	start time.Time // At 指定的周期，零值表示当前周期
+}
+
+// NewLeaderboard /工厂方法
+func NewLeaderboard[T any](c *Client, name string, opts ...LeaderboardOption) *Leaderboard[T] {
+	o := &leaderboardOptions{keep: 1, now: utils.Now}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return &Leaderboard[T]{t: As[T](c), name: name, opts: o}
+}
+
+// At 返回 t 所在周期的排行榜，用于查询历史周期
+func (l *Leaderboard[T]) At(t time.Time) *Leaderboard[T] {
+	at := *l
+	at.start = l.opts.period.start(t.In(utils.TimeLocation))
+	return &at
+}
+
+// bucket 返回当前周期的开始时间
+func (l *Leaderboard[T]) bucket() time.Time {
+	if !l.start.IsZero() {
+		return l.start
+	}
+	return l.opts.period.start(l.opts.now())
+}
+
+// Key 返回当前周期的完整KEY
+func (l *Leaderboard[T]) Key() string {
+	return l.t.c.Key("leaderboard:" + l.name + ":" + l.opts.period.suffix(l.bucket()))
+}
+
+// write 执行写命令，分桶时同时设置过期时间
+func (l *Leaderboard[T]) write(ctx context.Context, fn func(pipe redis.Pipeliner, key string)) error {
+	key := l.Key()
+	_, err := l.t.c.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		fn(pipe, key)
+		if PeriodAll != l.opts.period && 0 <= l.opts.keep {
+			pipe.ExpireAt(ctx, key, l.opts.period.add(l.bucket(), 1+l.opts.keep))
+		}
+		return nil
+	})
+	return err
+}
+
+// Set 设置成员分数
+func (l *Leaderboard[T]) Set(ctx context.Context, member T, score float64) error {
+	val, err := l.t.encode(member)
+	if err != nil {
+		return err
+	}
+	return l.write(ctx, func(pipe redis.Pipeliner, key string) {
+		pipe.ZAdd(ctx, key, &redis.Z{Score: score, Member: val})
+	})
+}
+
+// SetIfHigher 仅当新分数高于原分数时更新，用于最高分榜
+func (l *Leaderboard[T]) SetIfHigher(ctx context.Context, member T, score float64) error {
+	val, err := l.t.encode(member)
+	if err != nil {
+		return err
+	}
+	return l.write(ctx, func(pipe redis.Pipeliner, key string) {
+		pipe.ZAddArgs(ctx, key, redis.ZAddArgs{GT: true, Members: []redis.Z{{Score: score, Member: val}}})
+	})
+}
+
+// Incr 为成员分数加上增量，返回新分数
+func (l *Leaderboard[T]) Incr(ctx context.Context, member T, delta float64) (float64, error) {
+	val, err := l.t.encode(member)
+	if err != nil {
+		return 0, err
+	}
+	var cmd *redis.FloatCmd
+	err = l.write(ctx, func(pipe redis.Pipeliner, key string) {
+		cmd = pipe.ZIncrBy(ctx, key, delta, val)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return cmd.Val(), nil
+}
+
+// Remove 移除成员
+func (l *Leaderboard[T]) Remove(ctx context.Context, members ...T) error {
+	vals := make([]interface{}, len(members))
+	for i, m := range members {
+		val, err := l.t.encode(m)
+		if err != nil {
+			return err
+		}
+		vals[i] = val
+	}
+	return l.t.c.rdb.ZRem(ctx, l.Key(), vals...).Err()
+}
+
+// Count 返回上榜成员数
+func (l *Leaderboard[T]) Count(ctx context.Context) (int64, error) {
+	return l.t.c.rdb.ZCard(ctx, l.Key()).Result()
+}
+
+// Rank 返回成员的名次与分数，未上榜时返回 Nil
+func (l *Leaderboard[T]) Rank(ctx context.Context, member T) (e Entry[T], err error) {
+	val, err := l.t.encode(member)
+	if err != nil {
+		return
+	}
+	key := l.Key()
+	var scoreCmd *redis.FloatCmd
+	var rankCmd *redis.IntCmd
+	if _, err = l.t.c.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		scoreCmd = pipe.ZScore(ctx, key, val)
+		rankCmd = pipe.ZRevRank(ctx, key, val)
+		return nil
+	}); err != nil {
+		return
+	}
+	e = Entry[T]{Member: member, Score: scoreCmd.Val(), Rank: rankCmd.Val() + 1}
+	if l.opts.sharedRank {
+		e.Rank, err = l.rankOf(ctx, key, e.Score)
+	}
+	return
+}
+
+// rankOf 同分同名次时的名次，即分数更高的成员数加1
+func (l *Leaderboard[T]) rankOf(ctx context.Context, key string, score float64) (int64, error) {
+	n, err := l.t.c.rdb.ZCount(ctx, key, "("+strconv.FormatFloat(score, 'g', -1, 64), "+inf").Result()
+	return n + 1, err
+}
+
+// Top 返回前 n 名
+func (l *Leaderboard[T]) Top(ctx context.Context, n int64) ([]Entry[T], error) {
+	return l.Range(ctx, 0, n-1)
+}
+
+// Page 分页返回排名，page 从1开始，同时返回上榜成员总数
+func (l *Leaderboard[T]) Page(ctx context.Context, page, size int64) ([]Entry[T], int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	entries, err := l.Range(ctx, (page-1)*size, page*size-1)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := l.Count(ctx)
+	return entries, total, err
+}
+
+// Around 返回成员及其前后各 n 名，未上榜时返回 Nil
+func (l *Leaderboard[T]) Around(ctx context.Context, member T, n int64) ([]Entry[T], error) {
+	val, err := l.t.encode(member)
+	if err != nil {
+		return nil, err
+	}
+	rank, err := l.t.c.rdb.ZRevRank(ctx, l.Key(), val).Result()
+	if err != nil {
+		return nil, err
+	}
+	return l.Range(ctx, max(rank-n, 0), rank+n)
+}
+
+// Range 按名次区间返回排名，start 与 stop 从0开始且包含 stop
+func (l *Leaderboard[T]) Range(ctx context.Context, start, stop int64) ([]Entry[T], error) {
+	key := l.Key()
+	zs, err := l.t.c.rdb.ZRevRangeWithScores(ctx, key, start, stop).Result()
+	if err != nil || 0 == len(zs) {
+		return nil, err
+	}
+
+	entries := make([]Entry[T], len(zs))
+	for i, z := range zs {
+		member, err := l.t.decode(z.Member.(string))
+		if err != nil {
+			return nil, err
+		}
+		entries[i] = Entry[T]{Member: member, Score: z.Score, Rank: start + int64(i) + 1}
+	}
+	if l.opts.sharedRank {
+		// 只需查询首个成员的名次，之后同分沿用上一名次，不同分即为位置名次
+		if entries[0].Rank, err = l.rankOf(ctx, key, entries[0].Score); err != nil {
+			return nil, err
+		}
+		for i := 1; i < len(entries); i++ {
+			if entries[i].Score == entries[i-1].Score {
+				entries[i].Rank = entries[i-1].Rank
+			}
+		}
+	}
+	return entries, nil
+}
+
+// Clear 删除当前周期的排行榜
+func (l *Leaderboard[T]) Clear(ctx context.Context) error {
+	return l.t.c.rdb.Del(ctx, l.Key()).Err()
+}
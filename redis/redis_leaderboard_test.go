@@ -0,0 +1,75 @@
+package redis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/XingMenTech/common/utils"
+)
+
+func TestLeaderboard(t *testing.T) {
+	lb := NewLeaderboard[string](defaultClient, "test_lb", WithSharedRank())
+	lb.Clear(ctx)
+
+	for member, score := range map[string]float64{"a": 50, "b": 40, "c": 40, "d": 30, "e": 10} {
+		if err := lb.Set(ctx, member, score); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if s, err := lb.Incr(ctx, "e", 25); err != nil || s != 35 {
+		t.Errorf("Incr = %v, %v", s, err)
+	}
+
+	page, total, err := lb.Page(ctx, 2, 2)
+	if err != nil || total != 5 || len(page) != 2 {
+		t.Fatalf("Page = %v, %d, %v", page, total, err)
+	}
+	// 第二页为 b/c 中的一个(同分第2名)与 e(第4名)
+	if page[0].Rank != 2 || page[1].Member != "e" || page[1].Rank != 4 {
+		t.Errorf("unexpected page %+v", page)
+	}
+
+	if e, err := lb.Rank(ctx, "c"); err != nil || e.Rank != 2 || e.Score != 40 {
+		t.Errorf("Rank = %+v, %v", e, err)
+	}
+	if _, err := lb.Rank(ctx, "missing"); err != Nil {
+		t.Errorf("expect Nil, got %v", err)
+	}
+
+	around, err := lb.Around(ctx, "e", 1)
+	if err != nil || len(around) != 3 || around[1].Member != "e" || around[2].Member != "d" {
+		t.Errorf("Around = %+v, %v", around, err)
+	}
+}
+
+func TestLeaderboardPeriod(t *testing.T) {
+	// 本周三，过期时间须在当前时间之后
+	week := utils.WeekStartByTime(utils.Now())
+	now := week.AddDate(0, 0, 3)
+	lb := NewLeaderboard[string](defaultClient, "test_lb_week", WithLeaderboardPeriod(PeriodWeekly))
+	lb.opts.now = func() time.Time { return now }
+
+	if key := lb.Key(); key != defaultClient.Key("leaderboard:test_lb_week:weekly:"+week.Format("20060102")) {
+		t.Errorf("unexpected key %s", key)
+	}
+	if err := lb.Set(ctx, "a", 1); err != nil {
+		t.Fatal(err)
+	}
+	// 周期结束后保留一周
+	ttl := defaultClient.rdb.TTL(ctx, lb.Key()).Val()
+	if ttl <= 0 {
+		t.Errorf("bucket not expiring, ttl %v", ttl)
+	}
+
+	if lb.At(now.AddDate(0, 0, -7)).Key() == lb.Key() {
+		t.Error("previous week uses the same key")
+	}
+	if top, err := lb.At(now.AddDate(0, 0, 1)).Top(ctx, 1); err != nil || len(top) != 1 || top[0].Member != "a" {
+		t.Errorf("same week Top = %v, %v", top, err)
+	}
+
+	monthly := NewLeaderboard[string](defaultClient, "test_lb_month", WithLeaderboardPeriod(PeriodMonthly))
+	if key := monthly.At(now).Key(); key != defaultClient.Key("leaderboard:test_lb_month:monthly:"+now.Format("200601")) {
+		t.Errorf("unexpected key %s", key)
+	}
+}
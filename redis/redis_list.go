@@ -1,183 +1,256 @@
 package redis
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 // BLPop 1	BLPOP key1 [key2... ] timeout 移出并获取列表的第一个元素， 如果列表没有元素会阻塞列表直到等待超时或发现可弹出元素为止。
-func BLPop[T any](timeout int, keys ...string) (k string, v T, err error) {
-	kArr := make([]string, len(keys))
-	for i, val := range keys {
-		kArr[i] = associate(val)
-	}
-	cmd := client.BLPop(ctx, time.Duration(timeout)*time.Second, kArr...)
+func (t Typed[T]) BLPop(ctx context.Context, timeout int, keys ...string) (k string, v T, err error) {
+	cmd := t.c.rdb.BLPop(ctx, time.Duration(timeout)*time.Second, t.c.keys(keys)...)
 	if cmd.Err() != nil {
 		err = cmd.Err()
 		return
 	}
 	k = cmd.Val()[0]
-	v, err = decodeVal[T](cmd.Val()[1])
+	v, err = t.decode(cmd.Val()[1])
 	return
 }
 
 // BRPop 2	BRPOP key1 [key2 ] timeout 移出并获取列表的最后一个元素， 如果列表没有元素会阻塞列表直到等待超时或发现可弹出元素为止。
-func BRPop[T any](timeout int, keys ...string) (k string, v T, err error) {
-	kArr := make([]string, len(keys))
-	for i, val := range keys {
-		kArr[i] = associate(val)
-	}
-	cmd := client.BRPop(ctx, time.Duration(timeout)*time.Second, kArr...)
+func (t Typed[T]) BRPop(ctx context.Context, timeout int, keys ...string) (k string, v T, err error) {
+	cmd := t.c.rdb.BRPop(ctx, time.Duration(timeout)*time.Second, t.c.keys(keys)...)
 	if cmd.Err() != nil {
 		err = cmd.Err()
 		return
 	}
 	k = cmd.Val()[0]
-	v, err = decodeVal[T](cmd.Val()[1])
+	v, err = t.decode(cmd.Val()[1])
 	return
 }
 
 // BRPopLPush 3	BRPOPLPUSH source destination timeout 从列表中弹出一个值，将弹出的元素插入到另外一个列表中并返回它； 如果列表没有元素会阻塞列表直到等待超时或发现可弹出元素为止。
-func BRPopLPush(source, destination string, timeout int) (string, error) {
-	cmd := client.BRPopLPush(ctx, associate(source), associate(destination), time.Duration(timeout)*time.Second)
+func (c *Client) BRPopLPush(ctx context.Context, source, destination string, timeout int) (string, error) {
+	src, dst := c.Key(source), c.Key(destination)
+	if err := c.sameSlot(src, dst); err != nil {
+		return "", err
+	}
+	cmd := c.rdb.BRPopLPush(ctx, src, dst, time.Duration(timeout)*time.Second)
 	return cmd.Val(), cmd.Err()
 }
 
 // LIndex 4	LINDEX key index 通过索引获取列表中的元素
-func LIndex[T any](key string, index int64) (t T, err error) {
-	cmd := client.LIndex(ctx, associate(key), index)
+func (t Typed[T]) LIndex(ctx context.Context, key string, index int64) (v T, err error) {
+	cmd := t.c.rdb.LIndex(ctx, t.c.Key(key), index)
 	if cmd.Err() != nil {
 		err = cmd.Err()
 		return
 	}
-	return decodeVal[T](cmd.Val())
+	return t.decode(cmd.Val())
 }
 
 // LInsert 5	LINSERT key BEFORE|AFTER pivot value 在列表的元素前或者后插入元素
-func LInsert(key string, before string, pivot, val interface{}) error {
-	bytes, err := encode(val)
+func (c *Client) LInsert(ctx context.Context, key string, before string, pivot, val interface{}) error {
+	bytes, err := c.encode(val)
 	if err != nil {
 		return err
 	}
-	return client.LInsert(ctx, associate(key), before, pivot, bytes).Err()
+	return c.rdb.LInsert(ctx, c.Key(key), before, pivot, bytes).Err()
 }
 
 // LLen 6	LLEN key 获取列表长度
-func LLen(key string) (int64, error) {
-	cmd := client.LLen(ctx, associate(key))
+func (c *Client) LLen(ctx context.Context, key string) (int64, error) {
+	cmd := c.rdb.LLen(ctx, c.Key(key))
 	return cmd.Val(), cmd.Err()
 }
 
 // LPop 7	LPOP key 移出并获取列表的第一个元素
-func LPop[T any](key string) (t T, err error) {
-	cmd := client.LPop(ctx, associate(key))
+func (t Typed[T]) LPop(ctx context.Context, key string) (v T, err error) {
+	cmd := t.c.rdb.LPop(ctx, t.c.Key(key))
 	if cmd.Err() != nil {
 		err = cmd.Err()
 		return
 	}
-	return decodeVal[T](cmd.Val())
+	return t.decode(cmd.Val())
 }
 
 // LPush 8	LPUSH key value1 [value2] 将一个或多个值插入到列表头部
-func LPush(key string, vals ...interface{}) error {
-	arr := make([]interface{}, len(vals))
-	for i, v := range vals {
-		val, err := encode(v)
-		if err != nil {
-			return err
-		}
-		arr[i] = val
+func (c *Client) LPush(ctx context.Context, key string, vals ...interface{}) error {
+	arr, err := c.encodeAll(vals)
+	if err != nil {
+		return err
 	}
-	return client.LPush(ctx, associate(key), arr...).Err()
+	return c.rdb.LPush(ctx, c.Key(key), arr...).Err()
 }
 
 // LPushX 9	LPUSHX key value 将一个值插入到已存在的列表头部
-func LPushX(key string, vals ...interface{}) error {
-	arr := make([]interface{}, len(vals))
-	for i, v := range vals {
-		val, err := encode(v)
-		if err != nil {
-			return err
-		}
-		arr[i] = val
+func (c *Client) LPushX(ctx context.Context, key string, vals ...interface{}) error {
+	arr, err := c.encodeAll(vals)
+	if err != nil {
+		return err
 	}
-	return client.LPushX(ctx, associate(key), arr...).Err()
+	return c.rdb.LPushX(ctx, c.Key(key), arr...).Err()
 }
 
 // LRange 10	LRANGE key start stop 获取列表指定范围内的元素
-func LRange[T any](key string, start, stop int64) (res []T, err error) {
-	cmd := client.LRange(ctx, associate(key), start, stop)
+func (t Typed[T]) LRange(ctx context.Context, key string, start, stop int64) (res []T, err error) {
+	cmd := t.c.rdb.LRange(ctx, t.c.Key(key), start, stop)
 	if cmd.Err() != nil {
 		err = cmd.Err()
 		return
 	}
-	return decodeArr[T](cmd.Val())
+	return t.decodeArr(cmd.Val())
 }
 
 // LRem 11	LREM key count value 移除列表元素
-func LRem(key string, index int64, val interface{}) (int64, error) {
-	bytes, err := encode(val)
+func (c *Client) LRem(ctx context.Context, key string, index int64, val interface{}) (int64, error) {
+	bytes, err := c.encode(val)
 	if err != nil {
 		return 0, err
 	}
-	cmd := client.LRem(ctx, associate(key), index, bytes)
+	cmd := c.rdb.LRem(ctx, c.Key(key), index, bytes)
 	return cmd.Val(), cmd.Err()
 }
 
 // LSet 12	LSET key index value 通过索引设置列表元素的值
-func LSet(key string, index int64, val interface{}) error {
-	bytes, err := encode(val)
+func (c *Client) LSet(ctx context.Context, key string, index int64, val interface{}) error {
+	bytes, err := c.encode(val)
 	if err != nil {
 		return err
 	}
-	return client.LSet(ctx, associate(key), index, bytes).Err()
+	return c.rdb.LSet(ctx, c.Key(key), index, bytes).Err()
 }
 
 // Ltrim 13	LTRIM key start stop 对一个列表进行修剪(trim)，就是说，让列表只保留指定区间内的元素，不在指定区间之内的元素都将被删除。
-func Ltrim(key string, start, stop int64) error {
-	return client.LTrim(ctx, associate(key), start, stop).Err()
+func (c *Client) Ltrim(ctx context.Context, key string, start, stop int64) error {
+	return c.rdb.LTrim(ctx, c.Key(key), start, stop).Err()
 }
 
 // RPop 14	RPOP key 移除列表的最后一个元素，返回值为移除的元素。
-func RPop[T any](key string) (t T, err error) {
-	cmd := client.RPop(ctx, associate(key))
+func (t Typed[T]) RPop(ctx context.Context, key string) (v T, err error) {
+	cmd := t.c.rdb.RPop(ctx, t.c.Key(key))
 	if cmd.Err() != nil {
 		err = cmd.Err()
 		return
 	}
-	return decodeVal[T](cmd.Val())
+	return t.decode(cmd.Val())
 }
 
 // RPopLPush 15	RPOPLPUSH source destination 移除列表的最后一个元素，并将该元素添加到另一个列表并返回
-func RPopLPush[T any](source, destination string) (t T, err error) {
-	cmd := client.RPopLPush(ctx, associate(source), associate(destination))
+func (t Typed[T]) RPopLPush(ctx context.Context, source, destination string) (v T, err error) {
+	src, dst := t.c.Key(source), t.c.Key(destination)
+	if err = t.c.sameSlot(src, dst); err != nil {
+		return
+	}
+	cmd := t.c.rdb.RPopLPush(ctx, src, dst)
 	if cmd.Err() != nil {
 		err = cmd.Err()
 		return
 	}
-	return decodeVal[T](cmd.Val())
+	return t.decode(cmd.Val())
 }
 
 // RPush 16	RPUSH key value1 [value2] 在列表中添加一个或多个值到列表尾部
-func RPush(key string, vals ...any) error {
-	arr := make([]interface{}, len(vals))
-	for i, v := range vals {
-		val, err := encode(v)
-		if err != nil {
-			return err
-		}
-		arr[i] = val
+func (c *Client) RPush(ctx context.Context, key string, vals ...any) error {
+	arr, err := c.encodeAll(vals)
+	if err != nil {
+		return err
+	}
+	return c.rdb.RPush(ctx, c.Key(key), arr...).Err()
+}
+
+// RPushX 17 RPUSHX key value 为已存在的列表添加值
+func (c *Client) RPushX(ctx context.Context, key string, vals ...interface{}) error {
+	arr, err := c.encodeAll(vals)
+	if err != nil {
+		return err
 	}
+	return c.rdb.RPushX(ctx, c.Key(key), arr...).Err()
+}
+
+// 以下包级函数委托给默认实例
+
+// BLPop 1	BLPOP key1 [key2... ] timeout 移出并获取列表的第一个元素， 如果列表没有元素会阻塞列表直到等待超时或发现可弹出元素为止。
+func BLPop[T any](timeout int, keys ...string) (k string, v T, err error) {
+	return As[T](defaultClient).BLPop(ctx, timeout, keys...)
+}
+
+// BRPop 2	BRPOP key1 [key2 ] timeout 移出并获取列表的最后一个元素， 如果列表没有元素会阻塞列表直到等待超时或发现可弹出元素为止。
+func BRPop[T any](timeout int, keys ...string) (k string, v T, err error) {
+	return As[T](defaultClient).BRPop(ctx, timeout, keys...)
+}
 
-	return client.RPush(ctx, associate(key), arr...).Err()
+// BRPopLPush 3	BRPOPLPUSH source destination timeout 从列表中弹出一个值，将弹出的元素插入到另外一个列表中并返回它； 如果列表没有元素会阻塞列表直到等待超时或发现可弹出元素为止。
+func BRPopLPush(source, destination string, timeout int) (string, error) {
+	return defaultClient.BRPopLPush(ctx, source, destination, timeout)
+}
+
+// LIndex 4	LINDEX key index 通过索引获取列表中的元素
+func LIndex[T any](key string, index int64) (t T, err error) {
+	return As[T](defaultClient).LIndex(ctx, key, index)
+}
+
+// LInsert 5	LINSERT key BEFORE|AFTER pivot value 在列表的元素前或者后插入元素
+func LInsert(key string, before string, pivot, val interface{}) error {
+	return defaultClient.LInsert(ctx, key, before, pivot, val)
+}
+
+// LLen 6	LLEN key 获取列表长度
+func LLen(key string) (int64, error) {
+	return defaultClient.LLen(ctx, key)
+}
+
+// LPop 7	LPOP key 移出并获取列表的第一个元素
+func LPop[T any](key string) (t T, err error) {
+	return As[T](defaultClient).LPop(ctx, key)
+}
+
+// LPush 8	LPUSH key value1 [value2] 将一个或多个值插入到列表头部
+func LPush(key string, vals ...interface{}) error {
+	return defaultClient.LPush(ctx, key, vals...)
+}
+
+// LPushX 9	LPUSHX key value 将一个值插入到已存在的列表头部
+func LPushX(key string, vals ...interface{}) error {
+	return defaultClient.LPushX(ctx, key, vals...)
+}
+
+// LRange 10	LRANGE key start stop 获取列表指定范围内的元素
+func LRange[T any](key string, start, stop int64) (res []T, err error) {
+	return As[T](defaultClient).LRange(ctx, key, start, stop)
+}
+
+// LRem 11	LREM key count value 移除列表元素
+func LRem(key string, index int64, val interface{}) (int64, error) {
+	return defaultClient.LRem(ctx, key, index, val)
+}
+
+// LSet 12	LSET key index value 通过索引设置列表元素的值
+func LSet(key string, index int64, val interface{}) error {
+	return defaultClient.LSet(ctx, key, index, val)
+}
+
+// Ltrim 13	LTRIM key start stop 对一个列表进行修剪(trim)，就是说，让列表只保留指定区间内的元素，不在指定区间之内的元素都将被删除。
+func Ltrim(key string, start, stop int64) error {
+	return defaultClient.Ltrim(ctx, key, start, stop)
+}
+
+// RPop 14	RPOP key 移除列表的最后一个元素，返回值为移除的元素。
+func RPop[T any](key string) (t T, err error) {
+	return As[T](defaultClient).RPop(ctx, key)
+}
+
+// RPopLPush 15	RPOPLPUSH source destination 移除列表的最后一个元素，并将该元素添加到另一个列表并返回
+func RPopLPush[T any](source, destination string) (t T, err error) {
+	return As[T](defaultClient).RPopLPush(ctx, source, destination)
+}
+
+// RPush 16	RPUSH key value1 [value2] 在列表中添加一个或多个值到列表尾部
+func RPush(key string, vals ...any) error {
+	return defaultClient.RPush(ctx, key, vals...)
 }
 
 // RPushX 17 RPUSHX key value 为已存在的列表添加值
 func RPushX(key string, vals ...interface{}) error {
-	arr := make([]interface{}, len(vals))
-	for i, v := range vals {
-		val, err := encode(v)
-		if err != nil {
-			return err
-		}
-		arr[i] = val
-	}
-	return client.RPushX(ctx, associate(key), arr...).Err()
+	return defaultClient.RPushX(ctx, key, vals...)
 }
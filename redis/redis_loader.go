@@ -0,0 +1,200 @@
+// 缓存旁路加载
+// GetOrLoad 先读缓存，未命中时调用 loader 回源并写回缓存
+// 同一进程内相同KEY的并发回源通过 singleflight 合并为一次；loader 返回 ErrNotFound 时缓存空值，避免穿透
+// 过期时间附加随机抖动，避免大量KEY同时过期；开启提前刷新后，剩余时间不足时后台回源，请求继续使用旧值
+// 示例
+// user, err := redis.GetOrLoad("user:"+id, 10*time.Minute, func() (*User, error) {
+// 	  u := database.FindOne[User](id)
+// 	  if u == nil {
+// 	 	  return nil, redis.ErrNotFound
+// 	  }
+// 	  return u, nil
+// }, redis.WithEarlyRefresh(time.Minute))
+
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"runtime/debug"
+	"time"
+
+	"github.com/XingMenTech/common/logger"
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// negativeValue 空值占位，loader 返回 ErrNotFound 时写入
+	negativeValue      = "\x00nil"
+	defaultNegativeTTL = time.Minute
+	defaultTTLJitter   = 0.1
+)
+
+// ErrNotFound loader 未找到数据时返回，GetOrLoad 会缓存空值并原样返回该错误
+var ErrNotFound = errors.New("redis loader: not found")
+
+// LoadOption GetOrLoad 选项
+type LoadOption func(*loadOptions)
+
+type loadOptions struct {
+	negativeTTL  time.Duration
+	jitter       float64
+	earlyRefresh time.Duration
+}
+
+// WithNegativeTTL 空值的缓存时长，默认1分钟且不超过ttl，为0时不缓存空值
+func WithNegativeTTL(d time.Duration) LoadOption {
+	return func(o *loadOptions) {
+		o.negativeTTL = d
+	}
+}
+
+// WithTTLJitter 过期时间随机增加 [0, ttl*fraction)，默认0.1，为0时关闭
+func WithTTLJitter(fraction float64) LoadOption {
+	return func(o *loadOptions) {
+		o.jitter = fraction
+	}
+}
+
+// WithEarlyRefresh 命中时剩余过期时间小于 before 则后台回源刷新
+func WithEarlyRefresh(before time.Duration) LoadOption {
+	return func(o *loadOptions) {
+		o.earlyRefresh = before
+	}
+}
+
+func newLoadOptions(ttl time.Duration, opts []LoadOption) *loadOptions {
+	o := &loadOptions{
+		negativeTTL: defaultNegativeTTL,
+		jitter:      defaultTTLJitter,
+	}
+	if ttl > 0 && ttl < o.negativeTTL {
+		o.negativeTTL = ttl
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// expiration 附加抖动后的过期时间
+func (o *loadOptions) expiration(ttl time.Duration) time.Duration {
+	if ttl <= 0 {
+		return 0
+	}
+	if o.jitter <= 0 {
+		return ttl
+	}
+	n := int64(float64(ttl) * o.jitter)
+	if n <= 0 {
+		return ttl
+	}
+	return ttl + time.Duration(rand.Int63n(n))
+}
+
+// GetOrLoad 读取缓存，未命中时通过 loader 回源并写回，ttl<=0 时不过期
+func (t Typed[T]) GetOrLoad(ctx context.Context, key string, ttl time.Duration, loader func() (T, error), opts ...LoadOption) (T, error) {
+	o := newLoadOptions(ttl, opts)
+	fullKey := t.c.Key(key)
+
+	data, remaining, err := t.lookup(ctx, fullKey, o.earlyRefresh > 0)
+	if err == nil {
+		if negativeValue == data {
+			var zero T
+			return zero, ErrNotFound
+		}
+		val, err := t.decode(data)
+		if err == nil {
+			if o.earlyRefresh > 0 && remaining > 0 && remaining < o.earlyRefresh {
+				go t.refresh(context.WithoutCancel(ctx), fullKey, ttl, o, loader)
+			}
+			return val, nil
+		}
+	}
+	// 缓存未命中、读取失败或数据无法解码时均回源
+	return t.load(ctx, fullKey, ttl, o, loader)
+}
+
+// lookup 读取缓存值，需要时一并读取剩余过期时间
+func (t Typed[T]) lookup(ctx context.Context, fullKey string, withTTL bool) (string, time.Duration, error) {
+	if !withTTL {
+		data, err := t.c.rdb.Get(ctx, fullKey).Result()
+		return data, 0, err
+	}
+
+	var get *redis.StringCmd
+	var pttl *redis.DurationCmd
+	_, err := t.c.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		get = pipe.Get(ctx, fullKey)
+		pttl = pipe.PTTL(ctx, fullKey)
+		return nil
+	})
+	if err != nil {
+		return "", 0, err
+	}
+	return get.Val(), pttl.Val(), nil
+}
+
+// refresh 后台提前刷新，loader panic 时记录日志，避免进程退出
+func (t Typed[T]) refresh(ctx context.Context, fullKey string, ttl time.Duration, o *loadOptions, loader func() (T, error)) {
+	defer func() {
+		if r := recover(); r != nil {
+			logRefreshPanic(fullKey, r, debug.Stack())
+		}
+	}()
+	t.load(ctx, fullKey, ttl, o, loader)
+}
+
+// logRefreshPanic 日志未初始化时输出到标准错误
+func logRefreshPanic(fullKey string, r interface{}, stack []byte) {
+	if nil == logger.LOG {
+		fmt.Fprintf(os.Stderr, "redis loader: refresh %s panic: %v\n%s", fullKey, r, stack)
+		return
+	}
+	logger.LOG.WithField("module", "RedisLoader").Errorf("refresh %s panic: %v\n%s", fullKey, r, stack)
+}
+
+// load 合并同KEY的并发回源，并将结果写回缓存
+func (t Typed[T]) load(ctx context.Context, fullKey string, ttl time.Duration, o *loadOptions, loader func() (T, error)) (T, error) {
+	res, err, _ := t.c.loads.Do(fullKey, func() (interface{}, error) {
+		val, err := loader()
+		if errors.Is(err, ErrNotFound) {
+			if o.negativeTTL > 0 {
+				t.c.store(ctx, fullKey, negativeValue, o.negativeTTL)
+			}
+			return val, err
+		}
+		if err != nil {
+			return val, err
+		}
+
+		// 写回失败不影响本次结果，下次读取时重新回源
+		if data, err := t.encode(val); err == nil {
+			t.c.store(ctx, fullKey, data, o.expiration(ttl))
+		}
+		return val, nil
+	})
+
+	val, ok := res.(T)
+	if !ok {
+		// 相同KEY被不同类型并发加载，或 loader 返回了nil接口
+		var zero T
+		return zero, err
+	}
+	return val, err
+}
+
+// store 写回缓存，与 Client.Set 一样通知本地缓存失效
+func (c *Client) store(ctx context.Context, fullKey string, data string, expiration time.Duration) error {
+	return c.changed(ctx, c.rdb.Set(ctx, fullKey, data, expiration).Err(), fullKey)
+}
+
+// 以下包级函数委托给默认实例
+
+// GetOrLoad 读取缓存，未命中时通过 loader 回源并写回，ttl<=0 时不过期
+func GetOrLoad[T any](key string, ttl time.Duration, loader func() (T, error), opts ...LoadOption) (T, error) {
+	return As[T](defaultClient).GetOrLoad(ctx, key, ttl, loader, opts...)
+}
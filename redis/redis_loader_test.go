@@ -0,0 +1,95 @@
+package redis
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetOrLoad(t *testing.T) {
+	Delete("test_load")
+	var calls int32
+	loader := func() (int, error) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(50 * time.Millisecond)
+		return 42, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := GetOrLoad("test_load", time.Minute, loader)
+			if err != nil || v != 42 {
+				t.Errorf("GetOrLoad = %v, %v", v, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("concurrent loads not merged, loader called %d times", n)
+	}
+	if v, err := Get[int]("test_load"); err != nil || v != 42 {
+		t.Errorf("value not written back: %v, %v", v, err)
+	}
+
+	// 空值缓存
+	Delete("test_load_missing")
+	calls = 0
+	missing := func() (*int, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, ErrNotFound
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := GetOrLoad("test_load_missing", time.Minute, missing); !errors.Is(err, ErrNotFound) {
+			t.Errorf("expect ErrNotFound, got %v", err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("not found result not cached, loader called %d times", calls)
+	}
+
+	// 提前刷新
+	Delete("test_load_refresh")
+	refreshed := make(chan struct{}, 1)
+	version := int32(0)
+	refresh := func() (int32, error) {
+		v := atomic.AddInt32(&version, 1)
+		if v > 1 {
+			refreshed <- struct{}{}
+		}
+		return v, nil
+	}
+	GetOrLoad("test_load_refresh", time.Second, refresh, WithTTLJitter(0))
+	v, err := GetOrLoad("test_load_refresh", time.Second, refresh, WithEarlyRefresh(2*time.Second))
+	if err != nil || v != 1 {
+		t.Errorf("expect stale value 1, got %v, %v", v, err)
+	}
+	select {
+	case <-refreshed:
+	case <-time.After(time.Second):
+		t.Error("early refresh not triggered")
+	}
+
+	// 后台刷新 panic 不影响进程
+	panicked := make(chan struct{})
+	v, err = GetOrLoad("test_load_refresh", time.Second, func() (int32, error) {
+		defer close(panicked)
+		panic("refresh failed")
+	}, WithEarlyRefresh(2*time.Second))
+	if err != nil || v == 0 {
+		t.Errorf("expect cached value, got %v, %v", v, err)
+	}
+	select {
+	case <-panicked:
+	case <-time.After(time.Second):
+		t.Error("early refresh not triggered")
+	}
+
+	Delete("test_load")
+	Delete("test_load_missing")
+	Delete("test_load_refresh")
+}
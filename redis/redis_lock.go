@@ -0,0 +1,213 @@
+// 基于redis的分布式锁
+// 以 SET NX PX 抢占锁KEY，值为本次持有者的随机标识，仅持有者可续约与释放
+// 每次成功加锁时 INCR 栅栏计数器得到单调递增的栅栏令牌，下游存储可据此拒绝过期持有者的写入
+// 示例
+// lock := redis.NewLock("order:" + orderNo)
+// if err := lock.Acquire(ctx, 10*time.Second); err != nil {
+// 	  return err
+// }
+// defer lock.Release(ctx)
+
+package redis
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/XingMenTech/common/utils"
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	lockKeyPrefix      = "lock:"
+	lockMinRetry       = 10 * time.Millisecond
+	lockMaxRetry       = 500 * time.Millisecond
+	defaultLockLeaseMs = 30 * 1000
+)
+
+var (
+	// ErrLockNotAcquired 锁被其他持有者占用
+	ErrLockNotAcquired = errors.New("redis lock: not acquired")
+	// ErrLockNotHeld 锁未被当前实例持有，或租约已过期被他人抢占
+	ErrLockNotHeld = errors.New("redis lock: not held")
+	// ErrLockHeld 当前实例已持有锁
+	ErrLockHeld = errors.New("redis lock: already held")
+)
+
+var (
+	// 抢占成功时返回新的栅栏令牌，失败返回0
+	acquireLockScript = redis.NewScript(`
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return redis.call("INCR", KEYS[2])
+end
+return 0`)
+)
+
+// Lock 分布式锁，同一实例同一时刻只能持有一次
+type Lock struct {
+	sync.Mutex
+	c        *Client
+	key      string
+	fenceKey string
+
+	owner string
+	fence int64
+	lost  chan struct{}
+	stop  context.CancelFunc
+	// renewDone 在续约协程退出时关闭
+	renewDone chan struct{}
+}
+
+// NewLock 在默认实例上创建名为 name 的锁，KEY 与 associate 使用相同前缀
+func NewLock(name string) *Lock {
+	return defaultClient.NewLock(name)
+}
+
+// NewLock 创建名为 name 的锁
+// 锁KEY与栅栏计数器使用相同的hash tag，集群模式下位于同一slot
+func (c *Client) NewLock(name string) *Lock {
+	return &Lock{
+		c:        c,
+		key:      c.Key(lockKeyPrefix + "{" + name + "}"),
+		fenceKey: c.Key(lockKeyPrefix + "{" + name + "}:fence"),
+	}
+}
+
+// TryAcquire 尝试加锁一次，锁被占用时返回 false
+// 加锁成功后后台每 ttl/3 续约一次，直到 Release 或续约失败
+func (l *Lock) TryAcquire(ctx context.Context, ttl time.Duration) (bool, error) {
+	if ttl <= 0 {
+		ttl = defaultLockLeaseMs * time.Millisecond
+	}
+
+	l.Lock()
+	defer l.Unlock()
+	if "" != l.owner {
+		return false, ErrLockHeld
+	}
+
+	owner := NewNodeID() + "-" + utils.RandomString(8)
+	fence, err := acquireLockScript.Run(ctx, l.c.rdb, []string{l.key, l.fenceKey}, owner, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return false, err
+	}
+	if 0 == fence {
+		return false, nil
+	}
+
+	l.owner, l.fence = owner, fence
+	l.lost = make(chan struct{})
+	renewCtx, cancel := context.WithCancel(context.Background())
+	l.stop = cancel
+	l.renewDone = make(chan struct{})
+	go l.renew(renewCtx, owner, ttl, l.lost, l.renewDone)
+	return true, nil
+}
+
+// Acquire 加锁，锁被占用时退避重试直到成功或 ctx 结束
+func (l *Lock) Acquire(ctx context.Context, ttl time.Duration) error {
+	retry := lockMinRetry
+	for {
+		ok, err := l.TryAcquire(ctx, ttl)
+		if err != nil || ok {
+			return err
+		}
+
+		// 加入随机抖动，避免竞争者同时重试
+		wait := retry/2 + time.Duration(rand.Int63n(int64(retry)))
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(ErrLockNotAcquired, ctx.Err())
+		case <-timer.C:
+		}
+		if retry *= 2; retry > lockMaxRetry {
+			retry = lockMaxRetry
+		}
+	}
+}
+
+// Release 释放锁，仅删除自己持有的锁
+func (l *Lock) Release(ctx context.Context) error {
+	l.Lock()
+	owner := l.owner
+	if "" == owner {
+		l.Unlock()
+		return ErrLockNotHeld
+	}
+	l.owner, l.fence = "", 0
+	l.stop()
+	renewDone := l.renewDone
+	l.Unlock()
+	<-renewDone
+
+	n, err := releaseLeaseScript.Run(ctx, l.c.rdb, []string{l.key}, owner).Int64()
+	if err != nil {
+		return err
+	}
+	if 0 == n {
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
+// Token 当前持有的栅栏令牌，未持有时返回0
+func (l *Lock) Token() int64 {
+	l.Lock()
+	defer l.Unlock()
+	return l.fence
+}
+
+// Held 当前实例是否认为自己持有锁
+func (l *Lock) Held() bool {
+	l.Lock()
+	defer l.Unlock()
+	return "" != l.owner && !isClosed(l.lost)
+}
+
+// Lost 返回的通道在续约失败(锁已过期或被抢占)时关闭，持有者应停止受保护的操作
+func (l *Lock) Lost() <-chan struct{} {
+	l.Lock()
+	defer l.Unlock()
+	return l.lost
+}
+
+// renew 定期续约，续约失败时关闭 lost
+func (l *Lock) renew(renewCtx context.Context, owner string, ttl time.Duration, lost, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+
+	expireAt := time.Now().Add(ttl)
+	for {
+		select {
+		case <-renewCtx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		n, err := renewLeaseScript.Run(renewCtx, l.c.rdb, []string{l.key}, owner, ttl.Milliseconds()).Int64()
+		switch {
+		case err == nil && 1 == n:
+			expireAt = time.Now().Add(ttl)
+		case err == nil || time.Now().After(expireAt):
+			// 锁已不属于自己，或网络错误持续到租约过期
+			close(lost)
+			return
+		}
+	}
+}
+
+func isClosed(ch chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
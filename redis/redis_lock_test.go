@@ -0,0 +1,75 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLock(t *testing.T) {
+	ctx := context.Background()
+	a, b := NewLock("test_lock"), NewLock("test_lock")
+
+	if err := a.Acquire(ctx, 300*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	first := a.Token()
+
+	ok, err := b.TryAcquire(ctx, time.Second)
+	if err != nil || ok {
+		t.Fatalf("acquired a held lock: %v %v", ok, err)
+	}
+	if _, err = a.TryAcquire(ctx, time.Second); !errors.Is(err, ErrLockHeld) {
+		t.Fatalf("expect ErrLockHeld, got %v", err)
+	}
+
+	// the lease outlives its ttl while renewed
+	time.Sleep(500 * time.Millisecond)
+	if !a.Held() {
+		t.Fatal("lock lost while renewing")
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if err = b.Acquire(timeout, time.Second); !errors.Is(err, ErrLockNotAcquired) {
+		t.Fatalf("expect ErrLockNotAcquired, got %v", err)
+	}
+
+	if err = a.Release(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err = a.Release(ctx); !errors.Is(err, ErrLockNotHeld) {
+		t.Fatalf("expect ErrLockNotHeld, got %v", err)
+	}
+
+	if err = b.Acquire(ctx, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if b.Token() <= first {
+		t.Fatalf("fencing token not increasing: %d <= %d", b.Token(), first)
+	}
+	b.Release(ctx)
+}
+
+// TestLockInstanceClient 未调用 InitRedisCache 时实例客户端的锁不依赖包级 ctx
+func TestLockInstanceClient(t *testing.T) {
+	c, err := NewClient(&Config{Prefix: "instance", Host: testAddr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	saved := ctx
+	ctx = nil
+	defer func() { ctx = saved }()
+
+	l := c.NewLock("test_instance_lock")
+	background := context.Background()
+	if err = l.Acquire(background, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if err = l.Release(background); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,375 @@
+// 管道与事务
+// Pipeline 将多个命令合并为一次往返，TxPipeline 以 MULTI/EXEC 包裹保证原子执行
+// 写命令返回 go-redis 的命令对象，读命令通过 PipeGet[T] 等函数返回 Result[T]，Exec 之后取值
+// Transaction 基于 WATCH 实现乐观事务，被监视的KEY在提交前被修改时自动重试
+// 示例
+//
+//	err := redis.Pipelined(func(p *redis.Pipeline) error {
+//		for _, u := range users {
+//			p.HSet("user:"+u.Id, "info", u)
+//			p.ExpireIn("user:"+u.Id, time.Hour)
+//		}
+//		return nil
+//	})
+//
+//	err := redis.Transaction([]string{"stock"}, func(tx *redis.Tx) error {
+//		stock, err := redis.TxGet[int](tx, "stock")
+//		if err != nil {
+//			return err
+//		}
+//		return tx.Exec(func(p *redis.Pipeline) error {
+//			p.Set("stock", stock-1, 0)
+//			return nil
+//		})
+//	}, 0)
+
+package redis
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	defaultTxRetries = 10
+	txRetryMax       = 10 * time.Millisecond
+)
+
+// ErrTxConflict 被监视的KEY在重试次数内始终被其他客户端修改
+var ErrTxConflict = errors.New("redis: transaction conflict")
+
+// Pipeline 命令管道，非并发安全，添加命令时使用创建管道的 ctx
+type Pipeline struct {
+	c       *Client
+	ctx     context.Context
+	pipe    redis.Pipeliner
+	err     error
+	touched []string
+}
+
+// Pipeline 创建普通管道
+func (c *Client) Pipeline(ctx context.Context) *Pipeline {
+	return &Pipeline{c: c, ctx: ctx, pipe: c.rdb.Pipeline()}
+}
+
+// TxPipeline 创建事务管道，Exec 时以 MULTI/EXEC 执行
+func (c *Client) TxPipeline(ctx context.Context) *Pipeline {
+	return &Pipeline{c: c, ctx: ctx, pipe: c.rdb.TxPipeline()}
+}
+
+// Pipelined 在 fn 中添加命令后执行普通管道
+func (c *Client) Pipelined(ctx context.Context, fn func(p *Pipeline) error) error {
+	return c.exec(ctx, c.Pipeline(ctx), fn)
+}
+
+// TxPipelined 在 fn 中添加命令后以事务执行
+func (c *Client) TxPipelined(ctx context.Context, fn func(p *Pipeline) error) error {
+	return c.exec(ctx, c.TxPipeline(ctx), fn)
+}
+
+func (c *Client) exec(ctx context.Context, p *Pipeline, fn func(p *Pipeline) error) error {
+	if err := fn(p); err != nil {
+		p.Discard()
+		return err
+	}
+	return p.Exec(ctx)
+}
+
+// Exec 执行已添加的命令，单个读命令KEY不存在不视为错误，各命令的错误通过其结果获取
+// 添加命令时编码失败则放弃执行并返回该错误
+func (p *Pipeline) Exec(ctx context.Context) error {
+	if p.err != nil {
+		p.Discard()
+		return p.err
+	}
+	cmds, err := p.pipe.Exec(ctx)
+	if err == Nil {
+		// go-redis 只返回第一个失败命令的错误，KEY不存在之后的命令错误需要逐个检查
+		err = nil
+		for _, cmd := range cmds {
+			if cmdErr := cmd.Err(); cmdErr != nil && cmdErr != Nil {
+				err = cmdErr
+				break
+			}
+		}
+	}
+	if 0 < len(p.touched) {
+		// 部分命令可能已执行，出错时同样淘汰本地缓存
+		p.c.changed(ctx, nil, p.touched...)
+		p.touched = nil
+	}
+	return err
+}
+
+// Discard 放弃已添加的命令
+func (p *Pipeline) Discard() {
+	p.pipe.Discard()
+	p.touched = nil
+	p.err = nil
+}
+
+// Len 已添加的命令数量
+func (p *Pipeline) Len() int {
+	return p.pipe.Len()
+}
+
+// encode 编码失败时记录第一个错误
+func (p *Pipeline) encode(val interface{}) string {
+	str, err := p.c.encode(val)
+	if err != nil && nil == p.err {
+		p.err = err
+	}
+	return str
+}
+
+// key 返回完整KEY，并记录为已修改
+func (p *Pipeline) key(key string) string {
+	fullKey := p.c.Key(key)
+	p.touched = append(p.touched, fullKey)
+	return fullKey
+}
+
+// Set SET key value 设置指定 key 的值，timeout 单位为秒
+func (p *Pipeline) Set(key string, val interface{}, timeout int64) *redis.StatusCmd {
+	return p.pipe.Set(p.ctx, p.key(key), p.encode(val), time.Duration(timeout)*time.Second)
+}
+
+// Delete DEL key 删除KEY
+func (p *Pipeline) Delete(key string) *redis.IntCmd {
+	return p.pipe.Del(p.ctx, p.key(key))
+}
+
+// ExpireIn EXPIRE key 设置KEY在指定时长后过期
+func (p *Pipeline) ExpireIn(key string, d time.Duration) *redis.BoolCmd {
+	return p.pipe.Expire(p.ctx, p.c.Key(key), d)
+}
+
+// ExpireAt EXPIREAT key 设置KEY在指定时间过期
+func (p *Pipeline) ExpireAt(key string, t time.Time) *redis.BoolCmd {
+	return p.pipe.ExpireAt(p.ctx, p.c.Key(key), t)
+}
+
+// IncrBy INCRBY key increment 将 key 所储存的值加上给定的增量值
+func (p *Pipeline) IncrBy(key string, val int64) *redis.IntCmd {
+	return p.pipe.IncrBy(p.ctx, p.key(key), val)
+}
+
+// HSet HSET key field value 将哈希表 key 中的字段 field 的值设为 value
+func (p *Pipeline) HSet(key, field string, val interface{}) *redis.IntCmd {
+	return p.pipe.HSet(p.ctx, p.key(key), field, p.encode(val))
+}
+
+// HMSet HMSET key field1 value1 [field2 value2 ] 同时将多个 field-value 对设置到哈希表 key 中
+func (p *Pipeline) HMSet(key string, fields map[string]interface{}) *redis.BoolCmd {
+	args := make([]interface{}, 0, 2*len(fields))
+	for k, v := range fields {
+		args = append(args, k, p.encode(v))
+	}
+	return p.pipe.HMSet(p.ctx, p.key(key), args...)
+}
+
+// HDel HDEL key field1 [field2] 删除一个或多个哈希表字段
+func (p *Pipeline) HDel(key string, fields ...string) *redis.IntCmd {
+	return p.pipe.HDel(p.ctx, p.key(key), fields...)
+}
+
+// HIncrBy HINCRBY key field increment 为哈希表 key 中的指定字段的整数值加上增量
+func (p *Pipeline) HIncrBy(key, field string, incr int64) *redis.IntCmd {
+	return p.pipe.HIncrBy(p.ctx, p.key(key), field, incr)
+}
+
+// SAdd SADD key member1 [member2] 向集合添加一个或多个成员
+func (p *Pipeline) SAdd(key string, members ...interface{}) *redis.IntCmd {
+	return p.pipe.SAdd(p.ctx, p.key(key), p.encodeAll(members)...)
+}
+
+// SRem SREM key member1 [member2] 移除集合中一个或多个成员
+func (p *Pipeline) SRem(key string, members ...interface{}) *redis.IntCmd {
+	return p.pipe.SRem(p.ctx, p.key(key), p.encodeAll(members)...)
+}
+
+// ZAdd ZADD 向有序集合添加一个或多个成员，或者更新已存在成员的分数
+func (p *Pipeline) ZAdd(key string, pairs map[interface{}]float64) *redis.IntCmd {
+	args := make([]*redis.Z, 0, len(pairs))
+	for k, v := range pairs {
+		args = append(args, &redis.Z{Score: v, Member: p.encode(k)})
+	}
+	return p.pipe.ZAdd(p.ctx, p.key(key), args...)
+}
+
+// ZRem ZREM 移除有序集合中的一个或多个成员
+func (p *Pipeline) ZRem(key string, members ...interface{}) *redis.IntCmd {
+	return p.pipe.ZRem(p.ctx, p.key(key), p.encodeAll(members)...)
+}
+
+// LPush LPUSH key value1 [value2] 将一个或多个值插入到列表头部
+func (p *Pipeline) LPush(key string, vals ...interface{}) *redis.IntCmd {
+	return p.pipe.LPush(p.ctx, p.key(key), p.encodeAll(vals)...)
+}
+
+// RPush RPUSH key value1 [value2] 在列表中添加一个或多个值到列表尾部
+func (p *Pipeline) RPush(key string, vals ...interface{}) *redis.IntCmd {
+	return p.pipe.RPush(p.ctx, p.key(key), p.encodeAll(vals)...)
+}
+
+func (p *Pipeline) encodeAll(vals []interface{}) []interface{} {
+	arr := make([]interface{}, len(vals))
+	for i, v := range vals {
+		arr[i] = p.encode(v)
+	}
+	return arr
+}
+
+// Result 管道中读命令的结果，Exec 之后调用 Val 取值
+type Result[T any] struct {
+	val func() (T, error)
+}
+
+// Val 返回解码后的结果，Exec 之前调用返回空值
+func (r *Result[T]) Val() (T, error) {
+	return r.val()
+}
+
+// PipeGet GET key 获取指定 key 的值
+func PipeGet[T any](p *Pipeline, key string) *Result[T] {
+	cmd := p.pipe.Get(p.ctx, p.c.Key(key))
+	return &Result[T]{val: func() (T, error) {
+		if err := cmd.Err(); err != nil {
+			var zero T
+			return zero, err
+		}
+		return decodeValWith[T](p.c.codec, cmd.Val())
+	}}
+}
+
+// PipeHGet HGET key field 获取存储在哈希表中指定字段的值
+func PipeHGet[T any](p *Pipeline, key, field string) *Result[T] {
+	cmd := p.pipe.HGet(p.ctx, p.c.Key(key), field)
+	return &Result[T]{val: func() (T, error) {
+		if err := cmd.Err(); err != nil {
+			var zero T
+			return zero, err
+		}
+		return decodeValWith[T](p.c.codec, cmd.Val())
+	}}
+}
+
+// PipeHGetAll HGETALL key 获取在哈希表中指定 key 的所有字段和值
+func PipeHGetAll[T any](p *Pipeline, key string) *Result[map[string]T] {
+	cmd := p.pipe.HGetAll(p.ctx, p.c.Key(key))
+	return &Result[map[string]T]{val: func() (map[string]T, error) {
+		if err := cmd.Err(); err != nil {
+			return nil, err
+		}
+		result := make(map[string]T, len(cmd.Val()))
+		for k, v := range cmd.Val() {
+			val, err := decodeValWith[T](p.c.codec, v)
+			if err != nil {
+				return nil, err
+			}
+			result[k] = val
+		}
+		return result, nil
+	}}
+}
+
+// PipeLRange LRANGE key start stop 获取列表指定范围内的元素
+func PipeLRange[T any](p *Pipeline, key string, start, stop int64) *Result[[]T] {
+	cmd := p.pipe.LRange(p.ctx, p.c.Key(key), start, stop)
+	return pipeArr[T](p, cmd)
+}
+
+// PipeSMembers SMEMBERS key 返回集合中的所有成员
+func PipeSMembers[T any](p *Pipeline, key string) *Result[[]T] {
+	cmd := p.pipe.SMembers(p.ctx, p.c.Key(key))
+	return pipeArr[T](p, cmd)
+}
+
+func pipeArr[T any](p *Pipeline, cmd *redis.StringSliceCmd) *Result[[]T] {
+	return &Result[[]T]{val: func() ([]T, error) {
+		if err := cmd.Err(); err != nil {
+			return nil, err
+		}
+		return decodeArrWith[T](p.c.codec, cmd.Val())
+	}}
+}
+
+// Tx WATCH 事务，在回调中读取被监视的KEY并通过 Exec 提交
+type Tx struct {
+	c   *Client
+	tx  *redis.Tx
+	ctx context.Context
+}
+
+// TxGet 在事务中读取KEY
+func TxGet[T any](tx *Tx, key string) (v T, err error) {
+	data, err := tx.tx.Get(tx.ctx, tx.c.Key(key)).Result()
+	if err != nil {
+		return
+	}
+	return decodeValWith[T](tx.c.codec, data)
+}
+
+// TxHGet 在事务中读取哈希字段
+func TxHGet[T any](tx *Tx, key, field string) (v T, err error) {
+	data, err := tx.tx.HGet(tx.ctx, tx.c.Key(key), field).Result()
+	if err != nil {
+		return
+	}
+	return decodeValWith[T](tx.c.codec, data)
+}
+
+// Exec 以 MULTI/EXEC 提交 fn 中添加的命令，被监视的KEY已被修改时返回冲突，由 Transaction 重试
+func (tx *Tx) Exec(fn func(p *Pipeline) error) error {
+	p := &Pipeline{c: tx.c, ctx: tx.ctx, pipe: tx.tx.TxPipeline()}
+	return tx.c.exec(tx.ctx, p, fn)
+}
+
+// Transaction 监视 keys 并执行 fn，提交时KEY已被修改则重试，maxRetries<=0 时最多重试10次
+// 重试耗尽返回 ErrTxConflict，fn 返回的其他错误直接返回
+func (c *Client) Transaction(ctx context.Context, keys []string, fn func(tx *Tx) error, maxRetries int) error {
+	if maxRetries <= 0 {
+		maxRetries = defaultTxRetries
+	}
+	fullKeys := c.keys(keys)
+	if err := c.sameSlot(fullKeys...); err != nil {
+		return err
+	}
+
+	for i := 0; i <= maxRetries; i++ {
+		err := c.rdb.Watch(ctx, func(tx *redis.Tx) error {
+			return fn(&Tx{c: c, tx: tx, ctx: ctx})
+		}, fullKeys...)
+		if !errors.Is(err, redis.TxFailedErr) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(rand.Int63n(int64(txRetryMax)))):
+		}
+	}
+	return ErrTxConflict
+}
+
+// 以下包级函数委托给默认实例
+
+// Pipelined 在 fn 中添加命令后执行普通管道
+func Pipelined(fn func(p *Pipeline) error) error {
+	return defaultClient.Pipelined(ctx, fn)
+}
+
+// TxPipelined 在 fn 中添加命令后以事务执行
+func TxPipelined(fn func(p *Pipeline) error) error {
+	return defaultClient.TxPipelined(ctx, fn)
+}
+
+// Transaction 监视 keys 并执行 fn，提交时KEY已被修改则重试，maxRetries<=0 时最多重试10次
+func Transaction(keys []string, fn func(tx *Tx) error, maxRetries int) error {
+	return defaultClient.Transaction(ctx, keys, fn, maxRetries)
+}
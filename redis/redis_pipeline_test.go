@@ -0,0 +1,132 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPipelined(t *testing.T) {
+	Delete("test_pipe_user")
+	Delete("test_pipe_set")
+
+	var user *Result[args]
+	var members *Result[[]string]
+	err := Pipelined(func(p *Pipeline) error {
+		p.HSet("test_pipe_user", "u1", &args{Name: "name_1", Age: 1})
+		p.ExpireIn("test_pipe_user", time.Minute)
+		p.SAdd("test_pipe_set", "a", "b")
+		user = PipeHGet[args](p, "test_pipe_user", "u1")
+		members = PipeSMembers[string](p, "test_pipe_set")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u, err := user.Val(); err != nil || u.Name != "name_1" {
+		t.Errorf("PipeHGet = %v, %v", u, err)
+	}
+	if m, err := members.Val(); err != nil || len(m) != 2 {
+		t.Errorf("PipeSMembers = %v, %v", m, err)
+	}
+
+	// 读取不存在的KEY不影响整个管道
+	var missing *Result[string]
+	err = TxPipelined(func(p *Pipeline) error {
+		missing = PipeGet[string](p, "test_pipe_missing")
+		p.Set("test_pipe_str", "v", 60)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := missing.Val(); err != Nil {
+		t.Errorf("expect Nil, got %v", err)
+	}
+
+	// KEY不存在之后的命令错误仍需返回
+	err = Pipelined(func(p *Pipeline) error {
+		PipeGet[string](p, "test_pipe_missing")
+		p.IncrBy("test_pipe_set", 1)
+		return nil
+	})
+	if err == nil || err == Nil {
+		t.Errorf("expect WRONGTYPE error, got %v", err)
+	}
+	Delete("test_pipe_set")
+	Delete("test_pipe_str")
+}
+
+func TestTransaction(t *testing.T) {
+	if err := Set("test_tx_counter", 0, 60); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := Transaction([]string{"test_tx_counter"}, func(tx *Tx) error {
+				n, err := TxGet[int](tx, "test_tx_counter")
+				if err != nil {
+					return err
+				}
+				return tx.Exec(func(p *Pipeline) error {
+					p.Set("test_tx_counter", n+1, 60)
+					return nil
+				})
+			}, 100)
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n, err := Get[int]("test_tx_counter"); err != nil || n != 10 {
+		t.Errorf("counter = %d, %v", n, err)
+	}
+
+	stop := errors.New("stop")
+	err := Transaction([]string{"test_tx_counter"}, func(tx *Tx) error {
+		return stop
+	}, 0)
+	if !errors.Is(err, stop) {
+		t.Errorf("expect callback error, got %v", err)
+	}
+}
+
+type pipeCtxKey struct{}
+
+// TestPipelineInstanceClient 实例客户端的管道使用调用方的 ctx，不依赖包级 ctx
+func TestPipelineInstanceClient(t *testing.T) {
+	c, err := NewClient(&Config{Prefix: "instance", Host: testAddr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	saved := ctx
+	ctx = nil
+	defer func() { ctx = saved }()
+
+	caller := context.WithValue(context.Background(), pipeCtxKey{}, "caller")
+	var got *Result[string]
+	err = c.Pipelined(caller, func(p *Pipeline) error {
+		if p.ctx.Value(pipeCtxKey{}) != "caller" {
+			t.Error("pipeline does not use the caller context")
+		}
+		p.Set("test_pipe_ctx", "v", 60)
+		got = PipeGet[string](p, "test_pipe_ctx")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := got.Val(); err != nil || v != "v" {
+		t.Errorf("PipeGet = %v, %v", v, err)
+	}
+}
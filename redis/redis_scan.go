@@ -0,0 +1,315 @@
+// KEY遍历与批量删除
+// 基于 SCAN/HSCAN/SSCAN/ZSCAN 的迭代器，只遍历当前前缀下的KEY，不会像 KEYS 一样阻塞实例
+// SCAN 系列命令可能重复返回同一元素，调用方需自行去重
+// 示例
+//
+//	for key, err := range redis.Scan("user:*", 0) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(key)
+//	}
+//
+//	n, err := redis.DeleteByPattern("session:*")
+
+package redis
+
+import (
+	"context"
+	"iter"
+	"strings"
+	"sync"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// defaultScanCount SCAN 每次返回数量的建议值
+	defaultScanCount = 100
+	// unlinkBatch 每次 UNLINK 的KEY数量
+	unlinkBatch = 500
+)
+
+// HashField 哈希表的字段与值
+type HashField[T any] struct {
+	Field string
+	Val   T
+}
+
+// ZMember 有序集合的成员与分数
+type ZMember[T any] struct {
+	Member T
+	Score  float64
+}
+
+// escapeGlob 转义 SCAN MATCH 的通配符
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// scanNodes 返回需要遍历的节点，集群模式下为前缀所在的主节点或全部主节点
+func (c *Client) scanNodes(ctx context.Context) ([]redis.Cmdable, error) {
+	cluster, ok := c.rdb.(*redis.ClusterClient)
+	if !ok {
+		return []redis.Cmdable{c.rdb}, nil
+	}
+	if c.hashTag {
+		node, err := cluster.MasterForKey(ctx, c.Key(""))
+		if err != nil {
+			return nil, err
+		}
+		return []redis.Cmdable{node}, nil
+	}
+
+	var mu sync.Mutex
+	var nodes []redis.Cmdable
+	err := cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+		mu.Lock()
+		nodes = append(nodes, node)
+		mu.Unlock()
+		return nil
+	})
+	return nodes, err
+}
+
+// scanFullKeys 遍历前缀下匹配 match 的完整KEY
+func (c *Client) scanFullKeys(ctx context.Context, match string, count int64) iter.Seq2[string, error] {
+	if "" == match {
+		match = "*"
+	}
+	pattern := escapeGlob(c.Key("")) + match
+	count = scanCount(count)
+	return func(yield func(string, error) bool) {
+		nodes, err := c.scanNodes(ctx)
+		if err != nil {
+			yield("", err)
+			return
+		}
+		for _, node := range nodes {
+			var cursor uint64
+			for {
+				keys, next, err := node.Scan(ctx, cursor, pattern, count).Result()
+				if err != nil {
+					yield("", err)
+					return
+				}
+				for _, k := range keys {
+					if !yield(k, nil) {
+						return
+					}
+				}
+				if cursor = next; 0 == cursor {
+					break
+				}
+			}
+		}
+	}
+}
+
+// Scan SCAN cursor MATCH pattern 遍历前缀下匹配 match 的KEY，match 为空时遍历全部，返回的KEY不含前缀，count<=0 时为100
+func (c *Client) Scan(ctx context.Context, match string, count int64) iter.Seq2[string, error] {
+	prefix := c.Key("")
+	return func(yield func(string, error) bool) {
+		for k, err := range c.scanFullKeys(ctx, match, count) {
+			if !yield(strings.TrimPrefix(k, prefix), err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// DeleteByPattern 以 UNLINK 分批删除前缀下匹配 pattern 的KEY，返回删除的数量
+func (c *Client) DeleteByPattern(ctx context.Context, pattern string) (int64, error) {
+	return c.unlinkMatched(ctx, pattern, true)
+}
+
+// unlinkMatched 分批删除前缀下匹配 pattern 的KEY，notify 为true时每批删除后淘汰本地缓存
+func (c *Client) unlinkMatched(ctx context.Context, pattern string, notify bool) (int64, error) {
+	var deleted int64
+	batch := make([]string, 0, unlinkBatch)
+	flush := func() error {
+		if 0 == len(batch) {
+			return nil
+		}
+		n, err := c.unlink(ctx, batch)
+		deleted += n
+		if notify {
+			err = c.changed(ctx, err, batch...)
+		}
+		batch = batch[:0]
+		return err
+	}
+
+	for k, err := range c.scanFullKeys(ctx, pattern, unlinkBatch) {
+		if err != nil {
+			return deleted, err
+		}
+		if batch = append(batch, k); unlinkBatch <= len(batch) {
+			if err = flush(); err != nil {
+				return deleted, err
+			}
+		}
+	}
+	return deleted, flush()
+}
+
+// unlink UNLINK 完整KEY，集群模式下按哈希槽分组执行
+func (c *Client) unlink(ctx context.Context, fullKeys []string) (int64, error) {
+	if !c.cluster || nil == c.sameSlot(fullKeys...) {
+		return c.rdb.Unlink(ctx, fullKeys...).Result()
+	}
+
+	groups := make(map[int][]string)
+	for _, k := range fullKeys {
+		slot := hashSlot(k)
+		groups[slot] = append(groups[slot], k)
+	}
+	cmds := make([]*redis.IntCmd, 0, len(groups))
+	_, err := c.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, group := range groups {
+			cmds = append(cmds, pipe.Unlink(ctx, group...))
+		}
+		return nil
+	})
+	var n int64
+	for _, cmd := range cmds {
+		n += cmd.Val()
+	}
+	return n, err
+}
+
+// FlushAll FLUSHALL 清空整个实例的所有数据，共享实例上会删除其他服务的数据，谨慎使用
+func (c *Client) FlushAll(ctx context.Context) error {
+	return c.changed(ctx, c.rdb.FlushAll(ctx).Err())
+}
+
+// HScan HSCAN key cursor MATCH pattern 遍历哈希表中匹配 match 的字段，match 为空时遍历全部
+func (t Typed[T]) HScan(ctx context.Context, key, match string, count int64) iter.Seq2[HashField[T], error] {
+	return func(yield func(HashField[T], error) bool) {
+		for pair, err := range t.scanPairs(ctx, key, match, count, t.c.rdb.HScan) {
+			if err != nil {
+				yield(HashField[T]{}, err)
+				return
+			}
+			val, err := t.decode(pair[1])
+			if !yield(HashField[T]{Field: pair[0], Val: val}, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// SScan SSCAN key cursor MATCH pattern 遍历集合中匹配 match 的成员，match 为空时遍历全部
+func (t Typed[T]) SScan(ctx context.Context, key, match string, count int64) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		fullKey := t.c.Key(key)
+		for cursor := uint64(0); ; {
+			members, next, err := t.c.rdb.SScan(ctx, fullKey, cursor, match, scanCount(count)).Result()
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			for _, m := range members {
+				val, err := t.decode(m)
+				if !yield(val, err) || err != nil {
+					return
+				}
+			}
+			if cursor = next; 0 == cursor {
+				return
+			}
+		}
+	}
+}
+
+// ZScan ZSCAN key cursor MATCH pattern 遍历有序集合中匹配 match 的成员及分数，match 为空时遍历全部
+func (t Typed[T]) ZScan(ctx context.Context, key, match string, count int64) iter.Seq2[ZMember[T], error] {
+	return func(yield func(ZMember[T], error) bool) {
+		for pair, err := range t.scanPairs(ctx, key, match, count, t.c.rdb.ZScan) {
+			if err != nil {
+				yield(ZMember[T]{}, err)
+				return
+			}
+			member, err := t.decode(pair[0])
+			if err != nil {
+				yield(ZMember[T]{}, err)
+				return
+			}
+			score, err := decodeVal[float64](pair[1])
+			if !yield(ZMember[T]{Member: member, Score: score}, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// scanPairs 遍历 HSCAN/ZSCAN 返回的 [field value] 或 [member score] 对
+func (t Typed[T]) scanPairs(ctx context.Context, key, match string, count int64,
+	scan func(ctx context.Context, key string, cursor uint64, match string, count int64) *redis.ScanCmd) iter.Seq2[[2]string, error] {
+	return func(yield func([2]string, error) bool) {
+		fullKey := t.c.Key(key)
+		for cursor := uint64(0); ; {
+			vals, next, err := scan(ctx, fullKey, cursor, match, scanCount(count)).Result()
+			if err != nil {
+				yield([2]string{}, err)
+				return
+			}
+			for i := 0; i+1 < len(vals); i += 2 {
+				if !yield([2]string{vals[i], vals[i+1]}, nil) {
+					return
+				}
+			}
+			if cursor = next; 0 == cursor {
+				return
+			}
+		}
+	}
+}
+
+func scanCount(count int64) int64 {
+	if count <= 0 {
+		return defaultScanCount
+	}
+	return count
+}
+
+// 以下包级函数委托给默认实例
+
+// Scan 遍历前缀下匹配 match 的KEY，match 为空时遍历全部，返回的KEY不含前缀，count<=0 时为100
+func Scan(match string, count int64) iter.Seq2[string, error] {
+	return defaultClient.Scan(ctx, match, count)
+}
+
+// DeleteByPattern 以 UNLINK 分批删除前缀下匹配 pattern 的KEY，返回删除的数量
+func DeleteByPattern(pattern string) (int64, error) {
+	return defaultClient.DeleteByPattern(ctx, pattern)
+}
+
+// FlushAll 清空整个实例的所有数据，共享实例上会删除其他服务的数据，谨慎使用
+func FlushAll() error {
+	return defaultClient.FlushAll(ctx)
+}
+
+// HScan 遍历哈希表中匹配 match 的字段，match 为空时遍历全部
+func HScan[T any](key, match string, count int64) iter.Seq2[HashField[T], error] {
+	return As[T](defaultClient).HScan(ctx, key, match, count)
+}
+
+// SScan 遍历集合中匹配 match 的成员，match 为空时遍历全部
+func SScan[T any](key, match string, count int64) iter.Seq2[T, error] {
+	return As[T](defaultClient).SScan(ctx, key, match, count)
+}
+
+// ZScan 遍历有序集合中匹配 match 的成员及分数，match 为空时遍历全部
+func ZScan[T any](key, match string, count int64) iter.Seq2[ZMember[T], error] {
+	return As[T](defaultClient).ZScan(ctx, key, match, count)
+}
@@ -0,0 +1,76 @@
+package redis
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestEscapeGlob(t *testing.T) {
+	cases := map[string]string{
+		"app:":    "app:",
+		"{app}:":  "{app}:",
+		"a*b?[c]": `a\*b\?\[c\]`,
+		`a\b`:     `a\\b`,
+	}
+	for in, want := range cases {
+		if got := escapeGlob(in); got != want {
+			t.Errorf("escapeGlob(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestScanAndDeleteByPattern(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		if err := Set(fmt.Sprintf("test_scan:%d", i), i, 60); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for key, err := range Scan("test_scan:*", 5) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen[key] = true
+	}
+	if len(seen) != 20 || !seen["test_scan:0"] {
+		t.Errorf("Scan returned %d keys: %v", len(seen), seen)
+	}
+
+	n, err := DeleteByPattern("test_scan:*")
+	if err != nil || n != 20 {
+		t.Errorf("DeleteByPattern = %d, %v", n, err)
+	}
+	if IsExist("test_scan:0") {
+		t.Error("key not deleted")
+	}
+}
+
+func TestHScan(t *testing.T) {
+	Delete("test_hscan")
+	HMSet("test_hscan", map[string]interface{}{"a": 1, "b": 2, "c": 3})
+
+	sum := 0
+	for f, err := range HScan[int]("test_hscan", "", 0) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		sum += f.Val
+	}
+	if sum != 6 {
+		t.Errorf("HScan sum = %d", sum)
+	}
+
+	Delete("test_zscan")
+	ZAdd("test_zscan", map[interface{}]float64{"x": 1.5, "y": 2.5})
+	var score float64
+	for m, err := range ZScan[string]("test_zscan", "", 0) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		score += m.Score
+	}
+	if score != 4 {
+		t.Errorf("ZScan score sum = %v", score)
+	}
+}
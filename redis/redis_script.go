@@ -0,0 +1,27 @@
+package redis
+
+import (
+	"context"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Script Lua脚本，执行时 KEYS 自动添加前缀
+type Script struct {
+	script *redis.Script
+}
+
+// NewScript 创建Lua脚本
+func NewScript(src string) *Script {
+	return &Script{script: redis.NewScript(src)}
+}
+
+// Run EVALSHA 执行脚本，脚本未缓存时自动回退为 EVAL
+func (s *Script) Run(keys []string, args ...interface{}) *redis.Cmd {
+	return s.RunOn(ctx, defaultClient, keys, args...)
+}
+
+// RunOn 在指定实例上执行脚本
+func (s *Script) RunOn(ctx context.Context, c *Client, keys []string, args ...interface{}) *redis.Cmd {
+	return s.script.Run(ctx, c.rdb, c.keys(keys), args...)
+}
@@ -1,132 +1,222 @@
 package redis
 
+import "context"
+
 // SAdd 1	SADD key member1 [member2] 向集合添加一个或多个成员
-func SAdd(key string, members ...interface{}) (int64, error) {
-	arr := make([]interface{}, len(members))
-	for i, v := range members {
-		val, err := encode(v)
-		if err != nil {
-			return 0, err
-		}
-		arr[i] = val
+func (c *Client) SAdd(ctx context.Context, key string, members ...interface{}) (int64, error) {
+	arr, err := c.encodeAll(members)
+	if err != nil {
+		return 0, err
 	}
-
-	cmd := client.SAdd(ctx, associate(key), arr...)
+	cmd := c.rdb.SAdd(ctx, c.Key(key), arr...)
 	return cmd.Val(), cmd.Err()
 }
 
 // SCard 2	SCARD key 获取集合的成员数
-func SCard(key string) int64 {
-	return client.SCard(ctx, associate(key)).Val()
+func (c *Client) SCard(ctx context.Context, key string) int64 {
+	return c.rdb.SCard(ctx, c.Key(key)).Val()
 }
 
 // SDiff 3	SDIFF key1 [key2] 返回第一个集合与其他集合之间的差异。
-func SDiff[T any](keys ...string) (res []T, err error) {
-	cmd := client.SDiff(ctx, keys...)
-	if cmd.Err() != nil {
+func (t Typed[T]) SDiff(ctx context.Context, keys ...string) (res []T, err error) {
+	fullKeys := t.c.keys(keys)
+	if err = t.c.sameSlot(fullKeys...); err != nil {
 		return nil, err
 	}
-	return decodeArr[T](cmd.Val())
+	cmd := t.c.rdb.SDiff(ctx, fullKeys...)
+	if cmd.Err() != nil {
+		return nil, cmd.Err()
+	}
+	return t.decodeArr(cmd.Val())
 }
 
 // SDiffStore 4	SDIFFSTORE destination key1 [key2] 返回给定所有集合的差集并存储在 destination 中
-func SDiffStore(destination string, keys ...string) (int64, error) {
-	cmd := client.SDiffStore(ctx, associate(destination), keys...)
+func (c *Client) SDiffStore(ctx context.Context, destination string, keys ...string) (int64, error) {
+	dst, src, err := c.storeKeys(destination, keys)
+	if err != nil {
+		return 0, err
+	}
+	cmd := c.rdb.SDiffStore(ctx, dst, src...)
 	return cmd.Val(), cmd.Err()
 }
 
 // SInter 5	SINTER key1 [key2] 返回给定所有集合的交集
-func SInter[T any](keys ...string) (res []T, err error) {
-	cmd := client.SInter(ctx, keys...)
-	if cmd.Err() != nil {
+func (t Typed[T]) SInter(ctx context.Context, keys ...string) (res []T, err error) {
+	fullKeys := t.c.keys(keys)
+	if err = t.c.sameSlot(fullKeys...); err != nil {
 		return nil, err
 	}
-	return decodeArr[T](cmd.Val())
+	cmd := t.c.rdb.SInter(ctx, fullKeys...)
+	if cmd.Err() != nil {
+		return nil, cmd.Err()
+	}
+	return t.decodeArr(cmd.Val())
 }
 
 // SInterStore 6	SINTERSTORE destination key1 [key2] 返回给定所有集合的交集并存储在 destination 中
-func SInterStore(destination string, keys ...string) (int64, error) {
-	cmd := client.SInterStore(ctx, associate(destination), keys...)
+func (c *Client) SInterStore(ctx context.Context, destination string, keys ...string) (int64, error) {
+	dst, src, err := c.storeKeys(destination, keys)
+	if err != nil {
+		return 0, err
+	}
+	cmd := c.rdb.SInterStore(ctx, dst, src...)
 	return cmd.Val(), cmd.Err()
 }
 
 // SIsMember 7	SISMEMBER key member 判断 member 元素是否是集合 key 的成员
-func SIsMember(key string, member interface{}) (bool, error) {
-	val, err := encode(member)
+func (c *Client) SIsMember(ctx context.Context, key string, member interface{}) (bool, error) {
+	val, err := c.encode(member)
 	if err != nil {
 		return false, err
 	}
-	cmd := client.SIsMember(ctx, associate(key), val)
+	cmd := c.rdb.SIsMember(ctx, c.Key(key), val)
 	return cmd.Val(), cmd.Err()
 }
 
 // SMembers 8	SMEMBERS key 返回集合中的所有成员
-func SMembers[T any](key string) (res []T, err error) {
-	cmd := client.SMembers(ctx, associate(key))
+func (t Typed[T]) SMembers(ctx context.Context, key string) (res []T, err error) {
+	cmd := t.c.rdb.SMembers(ctx, t.c.Key(key))
 	if cmd.Err() != nil {
-		return nil, err
+		return nil, cmd.Err()
 	}
-	return decodeArr[T](cmd.Val())
+	return t.decodeArr(cmd.Val())
 }
 
 // SMove 9	SMOVE source destination member 将 member 元素从 source 集合移动到 destination 集合
-func SMove(source, destination string, member interface{}) (bool, error) {
-	val, err := encode(member)
+func (c *Client) SMove(ctx context.Context, source, destination string, member interface{}) (bool, error) {
+	val, err := c.encode(member)
 	if err != nil {
 		return false, err
 	}
-
-	cmd := client.SMove(ctx, associate(source), associate(destination), val)
+	src, dst := c.Key(source), c.Key(destination)
+	if err = c.sameSlot(src, dst); err != nil {
+		return false, err
+	}
+	cmd := c.rdb.SMove(ctx, src, dst, val)
 	return cmd.Val(), cmd.Err()
 }
 
 // SPop 10	SPOP key 移除并返回集合中的一个随机元素
-func SPop[T any](key string) (t T, err error) {
-	cmd := client.SPop(ctx, associate(key))
+func (t Typed[T]) SPop(ctx context.Context, key string) (v T, err error) {
+	cmd := t.c.rdb.SPop(ctx, t.c.Key(key))
 	if cmd.Err() != nil {
 		err = cmd.Err()
 		return
 	}
 
-	return decodeVal[T](cmd.Val())
+	return t.decode(cmd.Val())
 }
 
 // SRandMember 11	SRANDMEMBER key [count] 返回集合中一个或多个随机数
-func SRandMember[T any](key string, count int) (res []T, err error) {
-	cmd := client.SRandMemberN(ctx, associate(key), int64(count))
+func (t Typed[T]) SRandMember(ctx context.Context, key string, count int) (res []T, err error) {
+	cmd := t.c.rdb.SRandMemberN(ctx, t.c.Key(key), int64(count))
 	if cmd.Err() != nil {
-		return nil, err
+		return nil, cmd.Err()
 	}
-	return decodeArr[T](cmd.Val())
-
+	return t.decodeArr(cmd.Val())
 }
 
 // SRem 12	SREM key member1 [member2] 移除集合中一个或多个成员
-func SRem(key string, members ...interface{}) (int64, error) {
-	arr := make([]interface{}, len(members))
-	for i, v := range members {
-		val, err := encode(v)
-		if err != nil {
-			return 0, err
-		}
-		arr[i] = val
+func (c *Client) SRem(ctx context.Context, key string, members ...interface{}) (int64, error) {
+	arr, err := c.encodeAll(members)
+	if err != nil {
+		return 0, err
 	}
-
-	cmd := client.SRem(ctx, associate(key), arr...)
+	cmd := c.rdb.SRem(ctx, c.Key(key), arr...)
 	return cmd.Val(), cmd.Err()
 }
 
 // SUnion 13	SUNION key1 [key2] 返回所有给定集合的并集
-func SUnion[T any](keys ...string) (res []T, err error) {
-	cmd := client.SUnion(ctx, keys...)
-	if cmd.Err() != nil {
+func (t Typed[T]) SUnion(ctx context.Context, keys ...string) (res []T, err error) {
+	fullKeys := t.c.keys(keys)
+	if err = t.c.sameSlot(fullKeys...); err != nil {
 		return nil, err
 	}
-	return decodeArr[T](cmd.Val())
+	cmd := t.c.rdb.SUnion(ctx, fullKeys...)
+	if cmd.Err() != nil {
+		return nil, cmd.Err()
+	}
+	return t.decodeArr(cmd.Val())
 }
 
 // SUnionStore 14	SUNIONSTORE destination key1 [key2] 所有给定集合的并集存储在 destination 集合中
-func SUnionStore(destination string, keys ...string) (int64, error) {
-	cmd := client.SUnionStore(ctx, associate(destination), keys...)
+func (c *Client) SUnionStore(ctx context.Context, destination string, keys ...string) (int64, error) {
+	dst, src, err := c.storeKeys(destination, keys)
+	if err != nil {
+		return 0, err
+	}
+	cmd := c.rdb.SUnionStore(ctx, dst, src...)
 	return cmd.Val(), cmd.Err()
 }
+
+// 以下包级函数委托给默认实例
+
+// SAdd 1	SADD key member1 [member2] 向集合添加一个或多个成员
+func SAdd(key string, members ...interface{}) (int64, error) {
+	return defaultClient.SAdd(ctx, key, members...)
+}
+
+// SCard 2	SCARD key 获取集合的成员数
+func SCard(key string) int64 {
+	return defaultClient.SCard(ctx, key)
+}
+
+// SDiff 3	SDIFF key1 [key2] 返回第一个集合与其他集合之间的差异。
+func SDiff[T any](keys ...string) (res []T, err error) {
+	return As[T](defaultClient).SDiff(ctx, keys...)
+}
+
+// SDiffStore 4	SDIFFSTORE destination key1 [key2] 返回给定所有集合的差集并存储在 destination 中
+func SDiffStore(destination string, keys ...string) (int64, error) {
+	return defaultClient.SDiffStore(ctx, destination, keys...)
+}
+
+// SInter 5	SINTER key1 [key2] 返回给定所有集合的交集
+func SInter[T any](keys ...string) (res []T, err error) {
+	return As[T](defaultClient).SInter(ctx, keys...)
+}
+
+// SInterStore 6	SINTERSTORE destination key1 [key2] 返回给定所有集合的交集并存储在 destination 中
+func SInterStore(destination string, keys ...string) (int64, error) {
+	return defaultClient.SInterStore(ctx, destination, keys...)
+}
+
+// SIsMember 7	SISMEMBER key member 判断 member 元素是否是集合 key 的成员
+func SIsMember(key string, member interface{}) (bool, error) {
+	return defaultClient.SIsMember(ctx, key, member)
+}
+
+// SMembers 8	SMEMBERS key 返回集合中的所有成员
+func SMembers[T any](key string) (res []T, err error) {
+	return As[T](defaultClient).SMembers(ctx, key)
+}
+
+// SMove 9	SMOVE source destination member 将 member 元素从 source 集合移动到 destination 集合
+func SMove(source, destination string, member interface{}) (bool, error) {
+	return defaultClient.SMove(ctx, source, destination, member)
+}
+
+// SPop 10	SPOP key 移除并返回集合中的一个随机元素
+func SPop[T any](key string) (t T, err error) {
+	return As[T](defaultClient).SPop(ctx, key)
+}
+
+// SRandMember 11	SRANDMEMBER key [count] 返回集合中一个或多个随机数
+func SRandMember[T any](key string, count int) (res []T, err error) {
+	return As[T](defaultClient).SRandMember(ctx, key, count)
+}
+
+// SRem 12	SREM key member1 [member2] 移除集合中一个或多个成员
+func SRem(key string, members ...interface{}) (int64, error) {
+	return defaultClient.SRem(ctx, key, members...)
+}
+
+// SUnion 13	SUNION key1 [key2] 返回所有给定集合的并集
+func SUnion[T any](keys ...string) (res []T, err error) {
+	return As[T](defaultClient).SUnion(ctx, keys...)
+}
+
+// SUnionStore 14	SUNIONSTORE destination key1 [key2] 所有给定集合的并集存储在 destination 集合中
+func SUnionStore(destination string, keys ...string) (int64, error) {
+	return defaultClient.SUnionStore(ctx, destination, keys...)
+}
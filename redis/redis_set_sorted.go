@@ -1,16 +1,17 @@
 package redis
 
 import (
+	"context"
 	"strconv"
 
 	"github.com/go-redis/redis/v8"
 )
 
 // ZAdd ZADD 向有序集合添加一个或多个成员，或者更新已存在成员的分数
-func ZAdd(key string, pairs map[interface{}]float64) error {
+func (c *Client) ZAdd(ctx context.Context, key string, pairs map[interface{}]float64) error {
 	var args []*redis.Z
 	for k, v := range pairs {
-		val, err := encode(k)
+		val, err := c.encode(k)
 		if err != nil {
 			return err
 		}
@@ -20,12 +21,12 @@ func ZAdd(key string, pairs map[interface{}]float64) error {
 		})
 	}
 
-	return client.ZAdd(ctx, associate(key), args...).Err()
+	return c.rdb.ZAdd(ctx, c.Key(key), args...).Err()
 }
 
-func ZAddByScore(key string, member interface{}, score float64) error {
-
-	val, err := encode(member)
+// ZAddByScore ZADD 向有序集合添加一个成员，或者更新已存在成员的分数
+func (c *Client) ZAddByScore(ctx context.Context, key string, member interface{}, score float64) error {
+	val, err := c.encode(member)
 	if err != nil {
 		return err
 	}
@@ -35,88 +36,87 @@ func ZAddByScore(key string, member interface{}, score float64) error {
 		Member: val,
 	}
 
-	return client.ZAdd(ctx, associate(key), args).Err()
+	return c.rdb.ZAdd(ctx, c.Key(key), args).Err()
 }
 
 // ZCard ZCARD 获取有序集合的成员数
-func ZCard(key string) (int64, error) {
-	cmd := client.ZCard(ctx, associate(key))
+func (c *Client) ZCard(ctx context.Context, key string) (int64, error) {
+	cmd := c.rdb.ZCard(ctx, c.Key(key))
 	return cmd.Val(), cmd.Err()
 }
 
 // ZCount ZCOUNT 计算在有序集合中指定区间分数的成员数
-func ZCount(key, min, max string) (int64, error) {
-	cmd := client.ZCount(ctx, associate(key), min, max)
+func (c *Client) ZCount(ctx context.Context, key, min, max string) (int64, error) {
+	cmd := c.rdb.ZCount(ctx, c.Key(key), min, max)
 	return cmd.Val(), cmd.Err()
 }
 
 // ZScore ZSCORE
-func ZScore(key, member interface{}) (float64, error) {
-	val, err := encode(member)
+func (c *Client) ZScore(ctx context.Context, key, member interface{}) (float64, error) {
+	val, err := c.encode(member)
 	if err != nil {
 		return 0, err
 	}
-	cmd := client.ZScore(ctx, associate(key), val)
+	cmd := c.rdb.ZScore(ctx, c.Key(key), val)
 	return cmd.Val(), cmd.Err()
 }
 
 // ZIncrBy ZINCRBY 有序集合中对指定成员的分数加上增量 increment
-func ZIncrBy(key, member interface{}, increment float64) (float64, error) {
-	val, err := encode(member)
+func (c *Client) ZIncrBy(ctx context.Context, key, member interface{}, increment float64) (float64, error) {
+	val, err := c.encode(member)
 	if err != nil {
 		return 0, err
 	}
-	cmd := client.ZIncrBy(ctx, associate(key), increment, val)
+	cmd := c.rdb.ZIncrBy(ctx, c.Key(key), increment, val)
 	return cmd.Val(), cmd.Err()
 }
 
 // ZInterStore ZINTERSTORE 计算给定的一个或多个有序集的交集并将结果集存储在新的有序集合 destination 中
-func ZInterStore(destination string, keys ...string) (int64, error) {
-	arr := make([]string, len(keys))
-	for i, v := range keys {
-		arr[i] = associate(v)
+func (c *Client) ZInterStore(ctx context.Context, destination string, keys ...string) (int64, error) {
+	dst, src, err := c.storeKeys(destination, keys)
+	if err != nil {
+		return 0, err
 	}
-	cmd := client.ZInterStore(ctx, associate(destination), &redis.ZStore{
-		Keys: arr,
+	cmd := c.rdb.ZInterStore(ctx, dst, &redis.ZStore{
+		Keys: src,
 	})
 	return cmd.Val(), cmd.Err()
 }
 
 // ZLexCount ZLEXCOUNT 在有序集合中计算指定字典区间内成员数量
-func ZLexCount(key, min, max string) (int64, error) {
-	cmd := client.ZLexCount(ctx, associate(key), min, max)
+func (c *Client) ZLexCount(ctx context.Context, key, min, max string) (int64, error) {
+	cmd := c.rdb.ZLexCount(ctx, c.Key(key), min, max)
 	return cmd.Val(), cmd.Err()
 }
 
 // ZRange ZRANGE 通过索引区间返回有序集合指定区间内的成员
-func ZRange[T any](key string, start, stop int64) (res []T, err error) {
-	cmd := client.ZRange(ctx, associate(key), start, stop)
+func (t Typed[T]) ZRange(ctx context.Context, key string, start, stop int64) (res []T, err error) {
+	cmd := t.c.rdb.ZRange(ctx, t.c.Key(key), start, stop)
 	if cmd.Err() != nil {
 		err = cmd.Err()
 		return
 	}
-	return decodeArr[T](cmd.Val())
+	return t.decodeArr(cmd.Val())
 }
 
 // ZRangeWithScores ZRANGE 通过分数返回有序集合指定区间内的成员
-func ZRangeWithScores(key string, start, stop int64) ([]redis.Z, error) {
-	cmd := client.ZRangeWithScores(ctx, associate(key), start, stop)
+func (c *Client) ZRangeWithScores(ctx context.Context, key string, start, stop int64) ([]redis.Z, error) {
+	cmd := c.rdb.ZRangeWithScores(ctx, c.Key(key), start, stop)
 	return cmd.Val(), cmd.Err()
 }
 
 // ZRangeByLex ZRANGEBYLEX key min max 通过字典区间返回有序集合的成员
-func ZRangeByLex(key, min, max string) ([]string, error) {
-	cmd := client.ZRangeByLex(ctx, associate(key), &redis.ZRangeBy{
+func (c *Client) ZRangeByLex(ctx context.Context, key, min, max string) ([]string, error) {
+	cmd := c.rdb.ZRangeByLex(ctx, c.Key(key), &redis.ZRangeBy{
 		Min: min,
 		Max: max,
 	})
 	return cmd.Val(), cmd.Err()
-
 }
 
 // ZRangeByScore ZRANGEBYSCORE 通过分数返回有序集合指定区间内的成员
-func ZRangeByScore[T any](key, min, max string) (res []T, err error) {
-	cmd := client.ZRangeByScore(ctx, associate(key), &redis.ZRangeBy{
+func (t Typed[T]) ZRangeByScore(ctx context.Context, key, min, max string) (res []T, err error) {
+	cmd := t.c.rdb.ZRangeByScore(ctx, t.c.Key(key), &redis.ZRangeBy{
 		Min: min,
 		Max: max,
 	})
@@ -124,12 +124,12 @@ func ZRangeByScore[T any](key, min, max string) (res []T, err error) {
 		err = cmd.Err()
 		return
 	}
-	return decodeArr[T](cmd.Val())
+	return t.decodeArr(cmd.Val())
 }
 
 // ZRangeByScoreWithScores ZRANGEBYSCORE [WITHSCORES] 通过分数返回有序集合指定区间内的成员
-func ZRangeByScoreWithScores(key, min, max string) (res []redis.Z, err error) {
-	cmd := client.ZRangeByScoreWithScores(ctx, associate(key), &redis.ZRangeBy{
+func (c *Client) ZRangeByScoreWithScores(ctx context.Context, key, min, max string) (res []redis.Z, err error) {
+	cmd := c.rdb.ZRangeByScoreWithScores(ctx, c.Key(key), &redis.ZRangeBy{
 		Min: min,
 		Max: max,
 	})
@@ -137,102 +137,207 @@ func ZRangeByScoreWithScores(key, min, max string) (res []redis.Z, err error) {
 }
 
 // ZRank ZRANK key member 返回有序集合中指定成员的索引
-func ZRank(key, member interface{}) (int64, error) {
-	val, err := encode(member)
+func (c *Client) ZRank(ctx context.Context, key, member interface{}) (int64, error) {
+	val, err := c.encode(member)
 	if err != nil {
 		return 0, err
 	}
-	cmd := client.ZRank(ctx, associate(key), val)
+	cmd := c.rdb.ZRank(ctx, c.Key(key), val)
 	return cmd.Val(), cmd.Err()
 }
 
 // ZRevRank ZREVRANK key member 返回有序集合中指定成员的排名，有序集成员按分数值递减(从大到小)排序
-func ZRevRank(key, member interface{}) (int64, error) {
-	val, err := encode(member)
+func (c *Client) ZRevRank(ctx context.Context, key, member interface{}) (int64, error) {
+	val, err := c.encode(member)
 	if err != nil {
 		return 0, err
 	}
-	cmd := client.ZRevRank(ctx, associate(key), val)
+	cmd := c.rdb.ZRevRank(ctx, c.Key(key), val)
 	return cmd.Val(), cmd.Err()
 }
 
 // ZRevRange ZREVRANGE 返回有序集中指定区间内的成员，通过索引，分数从高到低
-func ZRevRange[T any](key string, start, stop int64) ([]T, error) {
-	cmd := client.ZRevRange(ctx, associate(key), start, stop)
+func (t Typed[T]) ZRevRange(ctx context.Context, key string, start, stop int64) ([]T, error) {
+	cmd := t.c.rdb.ZRevRange(ctx, t.c.Key(key), start, stop)
 	if cmd.Err() != nil {
 		return nil, cmd.Err()
 	}
-	return decodeArr[T](cmd.Val())
+	return t.decodeArr(cmd.Val())
 }
 
 // ZRevRangeByScore ZREVRANGEBYSCORE key max min [WITHSCORES] 返回有序集中指定分数区间内的成员，分数从高到低排序
-func ZRevRangeByScore[T any](key, max, min string) ([]T, error) {
-	cmd := client.ZRevRangeByScore(ctx, associate(key), &redis.ZRangeBy{
-		Min: max,
-		Max: min,
+func (t Typed[T]) ZRevRangeByScore(ctx context.Context, key, max, min string) ([]T, error) {
+	cmd := t.c.rdb.ZRevRangeByScore(ctx, t.c.Key(key), &redis.ZRangeBy{
+		Min: min,
+		Max: max,
 	})
 	if cmd.Err() != nil {
 		return nil, cmd.Err()
 	}
-	return decodeArr[T](cmd.Val())
+	return t.decodeArr(cmd.Val())
 }
 
 // ZRevRangeByScoreWithScores ZREVRANGEBYSCORE [WITHSCORES] 返回有序集中指定分数区间内的成员，分数从高到低排序
-func ZRevRangeByScoreWithScores(key, max, min string) (res []redis.Z, err error) {
-	cmd := client.ZRevRangeByScoreWithScores(ctx, associate(key), &redis.ZRangeBy{
-		Min: max,
-		Max: min,
+func (c *Client) ZRevRangeByScoreWithScores(ctx context.Context, key, max, min string) (res []redis.Z, err error) {
+	cmd := c.rdb.ZRevRangeByScoreWithScores(ctx, c.Key(key), &redis.ZRangeBy{
+		Min: min,
+		Max: max,
 	})
-	if cmd.Err() != nil {
-		err = cmd.Err()
-		return
-	}
-
 	return cmd.Val(), cmd.Err()
 }
 
 // ZRem ZREM 移除有序集合中的一个或多个成员
-func ZRem(key string, members ...interface{}) (int64, error) {
-	values := make([]interface{}, len(members))
-	for i, v := range members {
-		str, err := encode(v)
-		if err != nil {
-			return 0, err
-		}
-		values[i] = str
+func (c *Client) ZRem(ctx context.Context, key string, members ...interface{}) (int64, error) {
+	values, err := c.encodeAll(members)
+	if err != nil {
+		return 0, err
 	}
-	cmd := client.ZRem(ctx, associate(key), values...)
+	cmd := c.rdb.ZRem(ctx, c.Key(key), values...)
 	return cmd.Val(), cmd.Err()
 }
 
 // ZRemRangeByLex ZREMRANGEBYLEX key min max 移除有序集合中给定的字典区间的所有成员
-func ZRemRangeByLex(key, min, max string) (int64, error) {
-	cmd := client.ZRemRangeByLex(ctx, associate(key), min, max)
+func (c *Client) ZRemRangeByLex(ctx context.Context, key, min, max string) (int64, error) {
+	cmd := c.rdb.ZRemRangeByLex(ctx, c.Key(key), min, max)
 	return cmd.Val(), cmd.Err()
 }
 
 // ZRemRangeByRank ZREMRANGEBYRANK key start stop 移除有序集合中给定的排名区间的所有成员
-func ZRemRangeByRank(key string, start, stop int64) (int64, error) {
-	cmd := client.ZRemRangeByRank(ctx, associate(key), start, stop)
+func (c *Client) ZRemRangeByRank(ctx context.Context, key string, start, stop int64) (int64, error) {
+	cmd := c.rdb.ZRemRangeByRank(ctx, c.Key(key), start, stop)
 	return cmd.Val(), cmd.Err()
 }
 
 // ZRemRangeByScore ZREMRANGEBYSCORE key min max 移除有序集合中给定的分数区间的所有成员
-func ZRemRangeByScore(key string, min, max int64) (int64, error) {
-	cmd := client.ZRemRangeByScore(ctx, associate(key), strconv.FormatInt(min, 10), strconv.FormatInt(max, 10))
+func (c *Client) ZRemRangeByScore(ctx context.Context, key string, min, max int64) (int64, error) {
+	cmd := c.rdb.ZRemRangeByScore(ctx, c.Key(key), strconv.FormatInt(min, 10), strconv.FormatInt(max, 10))
 	return cmd.Val(), cmd.Err()
 }
 
 // ZUnionStore ZUNIONSTORE destination numkeys key [key ...] 计算给定的一个或多个有序集的并集，并存储在新的 key 中
-func ZUnionStore(destination string, keys ...string) (int64, error) {
-
-	arr := make([]string, len(keys))
-	for i, v := range keys {
-		arr[i] = associate(v)
+func (c *Client) ZUnionStore(ctx context.Context, destination string, keys ...string) (int64, error) {
+	dst, src, err := c.storeKeys(destination, keys)
+	if err != nil {
+		return 0, err
 	}
-
-	cmd := client.ZUnionStore(ctx, associate(destination), &redis.ZStore{
-		Keys: arr,
+	cmd := c.rdb.ZUnionStore(ctx, dst, &redis.ZStore{
+		Keys: src,
 	})
 	return cmd.Val(), cmd.Err()
 }
+
+// 以下包级函数委托给默认实例
+
+// ZAdd ZADD 向有序集合添加一个或多个成员，或者更新已存在成员的分数
+func ZAdd(key string, pairs map[interface{}]float64) error {
+	return defaultClient.ZAdd(ctx, key, pairs)
+}
+
+func ZAddByScore(key string, member interface{}, score float64) error {
+	return defaultClient.ZAddByScore(ctx, key, member, score)
+}
+
+// ZCard ZCARD 获取有序集合的成员数
+func ZCard(key string) (int64, error) {
+	return defaultClient.ZCard(ctx, key)
+}
+
+// ZCount ZCOUNT 计算在有序集合中指定区间分数的成员数
+func ZCount(key, min, max string) (int64, error) {
+	return defaultClient.ZCount(ctx, key, min, max)
+}
+
+// ZScore ZSCORE
+func ZScore(key, member interface{}) (float64, error) {
+	return defaultClient.ZScore(ctx, key, member)
+}
+
+// ZIncrBy ZINCRBY 有序集合中对指定成员的分数加上增量 increment
+func ZIncrBy(key, member interface{}, increment float64) (float64, error) {
+	return defaultClient.ZIncrBy(ctx, key, member, increment)
+}
+
+// ZInterStore ZINTERSTORE 计算给定的一个或多个有序集的交集并将结果集存储在新的有序集合 destination 中
+func ZInterStore(destination string, keys ...string) (int64, error) {
+	return defaultClient.ZInterStore(ctx, destination, keys...)
+}
+
+// ZLexCount ZLEXCOUNT 在有序集合中计算指定字典区间内成员数量
+func ZLexCount(key, min, max string) (int64, error) {
+	return defaultClient.ZLexCount(ctx, key, min, max)
+}
+
+// ZRange ZRANGE 通过索引区间返回有序集合指定区间内的成员
+func ZRange[T any](key string, start, stop int64) (res []T, err error) {
+	return As[T](defaultClient).ZRange(ctx, key, start, stop)
+}
+
+// ZRangeWithScores ZRANGE 通过分数返回有序集合指定区间内的成员
+func ZRangeWithScores(key string, start, stop int64) ([]redis.Z, error) {
+	return defaultClient.ZRangeWithScores(ctx, key, start, stop)
+}
+
+// ZRangeByLex ZRANGEBYLEX key min max 通过字典区间返回有序集合的成员
+func ZRangeByLex(key, min, max string) ([]string, error) {
+	return defaultClient.ZRangeByLex(ctx, key, min, max)
+}
+
+// ZRangeByScore ZRANGEBYSCORE 通过分数返回有序集合指定区间内的成员
+func ZRangeByScore[T any](key, min, max string) (res []T, err error) {
+	return As[T](defaultClient).ZRangeByScore(ctx, key, min, max)
+}
+
+// ZRangeByScoreWithScores ZRANGEBYSCORE [WITHSCORES] 通过分数返回有序集合指定区间内的成员
+func ZRangeByScoreWithScores(key, min, max string) (res []redis.Z, err error) {
+	return defaultClient.ZRangeByScoreWithScores(ctx, key, min, max)
+}
+
+// ZRank ZRANK key member 返回有序集合中指定成员的索引
+func ZRank(key, member interface{}) (int64, error) {
+	return defaultClient.ZRank(ctx, key, member)
+}
+
+// ZRevRank ZREVRANK key member 返回有序集合中指定成员的排名，有序集成员按分数值递减(从大到小)排序
+func ZRevRank(key, member interface{}) (int64, error) {
+	return defaultClient.ZRevRank(ctx, key, member)
+}
+
+// ZRevRange ZREVRANGE 返回有序集中指定区间内的成员，通过索引，分数从高到低
+func ZRevRange[T any](key string, start, stop int64) ([]T, error) {
+	return As[T](defaultClient).ZRevRange(ctx, key, start, stop)
+}
+
+// ZRevRangeByScore ZREVRANGEBYSCORE key max min [WITHSCORES] 返回有序集中指定分数区间内的成员，分数从高到低排序
+func ZRevRangeByScore[T any](key, max, min string) ([]T, error) {
+	return As[T](defaultClient).ZRevRangeByScore(ctx, key, max, min)
+}
+
+// ZRevRangeByScoreWithScores ZREVRANGEBYSCORE [WITHSCORES] 返回有序集中指定分数区间内的成员，分数从高到低排序
+func ZRevRangeByScoreWithScores(key, max, min string) (res []redis.Z, err error) {
+	return defaultClient.ZRevRangeByScoreWithScores(ctx, key, max, min)
+}
+
+// ZRem ZREM 移除有序集合中的一个或多个成员
+func ZRem(key string, members ...interface{}) (int64, error) {
+	return defaultClient.ZRem(ctx, key, members...)
+}
+
+// ZRemRangeByLex ZREMRANGEBYLEX key min max 移除有序集合中给定的字典区间的所有成员
+func ZRemRangeByLex(key, min, max string) (int64, error) {
+	return defaultClient.ZRemRangeByLex(ctx, key, min, max)
+}
+
+// ZRemRangeByRank ZREMRANGEBYRANK key start stop 移除有序集合中给定的排名区间的所有成员
+func ZRemRangeByRank(key string, start, stop int64) (int64, error) {
+	return defaultClient.ZRemRangeByRank(ctx, key, start, stop)
+}
+
+// ZRemRangeByScore ZREMRANGEBYSCORE key min max 移除有序集合中给定的分数区间的所有成员
+func ZRemRangeByScore(key string, min, max int64) (int64, error) {
+	return defaultClient.ZRemRangeByScore(ctx, key, min, max)
+}
+
+// ZUnionStore ZUNIONSTORE destination numkeys key [key ...] 计算给定的一个或多个有序集的并集，并存储在新的 key 中
+func ZUnionStore(destination string, keys ...string) (int64, error) {
+	return defaultClient.ZUnionStore(ctx, destination, keys...)
+}
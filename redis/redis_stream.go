@@ -0,0 +1,230 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// StreamPayloadField 消息体写入的字段名
+const StreamPayloadField = "payload"
+
+// StreamMessage 流消息，Payload 由 payload 字段解码得到，解码失败时 Err 非空
+type StreamMessage[T any] struct {
+	ID      string
+	Stream  string
+	Payload T
+	Raw     string
+	Values  map[string]interface{}
+	Err     error
+}
+
+// XAdd 1	XADD key [MAXLEN ~ n] ID field value 追加消息，maxLen>0 时近似裁剪到该长度，返回消息ID
+func (c *Client) XAdd(ctx context.Context, stream string, payload interface{}, maxLen int64) (string, error) {
+	val, err := c.encode(payload)
+	if err != nil {
+		return "", err
+	}
+	return c.xAdd(ctx, c.Key(stream), map[string]interface{}{StreamPayloadField: val}, maxLen)
+}
+
+func (c *Client) xAdd(ctx context.Context, fullKey string, values map[string]interface{}, maxLen int64) (string, error) {
+	args := &redis.XAddArgs{
+		Stream: fullKey,
+		Values: values,
+	}
+	if maxLen > 0 {
+		args.MaxLen = maxLen
+		args.Approx = true
+	}
+	return c.rdb.XAdd(ctx, args).Result()
+}
+
+// XLen 2	XLEN key 获取消息数量
+func (c *Client) XLen(ctx context.Context, stream string) (int64, error) {
+	return c.rdb.XLen(ctx, c.Key(stream)).Result()
+}
+
+// XDel 3	XDEL key ID [ID ...] 删除消息
+func (c *Client) XDel(ctx context.Context, stream string, ids ...string) (int64, error) {
+	return c.rdb.XDel(ctx, c.Key(stream), ids...).Result()
+}
+
+// XTrim 4	XTRIM key MAXLEN ~ n 近似裁剪到指定长度
+func (c *Client) XTrim(ctx context.Context, stream string, maxLen int64) (int64, error) {
+	return c.rdb.XTrimMaxLenApprox(ctx, c.Key(stream), maxLen, 0).Result()
+}
+
+// XTrimMinID 5	XTRIM key MINID ~ id 近似删除ID小于 minID 的消息，可按时间裁剪，如 fmt.Sprint(time.Now().Add(-24*time.Hour).UnixMilli())
+func (c *Client) XTrimMinID(ctx context.Context, stream string, minID string) (int64, error) {
+	return c.rdb.XTrimMinIDApprox(ctx, c.Key(stream), minID, 0).Result()
+}
+
+// XGroupCreate 6	XGROUP CREATE key group id MKSTREAM 创建消费组，流不存在时自动创建，消费组已存在时忽略
+// start 为 "$" 时只消费之后的新消息，为 "0" 时消费全部消息
+func (c *Client) XGroupCreate(ctx context.Context, stream, group, start string) error {
+	err := c.rdb.XGroupCreateMkStream(ctx, c.Key(stream), group, start).Err()
+	if err != nil && strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil
+	}
+	return err
+}
+
+// XAck 7	XACK key group ID [ID ...] 确认消息已处理
+func (c *Client) XAck(ctx context.Context, stream, group string, ids ...string) (int64, error) {
+	return c.rdb.XAck(ctx, c.Key(stream), group, ids...).Result()
+}
+
+// XPending 8	XPENDING key group IDLE ms - + count 返回空闲超过 idle 的待确认消息，包含投递次数
+func (c *Client) XPending(ctx context.Context, stream, group string, idle time.Duration, count int64) ([]redis.XPendingExt, error) {
+	return c.rdb.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: c.Key(stream),
+		Group:  group,
+		Idle:   idle,
+		Start:  "-",
+		End:    "+",
+		Count:  count,
+	}).Result()
+}
+
+// XRange 9	XRANGE key start end COUNT n 按ID区间读取消息
+func (t Typed[T]) XRange(ctx context.Context, stream, start, end string, count int64) ([]StreamMessage[T], error) {
+	fullKey := t.c.Key(stream)
+	messages, err := t.c.rdb.XRangeN(ctx, fullKey, start, end, count).Result()
+	if err != nil {
+		return nil, err
+	}
+	return t.messages(stream, messages), nil
+}
+
+// XRead 10	XREAD COUNT n BLOCK ms STREAMS key id 读取ID之后的消息，block<0 时不阻塞，无消息时返回 Nil
+func (t Typed[T]) XRead(ctx context.Context, stream, lastID string, count int64, block time.Duration) ([]StreamMessage[T], error) {
+	streams, err := t.c.rdb.XRead(ctx, &redis.XReadArgs{
+		Streams: []string{t.c.Key(stream), lastID},
+		Count:   count,
+		Block:   block,
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	return t.streams(stream, streams), nil
+}
+
+// XReadGroup 11	XREADGROUP GROUP group consumer COUNT n BLOCK ms STREAMS key > 以消费者身份读取未投递的消息，无消息时返回 Nil
+func (t Typed[T]) XReadGroup(ctx context.Context, stream, group, consumer string, count int64, block time.Duration) ([]StreamMessage[T], error) {
+	streams, err := t.c.rdb.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    group,
+		Consumer: consumer,
+		Streams:  []string{t.c.Key(stream), ">"},
+		Count:    count,
+		Block:    block,
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	return t.streams(stream, streams), nil
+}
+
+// XClaim 12	XCLAIM key group consumer min-idle-time ID [ID ...] 将空闲超过 minIdle 的待确认消息转给 consumer
+func (t Typed[T]) XClaim(ctx context.Context, stream, group, consumer string, minIdle time.Duration, ids ...string) ([]StreamMessage[T], error) {
+	messages, err := t.c.rdb.XClaim(ctx, &redis.XClaimArgs{
+		Stream:   t.c.Key(stream),
+		Group:    group,
+		Consumer: consumer,
+		MinIdle:  minIdle,
+		Messages: ids,
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	return t.messages(stream, messages), nil
+}
+
+func (t Typed[T]) streams(stream string, streams []redis.XStream) []StreamMessage[T] {
+	var res []StreamMessage[T]
+	for _, s := range streams {
+		res = append(res, t.messages(stream, s.Messages)...)
+	}
+	return res
+}
+
+func (t Typed[T]) messages(stream string, messages []redis.XMessage) []StreamMessage[T] {
+	res := make([]StreamMessage[T], len(messages))
+	for i, m := range messages {
+		msg := StreamMessage[T]{ID: m.ID, Stream: stream, Values: m.Values}
+		raw, ok := m.Values[StreamPayloadField].(string)
+		if !ok {
+			msg.Err = fmt.Errorf("stream message %s has no %s field", m.ID, StreamPayloadField)
+		} else {
+			msg.Raw = raw
+			msg.Payload, msg.Err = t.decode(raw)
+		}
+		res[i] = msg
+	}
+	return res
+}
+
+// 以下包级函数委托给默认实例
+
+// XAdd 1	XADD key [MAXLEN ~ n] ID field value 追加消息，maxLen>0 时近似裁剪到该长度，返回消息ID
+func XAdd(stream string, payload interface{}, maxLen int64) (string, error) {
+	return defaultClient.XAdd(ctx, stream, payload, maxLen)
+}
+
+// XLen 2	XLEN key 获取消息数量
+func XLen(stream string) (int64, error) {
+	return defaultClient.XLen(ctx, stream)
+}
+
+// XDel 3	XDEL key ID [ID ...] 删除消息
+func XDel(stream string, ids ...string) (int64, error) {
+	return defaultClient.XDel(ctx, stream, ids...)
+}
+
+// XTrim 4	XTRIM key MAXLEN ~ n 近似裁剪到指定长度
+func XTrim(stream string, maxLen int64) (int64, error) {
+	return defaultClient.XTrim(ctx, stream, maxLen)
+}
+
+// XTrimMinID 5	XTRIM key MINID ~ id 近似删除ID小于 minID 的消息
+func XTrimMinID(stream string, minID string) (int64, error) {
+	return defaultClient.XTrimMinID(ctx, stream, minID)
+}
+
+// XGroupCreate 6	XGROUP CREATE key group id MKSTREAM 创建消费组，流不存在时自动创建，消费组已存在时忽略
+func XGroupCreate(stream, group, start string) error {
+	return defaultClient.XGroupCreate(ctx, stream, group, start)
+}
+
+// XAck 7	XACK key group ID [ID ...] 确认消息已处理
+func XAck(stream, group string, ids ...string) (int64, error) {
+	return defaultClient.XAck(ctx, stream, group, ids...)
+}
+
+// XPending 8	XPENDING key group IDLE ms - + count 返回空闲超过 idle 的待确认消息，包含投递次数
+func XPending(stream, group string, idle time.Duration, count int64) ([]redis.XPendingExt, error) {
+	return defaultClient.XPending(ctx, stream, group, idle, count)
+}
+
+// XRange 9	XRANGE key start end COUNT n 按ID区间读取消息
+func XRange[T any](stream, start, end string, count int64) ([]StreamMessage[T], error) {
+	return As[T](defaultClient).XRange(ctx, stream, start, end, count)
+}
+
+// XRead 10	XREAD COUNT n BLOCK ms STREAMS key id 读取ID之后的消息，block<0 时不阻塞，无消息时返回 Nil
+func XRead[T any](stream, lastID string, count int64, block time.Duration) ([]StreamMessage[T], error) {
+	return As[T](defaultClient).XRead(ctx, stream, lastID, count, block)
+}
+
+// XReadGroup 11	XREADGROUP GROUP group consumer COUNT n BLOCK ms STREAMS key > 以消费者身份读取未投递的消息，无消息时返回 Nil
+func XReadGroup[T any](stream, group, consumer string, count int64, block time.Duration) ([]StreamMessage[T], error) {
+	return As[T](defaultClient).XReadGroup(ctx, stream, group, consumer, count, block)
+}
+
+// XClaim 12	XCLAIM key group consumer min-idle-time ID [ID ...] 将空闲超过 minIdle 的待确认消息转给 consumer
+func XClaim[T any](stream, group, consumer string, minIdle time.Duration, ids ...string) ([]StreamMessage[T], error) {
+	return As[T](defaultClient).XClaim(ctx, stream, group, consumer, minIdle, ids...)
+}
@@ -0,0 +1,101 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStreamHelpers(t *testing.T) {
+	Delete("test_stream")
+	defer Delete("test_stream")
+
+	id, err := XAdd("test_stream", &args{Name: "a", Age: 1}, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = XGroupCreate("test_stream", "g", "0"); err != nil {
+		t.Fatal(err)
+	}
+	if err = XGroupCreate("test_stream", "g", "0"); err != nil {
+		t.Errorf("creating an existing group should be ignored, got %v", err)
+	}
+
+	messages, err := XReadGroup[args]("test_stream", "g", "c1", 10, time.Second)
+	if err != nil || len(messages) != 1 || messages[0].ID != id || messages[0].Payload.Name != "a" {
+		t.Fatalf("XReadGroup = %+v, %v", messages, err)
+	}
+	pending, err := XPending("test_stream", "g", 0, 10)
+	if err != nil || len(pending) != 1 || pending[0].RetryCount != 1 {
+		t.Fatalf("XPending = %+v, %v", pending, err)
+	}
+	claimed, err := XClaim[args]("test_stream", "g", "c2", 0, id)
+	if err != nil || len(claimed) != 1 || claimed[0].Payload.Age != 1 {
+		t.Fatalf("XClaim = %+v, %v", claimed, err)
+	}
+	if n, err := XAck("test_stream", "g", id); err != nil || n != 1 {
+		t.Fatalf("XAck = %d, %v", n, err)
+	}
+
+	read, err := XRead[args]("test_stream", "0", 10, -1)
+	if err != nil || len(read) != 1 {
+		t.Fatalf("XRead = %+v, %v", read, err)
+	}
+	XAdd("test_stream", "not json", 0)
+	ranged, _ := XRange[args]("test_stream", "-", "+", 10)
+	if len(ranged) != 2 || ranged[1].Err == nil {
+		t.Errorf("expect a decode error on the second message, got %+v", ranged)
+	}
+	if n, err := XTrim("test_stream", 0); err != nil || n < 0 {
+		t.Errorf("XTrim = %d, %v", n, err)
+	}
+}
+
+func TestStreamWorker(t *testing.T) {
+	Delete("test_stream_worker")
+	Delete("test_stream_worker:dead")
+	defer Delete("test_stream_worker")
+	defer Delete("test_stream_worker:dead")
+
+	var handled, failed int32
+	worker := NewStreamWorker[args](Default(), "test_stream_worker", "g",
+		func(ctx context.Context, msg StreamMessage[args]) error {
+			if msg.Payload.Name == "bad" {
+				atomic.AddInt32(&failed, 1)
+				return errors.New("always fails")
+			}
+			atomic.AddInt32(&handled, 1)
+			return nil
+		},
+		WithStreamBlock(100*time.Millisecond),
+		WithStreamClaimIdle(100*time.Millisecond),
+		WithStreamMaxDeliveries(2))
+	worker.Start()
+	defer worker.Stop()
+
+	XAdd("test_stream_worker", &args{Name: "good"}, 0)
+	XAdd("test_stream_worker", &args{Name: "bad"}, 0)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if n, _ := XLen("test_stream_worker:dead"); n == 1 {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if atomic.LoadInt32(&handled) != 1 {
+		t.Errorf("expect 1 handled message, got %d", handled)
+	}
+	if atomic.LoadInt32(&failed) != 2 {
+		t.Errorf("expect 2 deliveries of the failing message, got %d", failed)
+	}
+	dead, err := XRange[args]("test_stream_worker:dead", "-", "+", 10)
+	if err != nil || len(dead) != 1 || dead[0].Payload.Name != "bad" || dead[0].Values["error"] != "always fails" {
+		t.Errorf("dead letter = %+v, %v", dead, err)
+	}
+	if pending, _ := XPending("test_stream_worker", "g", 0, 10); len(pending) != 0 {
+		t.Errorf("expect no pending messages, got %+v", pending)
+	}
+}
@@ -0,0 +1,372 @@
+// 基于 Streams 消费组的消息处理
+// 读取循环以 XREADGROUP 获取新消息，处理成功后 XACK；处理失败的消息保留在待确认列表中
+// 认领循环定期检查空闲超过 claimIdle 的待确认消息(处理失败或消费者崩溃)，XCLAIM 后重新处理
+// 投递次数达到上限或消息体无法解码时写入死信流并确认
+// 示例
+// worker := redis.NewStreamWorker[*OrderEvent](redis.Default(), "order_events", "billing",
+// 	  func(ctx context.Context, msg redis.StreamMessage[*OrderEvent]) error {
+// 	 	  return billing.Handle(msg.Payload)
+// 	  }, redis.WithStreamMaxDeliveries(3))
+// worker.Start()
+// defer worker.Stop()
+
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultStreamBatch         = 10
+	defaultStreamBlock         = 2 * time.Second
+	defaultStreamClaimIdle     = 30 * time.Second
+	defaultStreamMaxDeliveries = 5
+	streamDeadSuffix           = ":dead"
+	streamRetryInterval        = time.Second
+)
+
+// StreamHandler 消息处理函数，返回错误时消息在 claimIdle 后重新投递，ctx 在 Stop 时取消
+// 确认与死信写入不受 Stop 影响，已处理完成的消息不会因停止而重复投递
+type StreamHandler[T any] func(ctx context.Context, msg StreamMessage[T]) error
+
+// StreamWorkerOption 消费组处理选项
+type StreamWorkerOption func(*streamWorkerOptions)
+
+type streamWorkerOptions struct {
+	consumer      string
+	batch         int64
+	block         time.Duration
+	claimIdle     time.Duration
+	maxDeliveries int64
+	deadLetter    string
+	start         string
+	onError       func(msgID string, err error)
+}
+
+// WithStreamConsumer 消费者名称，默认为 NewNodeID()
+func WithStreamConsumer(consumer string) StreamWorkerOption {
+	return func(o *streamWorkerOptions) {
+		o.consumer = consumer
+	}
+}
+
+// WithStreamBatch 每次读取或认领的消息数量，默认10
+func WithStreamBatch(n int64) StreamWorkerOption {
+	return func(o *streamWorkerOptions) {
+		o.batch = n
+	}
+}
+
+// WithStreamBlock 无消息时 XREADGROUP 的阻塞时长，同时决定 Stop 的最长等待时间，默认2秒
+func WithStreamBlock(block time.Duration) StreamWorkerOption {
+	return func(o *streamWorkerOptions) {
+		o.block = block
+	}
+}
+
+// WithStreamClaimIdle 待确认消息空闲超过该时长后被认领重试，应大于单条消息的最长处理时间，默认30秒
+func WithStreamClaimIdle(idle time.Duration) StreamWorkerOption {
+	return func(o *streamWorkerOptions) {
+		o.claimIdle = idle
+	}
+}
+
+// WithStreamMaxDeliveries 最大投递次数，达到后写入死信流，默认5
+func WithStreamMaxDeliveries(n int64) StreamWorkerOption {
+	return func(o *streamWorkerOptions) {
+		o.maxDeliveries = n
+	}
+}
+
+// WithStreamDeadLetter 死信流名称，默认为 流名称:dead
+func WithStreamDeadLetter(stream string) StreamWorkerOption {
+	return func(o *streamWorkerOptions) {
+		o.deadLetter = stream
+	}
+}
+
+// WithStreamStartID 首次创建消费组时的起始ID，默认 "0" 消费全部历史消息，"$" 只消费新消息
+func WithStreamStartID(id string) StreamWorkerOption {
+	return func(o *streamWorkerOptions) {
+		o.start = id
+	}
+}
+
+// WithStreamErrorHandler 处理失败、死信及redis错误回调，msgID 为空表示与具体消息无关
+func WithStreamErrorHandler(fn func(msgID string, err error)) StreamWorkerOption {
+	return func(o *streamWorkerOptions) {
+		o.onError = fn
+	}
+}
+
+// StreamWorker 消费组处理器
+type StreamWorker[T any] struct {
+	c        *Client
+	stream   string
+	group    string
+	handler  StreamHandler[T]
+	opts     *streamWorkerOptions
+	lastErr  sync.Map // 消息ID -> 最近一次处理错误，写入死信时附带
+	priority int
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        *sync.WaitGroup
+}
+
+// NewStreamWorker /工厂方法，Start 后开始消费
+func NewStreamWorker[T any](c *Client, stream, group string, handler StreamHandler[T], opts ...StreamWorkerOption) *StreamWorker[T] {
+	o := &streamWorkerOptions{
+		batch:         defaultStreamBatch,
+		block:         defaultStreamBlock,
+		claimIdle:     defaultStreamClaimIdle,
+		maxDeliveries: defaultStreamMaxDeliveries,
+		deadLetter:    stream + streamDeadSuffix,
+		start:         "0",
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if "" == o.consumer {
+		o.consumer = NewNodeID()
+	}
+
+	object := &StreamWorker[T]{
+		c:        c,
+		stream:   stream,
+		group:    group,
+		handler:  handler,
+		opts:     o,
+		priority: 2,
+		wg:       &sync.WaitGroup{},
+	}
+	object.ctx, object.cancel = context.WithCancel(context.Background())
+	return object
+}
+
+// Start /启动读取与认领循环
+func (object *StreamWorker[T]) Start() {
+	object.startOnce.Do(func() {
+		object.wg.Add(2)
+		go object.readLoop()
+		go object.claimLoop()
+	})
+}
+
+// Stop /停止消费并等待处理中的消息完成
+func (object *StreamWorker[T]) Stop() {
+	object.stopOnce.Do(func() {
+		object.cancel()
+		object.wg.Wait()
+	})
+}
+
+// Consumer /消费者名称
+func (object *StreamWorker[T]) Consumer() string {
+	return object.opts.consumer
+}
+
+func (object *StreamWorker[T]) readLoop() {
+	defer object.wg.Done()
+
+	typed := As[T](object.c)
+	for !object.ensureGroup() {
+		if !object.sleep(streamRetryInterval) {
+			return
+		}
+	}
+	for {
+		if nil != object.ctx.Err() {
+			return
+		}
+		messages, err := typed.XReadGroup(object.ctx, object.stream, object.group, object.opts.consumer,
+			object.opts.batch, object.opts.block)
+		if err != nil {
+			if err == Nil || nil != object.ctx.Err() {
+				continue
+			}
+			object.reportError("", err)
+			// 消费组被删除时重新创建
+			object.ensureGroup()
+			object.sleep(streamRetryInterval)
+			continue
+		}
+		for _, msg := range messages {
+			object.process(msg)
+		}
+	}
+}
+
+func (object *StreamWorker[T]) claimLoop() {
+	defer object.wg.Done()
+
+	interval := object.opts.claimIdle / 2
+	if interval < streamRetryInterval {
+		interval = streamRetryInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-object.ctx.Done():
+			return
+		case <-ticker.C:
+			object.claim()
+		}
+	}
+}
+
+// claim /认领空闲的待确认消息，超过投递次数的写入死信流
+func (object *StreamWorker[T]) claim() {
+	pending, err := object.c.XPending(object.ctx, object.stream, object.group, object.opts.claimIdle, object.opts.batch)
+	if err != nil {
+		if nil == object.ctx.Err() {
+			object.reportError("", err)
+		}
+		return
+	}
+
+	ids := make([]string, 0, len(pending))
+	for _, p := range pending {
+		if p.RetryCount >= object.opts.maxDeliveries {
+			object.bury(p.ID, "", p.RetryCount, object.lastError(p.ID))
+			continue
+		}
+		ids = append(ids, p.ID)
+	}
+	if 0 == len(ids) {
+		return
+	}
+
+	messages, err := As[T](object.c).XClaim(object.ctx, object.stream, object.group, object.opts.consumer,
+		object.opts.claimIdle, ids...)
+	if err != nil {
+		object.reportError("", err)
+		return
+	}
+	for _, msg := range messages {
+		object.process(msg)
+	}
+}
+
+func (object *StreamWorker[T]) process(msg StreamMessage[T]) {
+	if msg.Err != nil {
+		// 消息体无法解码，重试也不会成功
+		object.bury(msg.ID, msg.Raw, 1, msg.Err)
+		return
+	}
+
+	if err := object.execute(msg); err != nil {
+		object.lastErr.Store(msg.ID, err)
+		object.reportError(msg.ID, err)
+		return
+	}
+	object.lastErr.Delete(msg.ID)
+	if _, err := object.c.XAck(context.Background(), object.stream, object.group, msg.ID); err != nil {
+		object.reportError(msg.ID, err)
+	}
+}
+
+func (object *StreamWorker[T]) execute(msg StreamMessage[T]) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("stream message %s panic: %v", msg.ID, r)
+		}
+	}()
+	return object.handler(object.ctx, msg)
+}
+
+// bury /写入死信流并确认原消息，raw 为空时从原流读取消息体
+func (object *StreamWorker[T]) bury(id, raw string, deliveries int64, cause error) {
+	if "" == raw {
+		messages, err := object.c.rdb.XRangeN(context.Background(), object.c.Key(object.stream), id, id, 1).Result()
+		if err != nil {
+			object.reportError(id, err)
+			return
+		}
+		if 0 < len(messages) {
+			raw, _ = messages[0].Values[StreamPayloadField].(string)
+		}
+	}
+
+	reason := "max deliveries exceeded"
+	if nil != cause {
+		reason = cause.Error()
+	}
+	_, err := object.c.xAdd(context.Background(), object.c.Key(object.opts.deadLetter), map[string]interface{}{
+		StreamPayloadField: raw,
+		"source_id":        id,
+		"source_stream":    object.stream,
+		"group":            object.group,
+		"deliveries":       strconv.FormatInt(deliveries, 10),
+		"error":            reason,
+	}, 0)
+	if err != nil {
+		object.reportError(id, err)
+		return
+	}
+	object.lastErr.Delete(id)
+	object.c.XAck(context.Background(), object.stream, object.group, id)
+	object.reportError(id, fmt.Errorf("stream message %s moved to %s: %s", id, object.opts.deadLetter, reason))
+}
+
+func (object *StreamWorker[T]) lastError(id string) error {
+	if v, ok := object.lastErr.Load(id); ok {
+		return v.(error)
+	}
+	return nil
+}
+
+func (object *StreamWorker[T]) ensureGroup() bool {
+	err := object.c.XGroupCreate(object.ctx, object.stream, object.group, object.opts.start)
+	if err != nil && nil == object.ctx.Err() {
+		object.reportError("", err)
+	}
+	return err == nil
+}
+
+// sleep /等待 d，期间停止时返回 false
+func (object *StreamWorker[T]) sleep(d time.Duration) bool {
+	select {
+	case <-object.ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+func (object *StreamWorker[T]) reportError(msgID string, err error) {
+	if nil != object.opts.onError {
+		object.opts.onError(msgID, err)
+	}
+}
+
+// Name /名字
+func (object *StreamWorker[T]) Name() string {
+	return "StreamWorker"
+}
+
+// SetShutdownPriority /设置关闭优先级
+func (object *StreamWorker[T]) SetShutdownPriority(priority int) {
+	object.priority = priority
+}
+
+// ShutdownPriority /关闭优先级
+func (object *StreamWorker[T]) ShutdownPriority() int {
+	return object.priority
+}
+
+// BeforeShutdown /关闭之前
+func (object *StreamWorker[T]) BeforeShutdown() {
+	object.Stop()
+}
+
+// AfterShutdown /关闭之后
+func (object *StreamWorker[T]) AfterShutdown() {}
@@ -1,87 +1,82 @@
 package redis
 
 import (
+	"context"
 	"time"
 )
 
 // Set 1	SET key value 设置指定 key 的值。
-func Set(key string, val interface{}, timeout int64) error {
-	bytes, err := encode(val)
-	if err != nil {
-		return err
-	}
-	cmd := client.Set(ctx, associate(key), bytes, time.Duration(timeout)*time.Second)
-	return cmd.Err()
+func (c *Client) Set(ctx context.Context, key string, val interface{}, timeout int64) error {
+	return c.SetForNoPrefix(ctx, c.Key(key), val, timeout)
 }
 
-func SetForNoPrefix(key string, val interface{}, dur int64) error {
-	bytes, err := encode(val)
+// SetForNoPrefix SET key value 设置指定 key 的值，key 不添加前缀
+func (c *Client) SetForNoPrefix(ctx context.Context, key string, val interface{}, dur int64) error {
+	bytes, err := c.encode(val)
 	if err != nil {
 		return err
 	}
-	cmd := client.Set(ctx, key, bytes, time.Duration(dur)*time.Second)
-	return cmd.Err()
+	cmd := c.rdb.Set(ctx, key, bytes, time.Duration(dur)*time.Second)
+	return c.changed(ctx, cmd.Err(), key)
 }
 
 // Get 2	GET key 获取指定 key 的值。
-func Get[T any](key string) (t T, err error) {
-	cmd := client.Get(ctx, associate(key))
-	if cmd.Err() != nil {
-		err = cmd.Err()
-		return
-	}
-	return decodeVal[T](cmd.Val())
+func (t Typed[T]) Get(ctx context.Context, key string) (T, error) {
+	return t.GetForNoPrefix(ctx, t.c.Key(key))
 }
 
-func GetForNoPrefix[T any](key string) (t T, err error) {
-	cmd := client.Get(ctx, key)
+// GetForNoPrefix GET key 获取指定 key 的值，key 不添加前缀
+func (t Typed[T]) GetForNoPrefix(ctx context.Context, key string) (v T, err error) {
+	cmd := t.c.rdb.Get(ctx, key)
 	if cmd.Err() != nil {
 		err = cmd.Err()
 		return
 	}
-
-	return decodeVal[T](cmd.Val())
+	return t.decode(cmd.Val())
 }
 
 // GetRange 3	GETRANGE key start end 返回 key 中字符串值的子字符
-func GetRange(key string, start, end int64) (string, error) {
-	cmd := client.GetRange(ctx, associate(key), start, end)
+func (c *Client) GetRange(ctx context.Context, key string, start, end int64) (string, error) {
+	cmd := c.rdb.GetRange(ctx, c.Key(key), start, end)
 	return cmd.Val(), cmd.Err()
 }
 
 // GetSet 4	GETSET key value 将给定 key 的值设为 value ，并返回 key 的旧值(old value)。
-func GetSet(key string, val interface{}) (string, error) {
-	val, err := encode(val)
+func (c *Client) GetSet(ctx context.Context, key string, val interface{}) (string, error) {
+	str, err := c.encode(val)
 	if err != nil {
 		return "", err
 	}
-	cmd := client.GetSet(ctx, associate(key), val)
-	return cmd.Val(), cmd.Err()
+	fullKey := c.Key(key)
+	cmd := c.rdb.GetSet(ctx, fullKey, str)
+	return cmd.Val(), c.changed(ctx, cmd.Err(), fullKey)
 }
 
 // GetBit 5	GETBIT key offset 对 key 所储存的字符串值，获取指定偏移量上的位(bit)。
-func GetBit(key string, offset int64) (int64, error) {
-	cmd := client.GetBit(ctx, associate(key), offset)
+func (c *Client) GetBit(ctx context.Context, key string, offset int64) (int64, error) {
+	cmd := c.rdb.GetBit(ctx, c.Key(key), offset)
 	return cmd.Val(), cmd.Err()
 }
 
 // MGet 6	MGET key1 [key2..] 获取所有(一个或多个)给定 key 的值。
-func MGet[T any](keys ...string) (res []T, err error) {
-	var args []string
-	for _, key := range keys {
-		args = append(args, associate(key))
+func (t Typed[T]) MGet(ctx context.Context, keys ...string) (res []T, err error) {
+	fullKeys := t.c.keys(keys)
+	if err = t.c.sameSlot(fullKeys...); err != nil {
+		return
 	}
-	cmd := client.MGet(ctx, args...)
-
+	cmd := t.c.rdb.MGet(ctx, fullKeys...)
 	if cmd.Err() != nil {
 		err = cmd.Err()
 		return
 	}
 
 	result := make([]T, len(cmd.Val()))
-
 	for i, s := range cmd.Val() {
-		v, err1 := decodeVal[T](s.(string))
+		str, ok := s.(string)
+		if !ok {
+			continue
+		}
+		v, err1 := t.decode(str)
 		if err1 != nil {
 			continue
 		}
@@ -91,127 +86,275 @@ func MGet[T any](keys ...string) (res []T, err error) {
 }
 
 // SetBit 7	SETBIT key offset value 对 key 所储存的字符串值，设置或清除指定偏移量上的位(bit)。
-func SetBit(key string, offset int64, value int) (int64, error) {
-	cmd := client.SetBit(ctx, associate(key), offset, value)
-	return cmd.Val(), cmd.Err()
+func (c *Client) SetBit(ctx context.Context, key string, offset int64, value int) (int64, error) {
+	fullKey := c.Key(key)
+	cmd := c.rdb.SetBit(ctx, fullKey, offset, value)
+	return cmd.Val(), c.changed(ctx, cmd.Err(), fullKey)
 }
 
 // SetEX 8	SETEX key seconds value 将值 value 关联到 key ，并将 key 的过期时间设为 seconds (以秒为单位)。
-func SetEX(key string, val interface{}, timeout int64) error {
-	str, err := encode(val)
+func (c *Client) SetEX(ctx context.Context, key string, val interface{}, timeout int64) error {
+	str, err := c.encode(val)
 	if err != nil {
 		return err
 	}
-	return client.SetEX(ctx, associate(key), str, time.Duration(timeout)*time.Second).Err()
+	fullKey := c.Key(key)
+	return c.changed(ctx, c.rdb.SetEX(ctx, fullKey, str, time.Duration(timeout)*time.Second).Err(), fullKey)
 }
 
 // Setnx 9	SETNX key value 只有在 key 不存在时设置 key 的值。
-func Setnx(key string, val interface{}) (bool, error) {
-	str, err := encode(val)
-	if err != nil {
-		return false, err
-	}
-	cmd := client.SetNX(ctx, associate(key), str, 0)
-	return cmd.Val(), cmd.Err()
+func (c *Client) Setnx(ctx context.Context, key string, val interface{}) (bool, error) {
+	return c.SetnxExpire(ctx, key, val, 0)
 }
 
 // SetnxExpire SETNX WITH EXPIRE (Second)
-func SetnxExpire(key string, val interface{}, expire int64) (bool, error) {
-	str, err := encode(val)
+func (c *Client) SetnxExpire(ctx context.Context, key string, val interface{}, expire int64) (bool, error) {
+	str, err := c.encode(val)
 	if err != nil {
 		return false, err
 	}
-	cmd := client.SetNX(ctx, associate(key), str, time.Duration(expire)*time.Second)
-	return cmd.Val(), cmd.Err()
+	fullKey := c.Key(key)
+	cmd := c.rdb.SetNX(ctx, fullKey, str, time.Duration(expire)*time.Second)
+	if !cmd.Val() {
+		return false, cmd.Err()
+	}
+	return true, c.changed(ctx, cmd.Err(), fullKey)
 }
 
 // SetRange 10	SETRANGE key offset value 用 value 参数覆写给定 key 所储存的字符串值，从偏移量 offset 开始。
-func SetRange(key string, offset int64, value interface{}) error {
-	str, err := encode(value)
+func (c *Client) SetRange(ctx context.Context, key string, offset int64, value interface{}) error {
+	str, err := c.encode(value)
 	if err != nil {
 		return err
 	}
-	cmd := client.SetRange(ctx, associate(key), offset, str)
-	return cmd.Err()
+	fullKey := c.Key(key)
+	cmd := c.rdb.SetRange(ctx, fullKey, offset, str)
+	return c.changed(ctx, cmd.Err(), fullKey)
 }
 
 // Strlen 11	STRLEN key 返回 key 所储存的字符串值的长度。
-func Strlen(key string) (int64, error) {
-	cmd := client.StrLen(ctx, associate(key))
+func (c *Client) Strlen(ctx context.Context, key string) (int64, error) {
+	cmd := c.rdb.StrLen(ctx, c.Key(key))
 	return cmd.Val(), cmd.Err()
 }
 
 // MSet 12	MSET key value [key value ...] 同时设置一个或多个 key-value 对。
-func MSet(keysAndValues map[string]interface{}) error {
-	var args []interface{}
-	for key, value := range keysAndValues {
-		str, err := encode(value)
-		if err != nil {
-			return err
-		}
+func (c *Client) MSet(ctx context.Context, keysAndValues map[string]interface{}) error {
+	args, err := c.pairs(keysAndValues)
+	if err != nil {
+		return err
+	}
+	if c.cluster {
+		err = c.msetBySlot(ctx, args)
+	} else {
+		err = c.rdb.MSet(ctx, args...).Err()
+	}
+	return c.changed(ctx, err, pairKeys(args)...)
+}
 
-		args = append(args, associate(key), str)
+// MSetnx 13	MSETNX key value [key value ...] 同时设置一个或多个 key-value 对，当且仅当所有给定 key 都不存在。
+func (c *Client) MSetnx(ctx context.Context, keysAndValues map[string]interface{}) (bool, error) {
+	args, err := c.pairs(keysAndValues)
+	if err != nil {
+		return false, err
+	}
+	// MSETNX 需要原子执行，集群模式下不拆分，要求所有KEY在同一个哈希槽
+	fullKeys := pairKeys(args)
+	if err = c.sameSlot(fullKeys...); err != nil {
+		return false, err
 	}
+	cmd := c.rdb.MSetNX(ctx, args...)
+	if !cmd.Val() {
+		return false, cmd.Err()
+	}
+	return true, c.changed(ctx, cmd.Err(), fullKeys...)
+}
 
-	return client.MSet(ctx, args...).Err()
+// pairKeys 返回 pairs 结果中的完整KEY
+func pairKeys(args []interface{}) []string {
+	fullKeys := make([]string, 0, len(args)/2)
+	for i := 0; i < len(args); i += 2 {
+		fullKeys = append(fullKeys, args[i].(string))
+	}
+	return fullKeys
 }
 
-// MSetnx 13	MSETNX key value [key value ...] 同时设置一个或多个 key-value 对，当且仅当所有给定 key 都不存在。
-func MSetnx(keysAndValues map[string]interface{}) (bool, error) {
+func (c *Client) pairs(keysAndValues map[string]interface{}) ([]interface{}, error) {
 	var args []interface{}
 	for key, value := range keysAndValues {
-		str, err := encode(value)
+		str, err := c.encode(value)
 		if err != nil {
-			return false, err
+			return nil, err
 		}
-		args = append(args, associate(key), str)
+		args = append(args, c.Key(key), str)
 	}
-	cmd := client.MSetNX(ctx, args...)
-	return cmd.Val(), cmd.Err()
+	return args, nil
 }
 
 // PSetEX 14	PSETEX key milliseconds value 这个命令和 SETEX 命令相似，但它以毫秒为单位设置 key 的生存时间，而不是像 SETEX 命令那样，以秒为单位。
-func PSetEX(key string, val interface{}, timeout int64) error {
-	str, err := encode(val)
+func (c *Client) PSetEX(ctx context.Context, key string, val interface{}, timeout int64) error {
+	str, err := c.encode(val)
 	if err != nil {
 		return err
 	}
-	cmd := client.Set(ctx, associate(key), str, time.Duration(timeout)*time.Millisecond)
-	return cmd.Err()
+	fullKey := c.Key(key)
+	cmd := c.rdb.Set(ctx, fullKey, str, time.Duration(timeout)*time.Millisecond)
+	return c.changed(ctx, cmd.Err(), fullKey)
+}
+
+// Incr 15	INCR key 将 key 中储存的数字值增一。
+func (c *Client) Incr(ctx context.Context, key string) (int64, error) {
+	fullKey := c.Key(key)
+	cmd := c.rdb.Incr(ctx, fullKey)
+	return cmd.Val(), c.changed(ctx, cmd.Err(), fullKey)
+}
+
+// IncrBy 16	INCRBY key increment 将 key 所储存的值加上给定的增量值（increment） 。
+func (c *Client) IncrBy(ctx context.Context, key string, val int64) (int64, error) {
+	fullKey := c.Key(key)
+	cmd := c.rdb.IncrBy(ctx, fullKey, val)
+	return cmd.Val(), c.changed(ctx, cmd.Err(), fullKey)
+}
+
+// IncrByFloat 17	INCRBYFLOAT key increment 将 key 所储存的值加上给定的浮点增量值（increment） 。
+func (c *Client) IncrByFloat(ctx context.Context, key string, val float64) (float64, error) {
+	fullKey := c.Key(key)
+	cmd := c.rdb.IncrByFloat(ctx, fullKey, val)
+	return cmd.Val(), c.changed(ctx, cmd.Err(), fullKey)
+}
+
+// Decr 18	DECR key 将 key 中储存的数字值减一。
+func (c *Client) Decr(ctx context.Context, key string) (int64, error) {
+	fullKey := c.Key(key)
+	cmd := c.rdb.Decr(ctx, fullKey)
+	return cmd.Val(), c.changed(ctx, cmd.Err(), fullKey)
+}
+
+// DecrBy 19	DECRBY key decrement key 所储存的值减去给定的减量值（decrement） 。
+func (c *Client) DecrBy(ctx context.Context, key string, val int64) (int64, error) {
+	fullKey := c.Key(key)
+	cmd := c.rdb.DecrBy(ctx, fullKey, val)
+	return cmd.Val(), c.changed(ctx, cmd.Err(), fullKey)
+}
+
+// Append 20	APPEND key value 如果 key 已经存在并且是一个字符串， APPEND 命令将指定的 value 追加到该 key 原来值（value）的末尾。
+func (c *Client) Append(ctx context.Context, key string, val string) (int64, error) {
+	fullKey := c.Key(key)
+	cmd := c.rdb.Append(ctx, fullKey, val)
+	return cmd.Val(), c.changed(ctx, cmd.Err(), fullKey)
+}
+
+// 以下包级函数委托给默认实例
+
+// Set 1	SET key value 设置指定 key 的值。
+func Set(key string, val interface{}, timeout int64) error {
+	return defaultClient.Set(ctx, key, val, timeout)
+}
+
+func SetForNoPrefix(key string, val interface{}, dur int64) error {
+	return defaultClient.SetForNoPrefix(ctx, key, val, dur)
+}
+
+// Get 2	GET key 获取指定 key 的值。
+func Get[T any](key string) (t T, err error) {
+	return As[T](defaultClient).Get(ctx, key)
+}
+
+func GetForNoPrefix[T any](key string) (t T, err error) {
+	return As[T](defaultClient).GetForNoPrefix(ctx, key)
+}
+
+// GetRange 3	GETRANGE key start end 返回 key 中字符串值的子字符
+func GetRange(key string, start, end int64) (string, error) {
+	return defaultClient.GetRange(ctx, key, start, end)
+}
+
+// GetSet 4	GETSET key value 将给定 key 的值设为 value ，并返回 key 的旧值(old value)。
+func GetSet(key string, val interface{}) (string, error) {
+	return defaultClient.GetSet(ctx, key, val)
+}
+
+// GetBit 5	GETBIT key offset 对 key 所储存的字符串值，获取指定偏移量上的位(bit)。
+func GetBit(key string, offset int64) (int64, error) {
+	return defaultClient.GetBit(ctx, key, offset)
+}
+
+// MGet 6	MGET key1 [key2..] 获取所有(一个或多个)给定 key 的值。
+func MGet[T any](keys ...string) (res []T, err error) {
+	return As[T](defaultClient).MGet(ctx, keys...)
+}
+
+// SetBit 7	SETBIT key offset value 对 key 所储存的字符串值，设置或清除指定偏移量上的位(bit)。
+func SetBit(key string, offset int64, value int) (int64, error) {
+	return defaultClient.SetBit(ctx, key, offset, value)
+}
+
+// SetEX 8	SETEX key seconds value 将值 value 关联到 key ，并将 key 的过期时间设为 seconds (以秒为单位)。
+func SetEX(key string, val interface{}, timeout int64) error {
+	return defaultClient.SetEX(ctx, key, val, timeout)
+}
+
+// Setnx 9	SETNX key value 只有在 key 不存在时设置 key 的值。
+func Setnx(key string, val interface{}) (bool, error) {
+	return defaultClient.Setnx(ctx, key, val)
+}
+
+// SetnxExpire SETNX WITH EXPIRE (Second)
+func SetnxExpire(key string, val interface{}, expire int64) (bool, error) {
+	return defaultClient.SetnxExpire(ctx, key, val, expire)
+}
+
+// SetRange 10	SETRANGE key offset value 用 value 参数覆写给定 key 所储存的字符串值，从偏移量 offset 开始。
+func SetRange(key string, offset int64, value interface{}) error {
+	return defaultClient.SetRange(ctx, key, offset, value)
+}
+
+// Strlen 11	STRLEN key 返回 key 所储存的字符串值的长度。
+func Strlen(key string) (int64, error) {
+	return defaultClient.Strlen(ctx, key)
+}
+
+// MSet 12	MSET key value [key value ...] 同时设置一个或多个 key-value 对。
+func MSet(keysAndValues map[string]interface{}) error {
+	return defaultClient.MSet(ctx, keysAndValues)
+}
+
+// MSetnx 13	MSETNX key value [key value ...] 同时设置一个或多个 key-value 对，当且仅当所有给定 key 都不存在。
+func MSetnx(keysAndValues map[string]interface{}) (bool, error) {
+	return defaultClient.MSetnx(ctx, keysAndValues)
+}
+
+// PSetEX 14	PSETEX key milliseconds value 这个命令和 SETEX 命令相似，但它以毫秒为单位设置 key 的生存时间，而不是像 SETEX 命令那样，以秒为单位。
+func PSetEX(key string, val interface{}, timeout int64) error {
+	return defaultClient.PSetEX(ctx, key, val, timeout)
 }
 
 // Incr 15	INCR key 将 key 中储存的数字值增一。
 func Incr(key string) (int64, error) {
-	cmd := client.Incr(ctx, associate(key))
-	return cmd.Val(), cmd.Err()
+	return defaultClient.Incr(ctx, key)
 }
 
 // IncrBy 16	INCRBY key increment 将 key 所储存的值加上给定的增量值（increment） 。
 func IncrBy(key string, val int64) (int64, error) {
-	cmd := client.IncrBy(ctx, associate(key), val)
-	return cmd.Val(), cmd.Err()
+	return defaultClient.IncrBy(ctx, key, val)
 }
 
 // IncrByFloat 17	INCRBYFLOAT key increment 将 key 所储存的值加上给定的浮点增量值（increment） 。
 func IncrByFloat(key string, val float64) (float64, error) {
-	cmd := client.IncrByFloat(ctx, associate(key), val)
-	return cmd.Val(), cmd.Err()
+	return defaultClient.IncrByFloat(ctx, key, val)
 }
 
 // Decr 18	DECR key 将 key 中储存的数字值减一。
 func Decr(key string) (int64, error) {
-	cmd := client.Decr(ctx, associate(key))
-	return cmd.Val(), cmd.Err()
+	return defaultClient.Decr(ctx, key)
 }
 
 // DecrBy 19	DECRBY key decrement key 所储存的值减去给定的减量值（decrement） 。
 func DecrBy(key string, val int64) (int64, error) {
-	cmd := client.DecrBy(ctx, associate(key), val)
-	return cmd.Val(), cmd.Err()
+	return defaultClient.DecrBy(ctx, key, val)
 }
 
 // Append 20	APPEND key value 如果 key 已经存在并且是一个字符串， APPEND 命令将指定的 value 追加到该 key 原来值（value）的末尾。
 func Append(key string, val string) (int64, error) {
-	cmd := client.Append(ctx, associate(key), val)
-	return cmd.Val(), cmd.Err()
+	return defaultClient.Append(ctx, key, val)
 }
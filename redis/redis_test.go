@@ -2,15 +2,24 @@ package redis
 
 import (
 	"fmt"
+	"os"
 	"strconv"
 	"testing"
 	"time"
+
+	"github.com/XingMenTech/common/redis/redistest"
 )
 
+// testAddr 测试使用的redis地址，设置 REDIS_ADDR 时连接该地址，否则使用进程内服务
+var testAddr = os.Getenv("REDIS_ADDR")
+
 func init() {
+	if "" == testAddr {
+		testAddr = redistest.MustStart().Addr()
+	}
 	err := InitRedisCache(&Config{
 		Prefix:   "aaaa",
-		Host:     "127.0.0.1:6379",
+		Host:     testAddr,
 		Password: "",
 		DbNum:    strconv.Itoa(0),
 	})
@@ -115,12 +124,14 @@ func TestHGetAll(t *testing.T) {
 }
 
 func TestHSet(t *testing.T) {
-	interval := time.Tick(time.Second * 30)
-	for range interval {
-
-		fmt.Println(time.Now().Unix())
+	interval := time.NewTicker(10 * time.Millisecond)
+	defer interval.Stop()
+	for i := 0; i < 3; i++ {
+		<-interval.C
+		if err := HSet("test_users", "tick", time.Now().Unix()); err != nil {
+			t.Fatal(err)
+		}
 	}
-
 }
 
 func TestList(t *testing.T) {
@@ -263,54 +274,57 @@ const (
 )
 
 func TestBLPop(t *testing.T) {
-	for {
-		k, v, err := BLPop[args](0, listKey, listKey2)
+	Delete(listKey)
+	Delete(listKey2)
+	if err := RPush(listKey2, &args{Name: "k2_name_0"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := RPush(listKey, &args{Name: "name_0"}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		k, v, err := BLPop[args](1, listKey, listKey2)
 		if err != nil {
-			t.Error(err)
-			return
+			t.Fatal(err)
 		}
 		t.Log(k, "====>", v)
 	}
+	if _, _, err := BLPop[args](1, listKey, listKey2); err != Nil {
+		t.Errorf("expect Nil on empty lists, got %v", err)
+	}
 }
 
 func TestRPush(t *testing.T) {
-
-	index := 0
-	for {
-
+	Delete(listKey)
+	for index := 0; index < 10; index++ {
 		val := &args{
 			Name:  fmt.Sprintf("name_%d", index),
 			Age:   20,
 			Phone: fmt.Sprintf("phone_%d", index),
 		}
-
-		err := RPush(listKey, val)
-		if err != nil {
-			t.Error(err)
-			return
+		if err := RPush(listKey, val); err != nil {
+			t.Fatal(err)
 		}
-		time.Sleep(10 * time.Second)
-		index++
+	}
+	if n, err := LLen(listKey); err != nil || n != 10 {
+		t.Errorf("LLen = %d, %v", n, err)
 	}
 }
 
 func TestRPush2(t *testing.T) {
-
-	index := 0
-	for {
-
+	Delete(listKey2)
+	for index := 0; index < 5; index++ {
 		val := &args{
 			Name:  fmt.Sprintf("k2_name_%d", index),
 			Age:   20,
 			Phone: fmt.Sprintf("k2_phone_%d", index),
 		}
-
-		err := RPush(listKey2, val)
-		if err != nil {
-			t.Error(err)
-			return
+		if err := RPush(listKey2, val); err != nil {
+			t.Fatal(err)
 		}
-		time.Sleep(5 * time.Second)
-		index++
+	}
+	res, err := LRange[args](listKey2, 0, -1)
+	if err != nil || len(res) != 5 || res[4].Name != "k2_name_4" {
+		t.Errorf("LRange = %v, %v", res, err)
 	}
 }
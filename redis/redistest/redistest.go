@@ -0,0 +1,48 @@
+// Package redistest 进程内的redis服务，用于测试
+// 基于 miniredis 实现 RESP 协议，支持字符串、哈希、列表、集合、有序集合、发布订阅、过期、Stream 与 Lua 脚本
+// 过期时间不随真实时间流逝，需要通过 FastForward 推进
+// 示例
+//
+//	srv := redistest.Run(t)
+//	redis.InitRedisCache(&redis.Config{Prefix: "test", Host: srv.Addr()})
+package redistest
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// Server 进程内redis服务，可通过内嵌的 Miniredis 直接读写数据或调用 FastForward 推进时间
+type Server struct {
+	*miniredis.Miniredis
+}
+
+// Start 在随机端口启动服务，使用完毕后调用 Close
+func Start() (*Server, error) {
+	m, err := miniredis.Run()
+	if err != nil {
+		return nil, err
+	}
+	return &Server{Miniredis: m}, nil
+}
+
+// Run 启动服务并在测试结束时关闭，启动失败时终止测试
+func Run(tb testing.TB) *Server {
+	tb.Helper()
+	s, err := Start()
+	if err != nil {
+		tb.Fatalf("redistest: start server: %v", err)
+	}
+	tb.Cleanup(s.Close)
+	return s
+}
+
+// MustStart 启动服务，失败时 panic，用于 TestMain 或 init
+func MustStart() *Server {
+	s, err := Start()
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
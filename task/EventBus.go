@@ -19,15 +19,21 @@
 // singleton.eventBus.Register(reflect.TypeOf(&PingEvent{}), &PingEventHandlerV1{})
 // 通知事件
 // singleton.eventBus.Notify(reflect.TypeOf(&PingEvent{}), &PingEvent{Text: "ping"})
+// 泛型注册与通知
+// sub := task.Subscribe(singleton.eventBus, func(v *PingEvent) error { return nil })
+// task.Publish(singleton.eventBus, &PingEvent{Text: "ping"})
+// sub.Unsubscribe()
 
 package task
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/XingMenTech/common/logger"
 	"github.com/sirupsen/logrus"
 	"os"
+	"reflect"
 	"runtime/debug"
 	"sync"
 	"sync/atomic"
@@ -43,15 +49,29 @@ var (
 	ebOnce   sync.Once
 )
 
+var (
+	// ErrListenerPanic is reported when a listener panics
+	ErrListenerPanic = errors.New("event bus: listener panic")
+)
+
 type EventBeforeNotifyFilter func(eventType, event interface{}) bool
 
+// EventErrorHandler /监听器错误处理，异步通知时监听器返回的错误与panic由此上报
+type EventErrorHandler func(event, param interface{}, err error)
+
 // Notifiable /可通知接口
 type Notifiable interface {
 	// Notify /通知
 	Notify(param interface{})
 }
 
+// errorNotifiable /可返回错误的监听器
+type errorNotifiable interface {
+	notifyWithError(param interface{}) error
+}
+
 type NotifyParam struct {
+	Event           interface{}
 	NotifiableArray []Notifiable
 	Param           interface{}
 }
@@ -68,31 +88,65 @@ type EventBus struct {
 	notifyCh                 chan *NotifyParam
 	eventGroup               map[interface{}][]Notifiable
 	eventBeforeNotifyFilters []EventBeforeNotifyFilter
+	errorHandler             EventErrorHandler
+	//顺序投递的事件，每个事件一个通道由单协程消费
+	orderedLanes map[interface{}]*orderedLane
+	lastSubID    uint64
+
+	opts        *eventBusOptions
+	spill       *RingBuffer
+	spillSignal chan struct{}
+	spilled     uint64
+	dropped     uint64
 }
 
-// NewEventBus /工厂方法
-func NewEventBus() *EventBus {
+// orderedLane /顺序投递通道，创建后直到总线停止才退出
+type orderedLane struct {
+	ch      chan *NotifyParam
+	enabled bool
+}
+
+// NewEventBus /工厂方法，配置项仅在首次调用时生效
+func NewEventBus(opts ...EventBusOption) *EventBus {
 	ebOnce.Do(func() {
-		object := &EventBus{
-			exitFlag:   0,
-			log:        logger.LOG.WithField("module", "EventBus"),
-			wg:         &sync.WaitGroup{},
-			notifyCh:   make(chan *NotifyParam, NotifyChanMaxSize),
-			eventGroup: make(map[interface{}][]Notifiable),
-		}
-		object.ctx, object.cancel = context.WithCancel(context.Background())
-		eventBus = object
+		eventBus = newEventBus(opts...)
 	})
 	return eventBus
 }
 
+func newEventBus(opts ...EventBusOption) *EventBus {
+	options := defaultEventBusOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+	object := &EventBus{
+		exitFlag:     0,
+		log:          logger.LOG.WithField("module", "EventBus"),
+		wg:           &sync.WaitGroup{},
+		notifyCh:     make(chan *NotifyParam, options.chanSize),
+		eventGroup:   make(map[interface{}][]Notifiable),
+		orderedLanes: make(map[interface{}]*orderedLane),
+		opts:         options,
+	}
+	if OverflowSpill == options.policy {
+		object.spill = NewRingBuffer(options.spillSize)
+		object.spillSignal = make(chan struct{}, 1)
+	}
+	object.errorHandler = object.logError
+	object.ctx, object.cancel = context.WithCancel(context.Background())
+	return object
+}
+
 // Start /启动
 func (object *EventBus) Start() {
-	for i := 0; i < EventBusWorkerSize; i++ {
-		NewRoutinePool().PostTask(func(params []interface{}) interface{} {
-			object.wait()
-			return nil
-		})
+	//先计数再投递，避免与stop中的Wait并发
+	object.wg.Add(object.opts.workers)
+	for i := 0; i < object.opts.workers; i++ {
+		object.postWorker()
+	}
+	if nil != object.spill {
+		object.wg.Add(1)
+		go object.drainSpill()
 	}
 }
 
@@ -111,6 +165,63 @@ func (object *EventBus) Register(event interface{}, notifiable Notifiable) *Even
 	return object
 }
 
+// Unregister 取消事件注册
+func (object *EventBus) Unregister(event interface{}, notifiable Notifiable) *EventBus {
+	object.Lock()
+	object.unregisterLocked(event, func(n Notifiable) bool {
+		return reflect.TypeOf(n).Comparable() && n == notifiable
+	})
+	object.Unlock()
+	return object
+}
+
+func (object *EventBus) unregisterLocked(event interface{}, match func(Notifiable) bool) {
+	v, ok := object.eventGroup[event]
+	if !ok {
+		return
+	}
+	//写时复制，已发出的通知不受影响
+	notifiableArray := make([]Notifiable, 0, len(v))
+	for _, n := range v {
+		if !match(n) {
+			notifiableArray = append(notifiableArray, n)
+		}
+	}
+	if 0 == len(notifiableArray) {
+		delete(object.eventGroup, event)
+	} else {
+		object.eventGroup[event] = notifiableArray
+	}
+}
+
+// SetErrorHandler /设置监听器错误处理，默认记录日志
+func (object *EventBus) SetErrorHandler(handler EventErrorHandler) {
+	object.Lock()
+	if nil == handler {
+		handler = object.logError
+	}
+	object.errorHandler = handler
+	object.Unlock()
+}
+
+// SetOrderedDelivery /设置事件是否按通知顺序投递
+// 开启后该事件的异步通知由单独协程依次处理，不再由工作协程并发处理
+func (object *EventBus) SetOrderedDelivery(event interface{}, ordered bool) {
+	object.Lock()
+	defer object.Unlock()
+	lane, ok := object.orderedLanes[event]
+	if !ok {
+		if !ordered {
+			return
+		}
+		lane = &orderedLane{ch: make(chan *NotifyParam, object.opts.chanSize)}
+		object.orderedLanes[event] = lane
+		object.wg.Add(1)
+		go object.laneLoop(lane.ch)
+	}
+	lane.enabled = ordered
+}
+
 // /安装发送消息过滤器
 func (object *EventBus) InstallBeforeNotifyFilter(filter EventBeforeNotifyFilter) {
 	object.Lock()
@@ -125,60 +236,120 @@ func (object *EventBus) Notify(event, param interface{}) *EventBus {
 		return object
 	}
 
-	//前置过滤
+	notifiableArray := object.listeners(event, param)
+	if nil != notifiableArray && 0 != len(notifiableArray) {
+		object.notify(event, notifiableArray, param)
+	}
+	return object
+}
+
+// /异步通知除except以外的监听器
+func (object *EventBus) notifyExcept(event, param interface{}, except Notifiable) {
+	if 0 != atomic.LoadInt32(&object.exitFlag) {
+		fmt.Fprintf(os.Stderr, "lost notify message: (%v,%v)", event, param)
+		return
+	}
+
+	notifiableArray := object.listeners(event, param)
+	for i := 0; i < len(notifiableArray); i++ {
+		if notifiableArray[i] == except {
+			notifiableArray = append(notifiableArray[:i], notifiableArray[i+1:]...)
+			i--
+		}
+	}
+	if 0 != len(notifiableArray) {
+		object.notify(event, notifiableArray, param)
+	}
+}
+
+// /经前置过滤后取得监听器副本，被过滤时返回nil
+func (object *EventBus) listeners(event, param interface{}) []Notifiable {
 	object.RLock()
+	defer object.RUnlock()
+
+	//前置过滤
 	for _, filter := range object.eventBeforeNotifyFilters {
 		if !filter(event, param) {
-			object.RUnlock()
-			return object
+			return nil
 		}
 	}
-	object.RUnlock()
 
 	var notifiableArray []Notifiable
-	object.RLock()
 	if v, ok := object.eventGroup[event]; ok {
 		notifiableArray = make([]Notifiable, len(v))
 		copy(notifiableArray, v)
 	}
-	object.RUnlock()
-	if nil != notifiableArray && 0 != len(notifiableArray) {
-		object.notify(notifiableArray, param)
+	return notifiableArray
+}
+
+// SyncNotify /事件同步通知，监听器错误交由错误处理器
+func (object *EventBus) SyncNotify(event, param interface{}) *EventBus {
+	if err := object.syncNotify(event, param); nil != err {
+		object.reportError(event, param, err)
 	}
 	return object
 }
 
-// SyncNotify /事件同步通知
-func (object *EventBus) SyncNotify(event, param interface{}) *EventBus {
+// /同步通知，返回所有监听器的错误
+func (object *EventBus) syncNotify(event, param interface{}) error {
 	if 0 != atomic.LoadInt32(&object.exitFlag) {
 		fmt.Fprintf(os.Stderr, "lost notify message: (%v,%v)", event, param)
-		return object
+		return nil
 	}
 
-	//前置过滤
-	object.RLock()
-	for _, filter := range object.eventBeforeNotifyFilters {
-		if !filter(event, param) {
-			object.RUnlock()
-			return object
+	var errs []error
+	for _, notifiable := range object.listeners(event, param) {
+		if err := object.dispatch(notifiable, param); nil != err {
+			errs = append(errs, err)
 		}
 	}
-	object.RUnlock()
+	return errors.Join(errs...)
+}
 
-	var notifiableArray []Notifiable
-	object.RLock()
-	if v, ok := object.eventGroup[event]; ok {
-		notifiableArray = make([]Notifiable, len(v))
-		copy(notifiableArray, v)
+// /调用单个监听器，隔离panic
+func (object *EventBus) dispatch(notifiable Notifiable, param interface{}) (err error) {
+	defer func() {
+		if r := recover(); nil != r {
+			err = fmt.Errorf("%w: %v\n%s", ErrListenerPanic, r, debug.Stack())
+		}
+	}()
+	if n, ok := notifiable.(errorNotifiable); ok {
+		return n.notifyWithError(param)
 	}
-	object.RUnlock()
-	if nil != notifiableArray && 0 != len(notifiableArray) {
-		for _, notifiable := range notifiableArray {
-			notifiable.Notify(param)
+	notifiable.Notify(param)
+	return nil
+}
+
+// /异步投递给所有监听器，逐个上报错误
+func (object *EventBus) deliver(notifyParam *NotifyParam) {
+	for _, notifiable := range notifyParam.NotifiableArray {
+		if err := object.dispatch(notifiable, notifyParam.Param); nil != err {
+			object.reportError(notifyParam.Event, notifyParam.Param, err)
 		}
 	}
+}
 
-	return object
+func (object *EventBus) reportError(event, param interface{}, err error) {
+	object.RLock()
+	handler := object.errorHandler
+	object.RUnlock()
+	handler(event, param, err)
+}
+
+func (object *EventBus) logError(event, param interface{}, err error) {
+	object.log.Errorf("notify event %v failed: %v", event, err)
+}
+
+// /在协程池中运行事件循环，计数已由调用方增加，提交失败时释放计数，避免 stop 一直等待
+func (object *EventBus) postWorker() {
+	_, err := NewRoutinePool().PostTask(func(params []interface{}) interface{} {
+		object.wait()
+		return nil
+	})
+	if err != nil {
+		object.wg.Done()
+		object.log.Errorf("start event worker failed: %v", err)
+	}
 }
 
 // /等待事件
@@ -187,42 +358,60 @@ func (object *EventBus) wait() {
 		if r := recover(); nil != r {
 			//打印调用栈
 			debug.PrintStack()
-			//退还资源
-			object.wg.Done()
-			//事件循环非正常退出
-			NewRoutinePool().PostTask(func(params []interface{}) interface{} {
-				object.wait()
-				return nil
-			})
+			//事件循环非正常退出，计数由重启的循环继承
+			object.postWorker()
 		}
 	}()
-	object.wg.Add(1)
 loop:
 	for {
 		select {
 		case <-object.ctx.Done():
 			break loop
 		case notifyParam := <-object.notifyCh:
-			for _, notifiable := range notifyParam.NotifiableArray {
-				notifiable.Notify(notifyParam.Param)
-			}
+			object.deliver(notifyParam)
 		}
 	}
 	for 0 != len(object.notifyCh) {
 		notifyParam := <-object.notifyCh
-		for _, notifiable := range notifyParam.NotifiableArray {
-			notifiable.Notify(notifyParam.Param)
-		}
+		object.deliver(notifyParam)
 	}
 	object.wg.Done()
 }
 
+// /顺序投递协程
+func (object *EventBus) laneLoop(lane chan *NotifyParam) {
+	defer object.wg.Done()
+	for {
+		select {
+		case <-object.ctx.Done():
+			for 0 != len(lane) {
+				object.deliver(<-lane)
+			}
+			return
+		case notifyParam := <-lane:
+			object.deliver(notifyParam)
+		}
+	}
+}
+
 // /通知事件
-func (object *EventBus) notify(notifiableArray []Notifiable, param interface{}) {
-	object.notifyCh <- &NotifyParam{
+func (object *EventBus) notify(event interface{}, notifiableArray []Notifiable, param interface{}) {
+	notifyParam := &NotifyParam{
+		Event:           event,
 		NotifiableArray: notifiableArray,
 		Param:           param,
 	}
+
+	object.RLock()
+	lane, ok := object.orderedLanes[event]
+	ordered := ok && lane.enabled
+	object.RUnlock()
+
+	if ordered {
+		object.offer(lane.ch, notifyParam, false)
+		return
+	}
+	object.offer(object.notifyCh, notifyParam, true)
 }
 
 // /停止
@@ -232,9 +421,11 @@ func (object *EventBus) stop() {
 	object.wg.Wait()
 	for 0 != len(object.notifyCh) {
 		notifyParam := <-object.notifyCh
-		for _, notifiable := range notifyParam.NotifiableArray {
-			notifiable.Notify(notifyParam.Param)
-		}
+		object.deliver(notifyParam)
+	}
+	for nil != object.spill && 0 != object.spill.Len() {
+		item, _ := object.spill.Get()
+		object.deliver(item.(*NotifyParam))
 	}
 	close(object.notifyCh)
 	object.log.Infof("event bus stopped")
@@ -5,8 +5,15 @@ package task
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime/debug"
 	"sync"
 	"sync/atomic"
+	"time"
+
+	"github.com/XingMenTech/common/logger"
 )
 
 var (
@@ -15,7 +22,19 @@ var (
 )
 
 const (
-	DefaultPoolSize = 128
+	DefaultPoolSize        = 128
+	DefaultMaxPoolSize     = 4096
+	DefaultPoolQueueSize   = 16
+	DefaultPoolIdleTimeout = time.Minute
+)
+
+var (
+	// ErrPoolOverload 协程与等待队列均已满，按 RejectAbort 拒绝任务
+	ErrPoolOverload = errors.New("routine pool: overload")
+	// ErrPoolClosed 协程池已关闭
+	ErrPoolClosed = errors.New("routine pool: closed")
+	// ErrTaskPanic 任务执行时 panic
+	ErrTaskPanic = errors.New("routine pool: task panic")
 )
 
 // TaskMethod /任务方法
@@ -27,159 +46,295 @@ type TaskParam struct {
 	TaskParam  []interface{}
 }
 
-// Context /协程上下文
-type Context struct {
-	sync.RWMutex
-	idleFlag        int32
-	ctx             context.Context
-	cancel          context.CancelFunc
-	taskCh          chan *TaskParam
-	recycleNotifyCh chan interface{}
-	wg              *sync.WaitGroup
-	parentWG        *sync.WaitGroup
+// RejectPolicy /协程与等待队列均已满时的处理策略
+type RejectPolicy int
+
+const (
+	// RejectBlock 阻塞等待队列空闲
+	RejectBlock RejectPolicy = iota
+	// RejectAbort 返回 ErrPoolOverload
+	RejectAbort
+	// RejectCallerRuns 在调用方协程中执行
+	RejectCallerRuns
+)
+
+// RoutinePoolOption /协程池配置项
+type RoutinePoolOption func(*routinePoolOptions)
+
+type routinePoolOptions struct {
+	minWorkers   int
+	maxWorkers   int
+	queueSize    int
+	idleTimeout  time.Duration
+	reject       RejectPolicy
+	panicHandler func(r interface{}, stack []byte)
 }
 
-// /协程方法
-func (object *Context) loop() {
+// WithMinWorkers /常驻协程数，空闲超时后不回收，默认 DefaultPoolSize
+func WithMinWorkers(n int) RoutinePoolOption {
+	return func(opts *routinePoolOptions) {
+		if n >= 0 {
+			opts.minWorkers = n
+		}
+	}
+}
 
-	object.wg.Add(1)
-loop:
-	for {
-		select {
-		case <-object.ctx.Done():
-			break loop
-		case taskParam, ok := <-object.taskCh:
-			if !ok {
-				continue
-			}
-			object.parentWG.Add(1)
-			taskParam.TaskMethod(taskParam.TaskParam)
-			object.parentWG.Done()
-			atomic.StoreInt32(&object.idleFlag, 1)
-
-			object.RLock()
-			if nil != object.recycleNotifyCh &&
-				0 < cap(object.recycleNotifyCh) &&
-				len(object.recycleNotifyCh) < cap(object.recycleNotifyCh) {
-				object.recycleNotifyCh <- nil
-			}
-			object.RUnlock()
+// WithMaxWorkers /最大协程数，默认 DefaultMaxPoolSize
+func WithMaxWorkers(n int) RoutinePoolOption {
+	return func(opts *routinePoolOptions) {
+		if n > 0 {
+			opts.maxWorkers = n
+		}
+	}
+}
+
+// WithQueueSize /每个协程可排队的任务数，等待队列总长度为 maxWorkers*size，默认 DefaultPoolQueueSize
+func WithQueueSize(size int) RoutinePoolOption {
+	return func(opts *routinePoolOptions) {
+		if size >= 0 {
+			opts.queueSize = size
+		}
+	}
+}
+
+// WithIdleTimeout /超出常驻数的协程空闲多久后回收，默认 DefaultPoolIdleTimeout
+func WithIdleTimeout(timeout time.Duration) RoutinePoolOption {
+	return func(opts *routinePoolOptions) {
+		if timeout > 0 {
+			opts.idleTimeout = timeout
 		}
 	}
-	object.wg.Done()
+}
+
+// WithRejectPolicy /协程与等待队列均已满时的处理策略，默认 RejectBlock
+func WithRejectPolicy(policy RejectPolicy) RoutinePoolOption {
+	return func(opts *routinePoolOptions) {
+		opts.reject = policy
+	}
+}
+
+// WithPanicHandler /任务 panic 时的回调，默认记录日志及调用栈
+func WithPanicHandler(handler func(r interface{}, stack []byte)) RoutinePoolOption {
+	return func(opts *routinePoolOptions) {
+		opts.panicHandler = handler
+	}
+}
+
+// TaskFuture /任务结果
+type TaskFuture struct {
+	done   chan struct{}
+	result interface{}
+	err    error
+}
+
+// Done /任务完成时关闭
+func (object *TaskFuture) Done() <-chan struct{} {
+	return object.done
+}
+
+// Wait /等待任务完成，任务 panic 时返回 ErrTaskPanic，协程池关闭前未执行时返回 ErrPoolClosed
+func (object *TaskFuture) Wait() (interface{}, error) {
+	<-object.done
+	return object.result, object.err
+}
+
+// WaitContext /同 Wait，ctx 先结束时返回 ctx.Err()，不取消任务
+func (object *TaskFuture) WaitContext(ctx context.Context) (interface{}, error) {
+	select {
+	case <-object.done:
+		return object.result, object.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (object *TaskFuture) complete(result interface{}, err error) {
+	object.result, object.err = result, err
+	close(object.done)
+}
+
+// poolTask /排队中的任务
+type poolTask struct {
+	param  TaskParam
+	future *TaskFuture
 }
 
 // RoutinePool /协程池
 type RoutinePool struct {
-	sync.RWMutex
-	minRoutine      int64
-	contextPool     []*Context
-	recycleNotifyCh chan interface{}
-	ctx             context.Context
-	cancel          context.CancelFunc
-	wg              *sync.WaitGroup
-	priority        int
+	sync.Mutex
+	opts     *routinePoolOptions
+	tasks    chan *poolTask
+	running  int
+	idle     int32
+	stopped  bool
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       *sync.WaitGroup
+	priority int
 }
 
-// NewRoutinePool /工厂方法
+// NewRoutinePool /默认协程池，进程内单例
 func NewRoutinePool() *RoutinePool {
 	rpOnce.Do(func() {
-		object := &RoutinePool{
-			minRoutine:      DefaultPoolSize,
-			recycleNotifyCh: make(chan interface{}, 2*DefaultPoolSize),
-			wg:              &sync.WaitGroup{},
-			priority:        2,
-		}
-		object.ctx, object.cancel = context.WithCancel(context.Background())
-		object.contextPool = make([]*Context, DefaultPoolSize)
-		for i := int64(0); i < DefaultPoolSize; i++ {
-			ctx := &Context{
-				idleFlag:        1,
-				taskCh:          make(chan *TaskParam, 16),
-				recycleNotifyCh: object.recycleNotifyCh,
-				parentWG:        object.wg,
-				wg:              &sync.WaitGroup{},
-			}
-			ctx.ctx, ctx.cancel = context.WithCancel(context.Background())
-			object.contextPool[i] = ctx
-			go ctx.loop()
-		}
-		go object.recycleRouting()
-		routinePool = object
+		routinePool = NewRoutinePoolWithOptions()
 	})
 	return routinePool
 }
 
-// /回收上下文
-func (object *RoutinePool) recycleContext(ctx *Context) {
-	ctx.cancel()
-	ctx.wg.Wait()
+// NewRoutinePoolWithOptions /工厂方法，创建独立的协程池
+func NewRoutinePoolWithOptions(opts ...RoutinePoolOption) *RoutinePool {
+	o := &routinePoolOptions{
+		minWorkers:  DefaultPoolSize,
+		maxWorkers:  DefaultMaxPoolSize,
+		queueSize:   DefaultPoolQueueSize,
+		idleTimeout: DefaultPoolIdleTimeout,
+		reject:      RejectBlock,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.minWorkers > o.maxWorkers {
+		o.minWorkers = o.maxWorkers
+	}
+	if nil == o.panicHandler {
+		o.panicHandler = logTaskPanic
+	}
+
+	object := &RoutinePool{
+		opts:     o,
+		tasks:    make(chan *poolTask, o.maxWorkers*o.queueSize),
+		wg:       &sync.WaitGroup{},
+		priority: 2,
+	}
+	object.ctx, object.cancel = context.WithCancel(context.Background())
+	return object
+}
+
+// logTaskPanic /默认的 panic 回调，日志未初始化时输出到标准错误
+func logTaskPanic(r interface{}, stack []byte) {
+	if nil == logger.LOG {
+		fmt.Fprintf(os.Stderr, "routine pool: task panic: %v\n%s", r, stack)
+		return
+	}
+	logger.LOG.WithField("module", "RoutinePool").Errorf("task panic: %v\n%s", r, stack)
+}
+
+// PostTask /提交任务，返回的 TaskFuture 可用于等待 TaskMethod 的返回值
+// 协程与等待队列均已满时按 RejectPolicy 处理，协程池关闭后返回 ErrPoolClosed
+func (object *RoutinePool) PostTask(task TaskMethod, params ...interface{}) (*TaskFuture, error) {
+	t := &poolTask{
+		param:  TaskParam{TaskMethod: task, TaskParam: params},
+		future: &TaskFuture{done: make(chan struct{})},
+	}
+
+	object.Lock()
+	if object.stopped {
+		object.Unlock()
+		return nil, ErrPoolClosed
+	}
+	//没有空闲协程时新建，已入队的任务由空闲协程领取
+	if 0 == atomic.LoadInt32(&object.idle) && object.running < object.opts.maxWorkers {
+		object.running++
+		object.wg.Add(1)
+		object.Unlock()
+		go object.worker(t)
+		return t.future, nil
+	}
+	//持锁入队，避免与协程空闲回收并发导致任务无人领取
+	select {
+	case object.tasks <- t:
+		object.Unlock()
+		return t.future, nil
+	default:
+	}
+	object.Unlock()
 
-	ctx.Lock()
-	close(ctx.taskCh)
-	ctx.taskCh = nil
-	ctx.Unlock()
+	switch object.opts.reject {
+	case RejectAbort:
+		return nil, ErrPoolOverload
+	case RejectCallerRuns:
+		object.run(t)
+		return t.future, nil
+	default:
+		select {
+		case object.tasks <- t:
+			return t.future, nil
+		case <-object.ctx.Done():
+			return nil, ErrPoolClosed
+		}
+	}
 }
 
-// /检查空闲协程数量
-func (object *RoutinePool) recycleRouting() {
-	object.wg.Add(1)
-loop:
+// /协程方法
+func (object *RoutinePool) worker(first *poolTask) {
+	defer object.wg.Done()
+	if nil != first {
+		object.run(first)
+	}
+
+	timer := time.NewTimer(object.opts.idleTimeout)
+	defer timer.Stop()
 	for {
+		atomic.AddInt32(&object.idle, 1)
 		select {
-		case <-object.ctx.Done():
-			break loop
-		case <-object.recycleNotifyCh:
+		case t := <-object.tasks:
+			atomic.AddInt32(&object.idle, -1)
+			object.run(t)
+		case <-timer.C:
 			object.Lock()
-			if object.minRoutine < int64(len(object.contextPool)) {
-				for i := len(object.contextPool) - 1; i >= 0; i-- {
-					ctx := object.contextPool[i]
-					if 1 == atomic.LoadInt32(&ctx.idleFlag) {
-						object.recycleContext(ctx)
-						object.contextPool = append(object.contextPool[:i], object.contextPool[i+1:]...)
-					}
-				}
+			if object.running > object.opts.minWorkers && 0 == len(object.tasks) {
+				object.running--
+				atomic.AddInt32(&object.idle, -1)
+				object.Unlock()
+				return
 			}
 			object.Unlock()
+			atomic.AddInt32(&object.idle, -1)
+		case <-object.ctx.Done():
+			object.Lock()
+			object.running--
+			atomic.AddInt32(&object.idle, -1)
+			object.Unlock()
+			return
 		}
-	}
-	object.wg.Done()
-}
 
-// PostTask /提交任务
-func (object *RoutinePool) PostTask(task TaskMethod, params ...interface{}) {
-	object.Lock()
-	i := 0
-	for i < len(object.contextPool) {
-		ctx := object.contextPool[i]
-		if 1 == atomic.LoadInt32(&(ctx.idleFlag)) {
-			break
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
 		}
-		i++
+		timer.Reset(object.opts.idleTimeout)
 	}
-	if i < len(object.contextPool) {
-		ctx := object.contextPool[i]
-		atomic.StoreInt32(&(ctx.idleFlag), 0)
+}
 
-		ctx.RLock()
-		if nil != ctx.taskCh && 0 < cap(ctx.taskCh) {
-			ctx.taskCh <- &TaskParam{TaskMethod: task, TaskParam: params}
-		}
-		ctx.RUnlock()
-	} else {
-		ctx := &Context{
-			idleFlag:        0,
-			taskCh:          make(chan *TaskParam, 16),
-			recycleNotifyCh: object.recycleNotifyCh,
-			parentWG:        object.wg,
-			wg:              &sync.WaitGroup{},
+// /执行任务，panic 不影响所在协程
+func (object *RoutinePool) run(t *poolTask) {
+	defer func() {
+		if r := recover(); nil != r {
+			object.opts.panicHandler(r, debug.Stack())
+			t.future.complete(nil, fmt.Errorf("%w: %v", ErrTaskPanic, r))
 		}
-		ctx.ctx, ctx.cancel = context.WithCancel(context.Background())
-		object.contextPool = append(object.contextPool, ctx)
-		go ctx.loop()
-		ctx.taskCh <- &TaskParam{TaskMethod: task, TaskParam: params}
-	}
-	object.Unlock()
+	}()
+	result := t.param.TaskMethod(t.param.TaskParam)
+	t.future.complete(result, nil)
+}
+
+// Running /当前协程数
+func (object *RoutinePool) Running() int {
+	object.Lock()
+	defer object.Unlock()
+	return object.running
+}
+
+// Idle /空闲协程数
+func (object *RoutinePool) Idle() int {
+	return int(atomic.LoadInt32(&object.idle))
+}
+
+// Waiting /排队中的任务数
+func (object *RoutinePool) Waiting() int {
+	return len(object.tasks)
 }
 
 // Name /名字
@@ -197,19 +352,28 @@ func (object *RoutinePool) ShutdownPriority() int {
 	return object.priority
 }
 
-// BeforeShutdown /应用退出前
+// BeforeShutdown /应用退出前，等待执行中的任务完成，未执行的任务返回 ErrPoolClosed
 func (object *RoutinePool) BeforeShutdown() {
-	object.cancel()
-	object.wg.Wait()
 	object.Lock()
-	for _, ctx := range object.contextPool {
-		object.recycleContext(ctx)
+	if object.stopped {
+		object.Unlock()
+		return
 	}
-	object.contextPool = make([]*Context, 0)
-	close(object.recycleNotifyCh)
-	object.recycleNotifyCh = nil
+	object.stopped = true
 	object.Unlock()
+
+	object.cancel()
+	object.wg.Wait()
+	for {
+		select {
+		case t := <-object.tasks:
+			t.future.complete(nil, ErrPoolClosed)
+		default:
+			return
+		}
+	}
 }
+
 func (object *RoutinePool) AfterShutdown() {
 
 }
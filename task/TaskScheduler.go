@@ -14,10 +14,17 @@ var (
 	tsOnce        sync.Once
 )
 
+// LeaderElector /主节点选举器，多副本部署时用于过滤仅主节点执行的触发器
+type LeaderElector interface {
+	// IsLeader /当前节点是否为主节点
+	IsLeader() bool
+}
+
 // TaskScheduler /任务调度器
 type TaskScheduler struct {
 	sync.RWMutex
 	allTriggers map[Trigger]interface{}
+	elector     LeaderElector
 	ctx         context.Context
 	cancel      context.CancelFunc
 	wg          *sync.WaitGroup
@@ -58,11 +65,14 @@ loop:
 			object.RLock()
 			for trigger := range object.allTriggers {
 				if trigger.CanTrigger(now) {
-					routinePool.PostTask(func(params []interface{}) interface{} {
-						trigger := params[0].(Trigger)
-						trigger.Trigger()
-						return nil
-					}, trigger)
+					//非主节点跳过仅主节点执行的触发器
+					if object.canRun(trigger) {
+						routinePool.PostTask(func(params []interface{}) interface{} {
+							trigger := params[0].(Trigger)
+							trigger.Trigger()
+							return nil
+						}, trigger)
+					}
 					//不能周期性触发的，直接删除
 					if !trigger.CanPeriodic() {
 						delete(object.allTriggers, trigger)
@@ -75,6 +85,24 @@ loop:
 	object.wg.Done()
 }
 
+// /是否可在当前节点执行
+func (object *TaskScheduler) canRun(trigger Trigger) bool {
+	if nil == object.elector {
+		return true
+	}
+	if filter, ok := trigger.(MasterFilter); ok && filter.CanFilter() {
+		return object.elector.IsLeader()
+	}
+	return true
+}
+
+// SetLeaderElector /设置主节点选举器，为空时所有触发器在每个节点执行
+func (object *TaskScheduler) SetLeaderElector(elector LeaderElector) {
+	object.Lock()
+	object.elector = elector
+	object.Unlock()
+}
+
 // AddTrigger /设置触发器
 func (object *TaskScheduler) AddTrigger(trigger Trigger) {
 	object.Lock()
@@ -4,6 +4,7 @@
 package task
 
 import (
+	"sync"
 	"time"
 )
 
@@ -16,6 +17,12 @@ type Trigger interface {
 	CanPeriodic() bool
 }
 
+// MasterFilter 主节点过滤
+// 调度器设置了选举器时，CanFilter 返回 true 的触发器仅在主节点执行
+type MasterFilter interface {
+	CanFilter() bool
+}
+
 // AnyTrigger /任意触发器
 type AnyTrigger struct {
 	canTrigger  func(time.Time) bool
@@ -46,6 +53,16 @@ func (object *AnyTrigger) CanPeriodic() bool {
 	return object.canPeriodic
 }
 
+// CanFilter 是否仅主节点触发
+func (object *AnyTrigger) CanFilter() bool {
+	return object.canFilter
+}
+
+// SetFilterMaster 设置是否仅主节点触发
+func (object *AnyTrigger) SetFilterMaster(filterMaster bool) {
+	object.canFilter = filterMaster
+}
+
 // OneMinuteTrigger /1分钟周期性触发器
 type OneMinuteTrigger struct {
 	*AnyTrigger
@@ -119,3 +136,62 @@ func NewTimePointTrigger(timePoint int64, filterMaster bool) *TimePointTrigger {
 	object.AnyTrigger.canFilter = filterMaster
 	return object
 }
+
+// CronTrigger /cron表达式触发器
+// 预先计算下一次触发时间，CanTrigger 仅做时间比较
+type CronTrigger struct {
+	*AnyTrigger
+	Spec     string
+	schedule *cronSchedule
+	lock     sync.RWMutex
+	next     time.Time
+}
+
+// NewCronTrigger /工厂方法，使用本地时区(可通过 CRON_TZ= 前缀指定时区)
+// filterMaster 为 true 时调度器设置了选举器后仅在主节点触发
+func NewCronTrigger(spec string, filterMaster bool) (*CronTrigger, error) {
+	return NewCronTriggerInLocation(spec, time.Local, filterMaster)
+}
+
+// NewCronTriggerInLocation /工厂方法，指定时区
+func NewCronTriggerInLocation(spec string, loc *time.Location, filterMaster bool) (*CronTrigger, error) {
+	schedule, err := parseCronSpec(spec, loc)
+	if err != nil {
+		return nil, err
+	}
+	object := &CronTrigger{
+		Spec:     spec,
+		schedule: schedule,
+	}
+	object.next = schedule.next(time.Now())
+	object.AnyTrigger = NewAnyTrigger(func(now time.Time) bool {
+		object.lock.Lock()
+		defer object.lock.Unlock()
+		if object.next.IsZero() || now.Before(object.next) {
+			return false
+		}
+		//错过多次触发时只触发一次
+		object.next = object.schedule.next(now)
+		return true
+	}, true)
+	object.AnyTrigger.canFilter = filterMaster
+	return object, nil
+}
+
+// NextFireTime /下一次触发时间，无后续触发时返回零值
+func (object *CronTrigger) NextFireTime() time.Time {
+	object.lock.RLock()
+	defer object.lock.RUnlock()
+	return object.next
+}
+
+// NextFireTimes /接下来 n 次触发时间
+func (object *CronTrigger) NextFireTimes(n int) []time.Time {
+	times := make([]time.Time, 0, n)
+	next := object.NextFireTime()
+	for i := 0; i < n && !next.IsZero(); i++ {
+		times = append(times, next)
+		next = object.schedule.next(next)
+	}
+	return times
+}
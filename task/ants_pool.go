@@ -1,15 +1,34 @@
 package task
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"runtime/debug"
 	"sync"
+	"time"
 
+	"github.com/XingMenTech/common/logger"
 	"github.com/panjf2000/ants/v2"
 )
 
-var generic *ants.PoolWithFuncGeneric[AntsPoolParam]
+const (
+	// DefaultAntsPool InitAntsWorkPool 创建的协程池名称，Submit 使用该协程池
+	DefaultAntsPool = "default"
+	// DefaultAntsFuncPool InitAntsFuncPool 创建的协程池名称，Invoke 使用该协程池
+	DefaultAntsFuncPool = "default_func"
+	// DefaultAntsReleaseTimeout 关闭时等待任务完成的最长时间
+	DefaultAntsReleaseTimeout = 10 * time.Second
+)
 
-var pool *ants.Pool
+var (
+	// ErrAntsPoolExists 同名协程池已存在
+	ErrAntsPoolExists = errors.New("ants pool: already exists")
+	// ErrAntsPoolNotFound 协程池不存在或未初始化
+	ErrAntsPoolNotFound = errors.New("ants pool: not found")
+	// ErrAntsFuncNotFound 未通过 RegisterAntsPoolFunc 注册的方法
+	ErrAntsFuncNotFound = errors.New("ants pool: func not registered")
+)
 
 type AntsPoolParam struct {
 	FuncCode string
@@ -19,44 +38,292 @@ type AntsPoolParam struct {
 var funcMap = make(map[string]func(interface{}))
 var lock sync.Mutex
 
+var (
+	antsPools   = make(map[string]*AntsPool)
+	antsPoolsMu sync.RWMutex
+)
+
 func RegisterAntsPoolFunc(code string, fn func(interface{})) {
 	lock.Lock()
 	defer lock.Unlock()
 	funcMap[code] = fn
 }
 
-func InitAntsFuncPool(max int) (err error) {
-	generic, err = ants.NewPoolWithFuncGeneric(max, func(i AntsPoolParam) {
-		code := i.FuncCode
-		if fu, ok := funcMap[code]; ok {
-			fu(i.Arg)
+func antsFunc(code string) (func(interface{}), bool) {
+	lock.Lock()
+	defer lock.Unlock()
+	fn, ok := funcMap[code]
+	return fn, ok
+}
+
+// AntsPoolOption /协程池配置项
+type AntsPoolOption func(*antsPoolOptions)
+
+type antsPoolOptions struct {
+	nonblocking    bool
+	preAlloc       bool
+	expiry         time.Duration
+	maxBlocking    int
+	panicHandler   func(interface{})
+	releaseTimeout time.Duration
+}
+
+// WithAntsNonblocking /协程已满时直接返回 ants.ErrPoolOverload，默认阻塞等待
+func WithAntsNonblocking(nonblocking bool) AntsPoolOption {
+	return func(opts *antsPoolOptions) {
+		opts.nonblocking = nonblocking
+	}
+}
+
+// WithAntsPreAlloc /预分配协程队列，适用于容量固定的大协程池
+func WithAntsPreAlloc(preAlloc bool) AntsPoolOption {
+	return func(opts *antsPoolOptions) {
+		opts.preAlloc = preAlloc
+	}
+}
+
+// WithAntsExpiry /空闲协程的回收时间，默认 ants.DefaultCleanIntervalTime
+func WithAntsExpiry(expiry time.Duration) AntsPoolOption {
+	return func(opts *antsPoolOptions) {
+		if expiry > 0 {
+			opts.expiry = expiry
+		}
+	}
+}
+
+// WithAntsMaxBlocking /阻塞模式下最多等待的任务数，超出时返回 ants.ErrPoolOverload，默认不限制
+func WithAntsMaxBlocking(n int) AntsPoolOption {
+	return func(opts *antsPoolOptions) {
+		if n > 0 {
+			opts.maxBlocking = n
+		}
+	}
+}
+
+// WithAntsPanicHandler /任务 panic 时的回调，默认记录日志及调用栈
+func WithAntsPanicHandler(handler func(interface{})) AntsPoolOption {
+	return func(opts *antsPoolOptions) {
+		opts.panicHandler = handler
+	}
+}
+
+// WithAntsReleaseTimeout /关闭时等待任务完成的最长时间，默认 DefaultAntsReleaseTimeout
+func WithAntsReleaseTimeout(timeout time.Duration) AntsPoolOption {
+	return func(opts *antsPoolOptions) {
+		if timeout > 0 {
+			opts.releaseTimeout = timeout
+		}
+	}
+}
+
+// AntsPoolStats /协程池统计
+type AntsPoolStats struct {
+	Cap     int
+	Running int
+	Free    int
+	Waiting int
+}
+
+// AntsPool /命名协程池，实现 grpcx.ShutdownHook，关闭时释放
+type AntsPool struct {
+	name     string
+	pool     *ants.Pool
+	opts     *antsPoolOptions
+	priority int
+}
+
+// NewAntsPool /创建并注册命名协程池，同名协程池已存在时返回 ErrAntsPoolExists
+func NewAntsPool(name string, size int, opts ...AntsPoolOption) (*AntsPool, error) {
+	object, _, err := newAntsPool(name, size, false, opts)
+	return object, err
+}
+
+// newAntsPool /创建并注册协程池，replace 为true时替换同名协程池并返回被替换的协程池
+func newAntsPool(name string, size int, replace bool, opts []AntsPoolOption) (*AntsPool, *AntsPool, error) {
+	o := &antsPoolOptions{releaseTimeout: DefaultAntsReleaseTimeout}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if nil == o.panicHandler {
+		o.panicHandler = func(r interface{}) {
+			logAntsPanic(name, r)
 		}
+	}
 
-	}, ants.WithPreAlloc(true), ants.WithNonblocking(false))
+	antsPoolsMu.Lock()
+	defer antsPoolsMu.Unlock()
+	old, ok := antsPools[name]
+	if ok && !replace {
+		return nil, nil, ErrAntsPoolExists
+	}
+	p, err := ants.NewPool(size,
+		ants.WithNonblocking(o.nonblocking),
+		ants.WithPreAlloc(o.preAlloc),
+		ants.WithExpiryDuration(o.expiry),
+		ants.WithMaxBlockingTasks(o.maxBlocking),
+		ants.WithPanicHandler(o.panicHandler))
 	if err != nil {
-		return
+		return nil, nil, err
 	}
+	object := &AntsPool{name: name, pool: p, opts: o, priority: 2}
+	antsPools[name] = object
+	return object, old, nil
+}
 
-	return
+// initDefaultAntsPool /创建默认协程池，重复初始化时替换原协程池，原协程池在后台等待任务完成后释放
+func initDefaultAntsPool(name string, max int) error {
+	_, old, err := newAntsPool(name, max, true, []AntsPoolOption{WithAntsPreAlloc(true)})
+	if err != nil {
+		return err
+	}
+	if nil != old {
+		go old.Release()
+	}
+	return nil
 }
 
-func Invoke(code string, arg interface{}) error {
+// GetAntsPool /按名称获取协程池
+func GetAntsPool(name string) (*AntsPool, bool) {
+	antsPoolsMu.RLock()
+	defer antsPoolsMu.RUnlock()
+	p, ok := antsPools[name]
+	return p, ok
+}
+
+// AntsPools /所有已注册的协程池，可逐个安装为关闭钩子
+func AntsPools() []*AntsPool {
+	antsPoolsMu.RLock()
+	defer antsPoolsMu.RUnlock()
+	arr := make([]*AntsPool, 0, len(antsPools))
+	for _, p := range antsPools {
+		arr = append(arr, p)
+	}
+	return arr
+}
+
+// ReleaseAntsPools /释放所有协程池
+func ReleaseAntsPools() {
+	for _, p := range AntsPools() {
+		p.Release()
+	}
+}
+
+// logAntsPanic /默认的 panic 回调，日志未初始化时输出到标准错误
+func logAntsPanic(name string, r interface{}) {
+	if nil == logger.LOG {
+		fmt.Fprintf(os.Stderr, "ants pool %s: task panic: %v\n%s", name, r, debug.Stack())
+		return
+	}
+	logger.LOG.WithField("module", "AntsPool").Errorf("pool %s task panic: %v\n%s", name, r, debug.Stack())
+}
+
+// Submit /提交任务
+func (object *AntsPool) Submit(fn func()) error {
+	return object.pool.Submit(fn)
+}
 
-	return generic.Invoke(AntsPoolParam{
-		FuncCode: code,
-		Arg:      arg,
+// Invoke /执行通过 RegisterAntsPoolFunc 注册的方法
+func (object *AntsPool) Invoke(code string, arg interface{}) error {
+	fn, ok := antsFunc(code)
+	if !ok {
+		return ErrAntsFuncNotFound
+	}
+	return object.pool.Submit(func() {
+		fn(arg)
 	})
 }
 
+// Tune /运行时调整容量，预分配的协程池(包括 InitAntsWorkPool、InitAntsFuncPool 创建的默认协程池)调用无效
+// 需要调整容量时通过 NewAntsPool 创建不预分配的协程池
+func (object *AntsPool) Tune(size int) {
+	object.pool.Tune(size)
+}
+
+// Running /执行中的协程数
+func (object *AntsPool) Running() int {
+	return object.pool.Running()
+}
+
+// Free /可用的协程数
+func (object *AntsPool) Free() int {
+	return object.pool.Free()
+}
+
+// Waiting /等待中的任务数
+func (object *AntsPool) Waiting() int {
+	return object.pool.Waiting()
+}
+
+// Stats /统计
+func (object *AntsPool) Stats() AntsPoolStats {
+	return AntsPoolStats{
+		Cap:     object.pool.Cap(),
+		Running: object.pool.Running(),
+		Free:    object.pool.Free(),
+		Waiting: object.pool.Waiting(),
+	}
+}
+
+// Release /等待执行中的任务完成后释放并注销，超时后直接释放
+func (object *AntsPool) Release() error {
+	antsPoolsMu.Lock()
+	if antsPools[object.name] == object {
+		delete(antsPools, object.name)
+	}
+	antsPoolsMu.Unlock()
+	return object.pool.ReleaseTimeout(object.opts.releaseTimeout)
+}
+
+// Name /名字
+func (object *AntsPool) Name() string {
+	return "AntsPool:" + object.name
+}
+
+// SetShutdownPriority /设置关闭优先级
+func (object *AntsPool) SetShutdownPriority(priority int) {
+	object.priority = priority
+}
+
+// ShutdownPriority /关闭优先级
+func (object *AntsPool) ShutdownPriority() int {
+	return object.priority
+}
+
+// BeforeShutdown /应用退出前
+func (object *AntsPool) BeforeShutdown() {
+	_ = object.Release()
+}
+
+func (object *AntsPool) AfterShutdown() {
+
+}
+
+// InitAntsFuncPool /创建 Invoke 使用的默认协程池，协程预分配，重复调用时替换原协程池
+func InitAntsFuncPool(max int) (err error) {
+	return initDefaultAntsPool(DefaultAntsFuncPool, max)
+}
+
+// Invoke /在默认协程池中执行注册的方法，未调用 InitAntsFuncPool 时返回 ErrAntsPoolNotFound
+func Invoke(code string, arg interface{}) error {
+	p, ok := GetAntsPool(DefaultAntsFuncPool)
+	if !ok {
+		return ErrAntsPoolNotFound
+	}
+	return p.Invoke(code, arg)
+}
+
+// InitAntsWorkPool /创建 Submit 使用的默认协程池，协程预分配，重复调用时替换原协程池
 func InitAntsWorkPool(max int) {
-	var err error
-	pool, err = ants.NewPool(max, ants.WithPreAlloc(true), ants.WithNonblocking(false))
-	if err != nil {
+	if err := initDefaultAntsPool(DefaultAntsPool, max); err != nil {
 		panic(fmt.Sprintf("goroutine池启动失败,error: %+v", err))
 	}
-	return
 }
 
+// Submit /在默认协程池中执行任务，未调用 InitAntsWorkPool 时返回 ErrAntsPoolNotFound
 func Submit(fn func()) error {
-	return pool.Submit(fn)
+	p, ok := GetAntsPool(DefaultAntsPool)
+	if !ok {
+		return ErrAntsPoolNotFound
+	}
+	return p.Submit(fn)
 }
@@ -0,0 +1,86 @@
+package task
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAntsPoolRegistry(t *testing.T) {
+	assert.Equal(t, ErrAntsPoolNotFound, Invoke("missing", nil))
+	assert.Equal(t, ErrAntsPoolNotFound, Submit(func() {}))
+
+	p, err := NewAntsPool("test_payout", 2, WithAntsExpiry(time.Second))
+	assert.NoError(t, err)
+	_, err = NewAntsPool("test_payout", 2)
+	assert.Equal(t, ErrAntsPoolExists, err)
+
+	got, ok := GetAntsPool("test_payout")
+	assert.True(t, ok)
+	assert.Equal(t, p, got)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	RegisterAntsPoolFunc("test_double", func(arg interface{}) {
+		defer wg.Done()
+		assert.Equal(t, 2, arg)
+	})
+	assert.NoError(t, p.Invoke("test_double", 2))
+	assert.Equal(t, ErrAntsFuncNotFound, p.Invoke("test_unknown", nil))
+	wg.Wait()
+
+	p.Tune(4)
+	assert.Equal(t, 4, p.Stats().Cap)
+
+	assert.NoError(t, p.Release())
+	_, ok = GetAntsPool("test_payout")
+	assert.False(t, ok)
+}
+
+func TestInitAntsWorkPoolReplace(t *testing.T) {
+	InitAntsWorkPool(2)
+	old, _ := GetAntsPool(DefaultAntsPool)
+	InitAntsWorkPool(4)
+	p, ok := GetAntsPool(DefaultAntsPool)
+	assert.True(t, ok)
+	assert.NotSame(t, old, p)
+	assert.Equal(t, 4, p.Stats().Cap)
+
+	done := make(chan struct{})
+	assert.NoError(t, Submit(func() { close(done) }))
+	<-done
+
+	assert.NoError(t, InitAntsFuncPool(2))
+	assert.NoError(t, InitAntsFuncPool(2))
+	ReleaseAntsPools()
+}
+
+func TestAntsPoolPanic(t *testing.T) {
+	recovered := make(chan interface{}, 1)
+	p, err := NewAntsPool("test_panic", 1, WithAntsPanicHandler(func(r interface{}) {
+		recovered <- r
+	}))
+	assert.NoError(t, err)
+	defer p.Release()
+
+	assert.NoError(t, p.Submit(func() {
+		panic("boom")
+	}))
+	assert.Equal(t, "boom", <-recovered)
+}
+
+func TestAntsPoolNonblocking(t *testing.T) {
+	p, err := NewAntsPool("test_report", 1, WithAntsNonblocking(true))
+	assert.NoError(t, err)
+	defer p.Release()
+
+	release := make(chan struct{})
+	assert.NoError(t, p.Submit(func() {
+		<-release
+	}))
+	assert.Error(t, p.Submit(func() {}))
+	assert.Equal(t, 1, p.Running())
+	close(release)
+}
@@ -0,0 +1,266 @@
+package task
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/XingMenTech/common/logger"
+)
+
+const (
+	DefaultBatchSize     = 100
+	DefaultBatchInterval = time.Second
+	DefaultBatchRetries  = 3
+	DefaultBatchBackoff  = 100 * time.Millisecond
+)
+
+// BatchFlushFunc /批量处理方法，返回错误时按配置重试
+type BatchFlushFunc[T any] func(items []T) error
+
+// BatcherOption /批处理配置项
+type BatcherOption func(*batcherOptions)
+
+type batcherOptions struct {
+	size         int
+	interval     time.Duration
+	concurrency  int
+	retries      int
+	backoff      time.Duration
+	errorHandler func(items []interface{}, err error)
+}
+
+// WithBatchSize /累计多少条后立即处理，默认 DefaultBatchSize
+func WithBatchSize(size int) BatcherOption {
+	return func(opts *batcherOptions) {
+		if size > 0 {
+			opts.size = size
+		}
+	}
+}
+
+// WithBatchInterval /首条数据最多等待多久后处理，默认 DefaultBatchInterval
+func WithBatchInterval(interval time.Duration) BatcherOption {
+	return func(opts *batcherOptions) {
+		if interval > 0 {
+			opts.interval = interval
+		}
+	}
+}
+
+// WithBatchConcurrency /同时执行的批量处理数，达到上限时暂停收集，默认1
+func WithBatchConcurrency(n int) BatcherOption {
+	return func(opts *batcherOptions) {
+		if n > 0 {
+			opts.concurrency = n
+		}
+	}
+}
+
+// WithBatchRetry /处理失败后的重试次数与间隔，间隔按次数翻倍，默认 DefaultBatchRetries 与 DefaultBatchBackoff
+func WithBatchRetry(retries int, backoff time.Duration) BatcherOption {
+	return func(opts *batcherOptions) {
+		if retries >= 0 {
+			opts.retries = retries
+		}
+		if backoff > 0 {
+			opts.backoff = backoff
+		}
+	}
+}
+
+// WithBatchErrorHandler /重试耗尽后的回调，items 为该批数据，默认记录日志
+func WithBatchErrorHandler(handler func(items []interface{}, err error)) BatcherOption {
+	return func(opts *batcherOptions) {
+		opts.errorHandler = handler
+	}
+}
+
+// Batcher /批处理器，多个协程 Add 的数据在达到数量或等待时间后合并处理
+type Batcher[T any] struct {
+	flush    BatchFlushFunc[T]
+	opts     *batcherOptions
+	queue    *Queue
+	sem      chan struct{}
+	wg       *sync.WaitGroup
+	done     chan struct{}
+	once     sync.Once
+	priority int
+}
+
+// NewBatcher /工厂方法，创建后即开始收集
+func NewBatcher[T any](flush BatchFlushFunc[T], opts ...BatcherOption) *Batcher[T] {
+	o := &batcherOptions{
+		size:        DefaultBatchSize,
+		interval:    DefaultBatchInterval,
+		concurrency: 1,
+		retries:     DefaultBatchRetries,
+		backoff:     DefaultBatchBackoff,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if nil == o.errorHandler {
+		o.errorHandler = logBatchError
+	}
+
+	object := &Batcher[T]{
+		flush:    flush,
+		opts:     o,
+		queue:    New(int64(o.size)),
+		sem:      make(chan struct{}, o.concurrency),
+		wg:       &sync.WaitGroup{},
+		done:     make(chan struct{}),
+		priority: 3,
+	}
+	go object.collect()
+	return object
+}
+
+// logBatchError /默认的失败回调，日志未初始化时输出到标准错误
+func logBatchError(items []interface{}, err error) {
+	if nil == logger.LOG {
+		fmt.Fprintf(os.Stderr, "batcher: flush %d items failed: %v\n", len(items), err)
+		return
+	}
+	logger.LOG.WithField("module", "Batcher").Errorf("flush %d items failed: %v", len(items), err)
+}
+
+// Add /添加数据，Close 后返回 ErrDisposed
+func (object *Batcher[T]) Add(items ...T) error {
+	arr := make([]interface{}, len(items))
+	for i, item := range items {
+		arr[i] = item
+	}
+	return object.queue.Put(arr...)
+}
+
+// Len /等待收集的数据量
+func (object *Batcher[T]) Len() int64 {
+	return object.queue.Len()
+}
+
+// /收集循环，数量达到 size 或首条数据等待超过 interval 时处理
+func (object *Batcher[T]) collect() {
+	defer close(object.done)
+
+	size := object.opts.size
+	buf := make([]interface{}, size)
+	batch := make([]T, 0, size)
+	var deadline time.Time
+	for {
+		//没有数据时一直等待
+		var timeout time.Duration
+		if 0 < len(batch) {
+			if timeout = time.Until(deadline); timeout <= 0 {
+				batch = object.dispatch(batch)
+				continue
+			}
+		}
+
+		n, err := object.queue.Poll(int64(size-len(batch)), buf, timeout)
+		for i := int64(0); i < n; i++ {
+			batch = append(batch, buf[i].(T))
+			buf[i] = nil
+		}
+		if ErrDisposed == err {
+			break
+		}
+		if 0 < n && len(batch) == int(n) {
+			deadline = time.Now().Add(object.opts.interval)
+		}
+		if size <= len(batch) {
+			batch = object.dispatch(batch)
+		}
+	}
+	if 0 < len(batch) {
+		object.dispatch(batch)
+	}
+}
+
+// /提交一批数据，达到并发上限时等待，返回新的缓冲
+func (object *Batcher[T]) dispatch(batch []T) []T {
+	object.sem <- struct{}{}
+	object.wg.Add(1)
+	go func() {
+		defer func() {
+			<-object.sem
+			object.wg.Done()
+		}()
+		object.flushWithRetry(batch)
+	}()
+	return make([]T, 0, object.opts.size)
+}
+
+// /执行批量处理，失败时按间隔翻倍重试
+func (object *Batcher[T]) flushWithRetry(batch []T) {
+	backoff := object.opts.backoff
+	var err error
+	for i := 0; i <= object.opts.retries; i++ {
+		if err = object.safeFlush(batch); nil == err {
+			return
+		}
+		if i < object.opts.retries {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+	items := make([]interface{}, len(batch))
+	for i, item := range batch {
+		items[i] = item
+	}
+	object.opts.errorHandler(items, err)
+}
+
+// /panic 视为处理失败
+func (object *Batcher[T]) safeFlush(batch []T) (err error) {
+	defer func() {
+		if r := recover(); nil != r {
+			err = fmt.Errorf("batcher: flush panic: %v", r)
+		}
+	}()
+	return object.flush(batch)
+}
+
+// Close /停止收集，处理剩余的全部数据并等待完成
+func (object *Batcher[T]) Close() {
+	object.once.Do(func() {
+		rest := object.queue.Dispose()
+		<-object.done
+		for len(rest) > 0 {
+			n := min(len(rest), object.opts.size)
+			batch := make([]T, n)
+			for i := 0; i < n; i++ {
+				batch[i] = rest[i].(T)
+			}
+			object.dispatch(batch)
+			rest = rest[n:]
+		}
+		object.wg.Wait()
+	})
+}
+
+// Name /名字
+func (object *Batcher[T]) Name() string {
+	return "Batcher"
+}
+
+// SetShutdownPriority /设置关闭优先级
+func (object *Batcher[T]) SetShutdownPriority(priority int) {
+	object.priority = priority
+}
+
+// ShutdownPriority /关闭优先级
+func (object *Batcher[T]) ShutdownPriority() int {
+	return object.priority
+}
+
+// BeforeShutdown /应用退出前，处理剩余数据
+func (object *Batcher[T]) BeforeShutdown() {
+	object.Close()
+}
+
+func (object *Batcher[T]) AfterShutdown() {
+
+}
@@ -0,0 +1,94 @@
+package task
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatcherFlushBySize(t *testing.T) {
+	batches := make(chan []int, 10)
+	b := NewBatcher(func(items []int) error {
+		batches <- items
+		return nil
+	}, WithBatchSize(3), WithBatchInterval(time.Hour))
+	defer b.Close()
+
+	assert.NoError(t, b.Add(1, 2, 3, 4))
+	assert.Equal(t, []int{1, 2, 3}, <-batches)
+	select {
+	case batch := <-batches:
+		t.Errorf("unexpected flush %v", batch)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestBatcherFlushByInterval(t *testing.T) {
+	batches := make(chan []int, 10)
+	b := NewBatcher(func(items []int) error {
+		batches <- items
+		return nil
+	}, WithBatchSize(100), WithBatchInterval(30*time.Millisecond))
+	defer b.Close()
+
+	start := time.Now()
+	assert.NoError(t, b.Add(1))
+	assert.NoError(t, b.Add(2))
+	assert.Equal(t, []int{1, 2}, <-batches)
+	assert.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+}
+
+func TestBatcherCloseDrains(t *testing.T) {
+	var mu sync.Mutex
+	var total int
+	b := NewBatcher(func(items []int) error {
+		mu.Lock()
+		total += len(items)
+		mu.Unlock()
+		return nil
+	}, WithBatchSize(7), WithBatchInterval(time.Hour), WithBatchConcurrency(4))
+
+	var wg sync.WaitGroup
+	for p := 0; p < 10; p++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				_ = b.Add(i)
+			}
+		}()
+	}
+	wg.Wait()
+	b.Close()
+
+	assert.Equal(t, 1000, total)
+	assert.Equal(t, ErrDisposed, b.Add(1))
+}
+
+func TestBatcherRetry(t *testing.T) {
+	var calls int32
+	failed := make(chan []interface{}, 1)
+	b := NewBatcher(func(items []int) error {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			return errors.New("db down")
+		}
+		return nil
+	}, WithBatchSize(1), WithBatchRetry(2, time.Millisecond))
+	assert.NoError(t, b.Add(1))
+	b.Close()
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	b = NewBatcher(func(items []int) error {
+		return errors.New("db down")
+	}, WithBatchSize(1), WithBatchRetry(1, time.Millisecond),
+		WithBatchErrorHandler(func(items []interface{}, err error) {
+			failed <- items
+		}))
+	assert.NoError(t, b.Add(5))
+	b.Close()
+	assert.Equal(t, []interface{}{5}, <-failed)
+}
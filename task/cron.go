@@ -0,0 +1,264 @@
+// cron表达式解析
+// 支持5段(分 时 日 月 周)与6段(秒 分 时 日 月 周)表达式，
+// 支持 * ? , - / 语法、月份与星期英文缩写、@daily 等描述符以及 CRON_TZ=/TZ= 时区前缀
+
+package task
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrCronSpec is returned when a cron expression can not be parsed.
+	ErrCronSpec = errors.New(`cron: invalid spec`)
+)
+
+// cronBounds /字段取值范围
+type cronBounds struct {
+	min, max uint
+	names    map[string]uint
+}
+
+var (
+	cronSeconds = cronBounds{0, 59, nil}
+	cronMinutes = cronBounds{0, 59, nil}
+	cronHours   = cronBounds{0, 23, nil}
+	cronDom     = cronBounds{1, 31, nil}
+	cronMonths  = cronBounds{1, 12, map[string]uint{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// 星期允许 0-7，7 与 0 均表示周日
+	cronDow = cronBounds{0, 7, map[string]uint{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+
+	cronDescriptors = map[string]string{
+		"@yearly":   "0 0 0 1 1 *",
+		"@annually": "0 0 0 1 1 *",
+		"@monthly":  "0 0 0 1 * *",
+		"@weekly":   "0 0 0 * * 0",
+		"@daily":    "0 0 0 * * *",
+		"@midnight": "0 0 0 * * *",
+		"@hourly":   "0 0 * * * *",
+	}
+)
+
+// cronSchedule /解析后的cron表达式，每个字段以位图表示
+type cronSchedule struct {
+	second, minute, hour, dom, month, dow uint64
+	// 日、周字段均非 * 时按标准cron语义取并集
+	domStar, dowStar bool
+	location         *time.Location
+}
+
+// parseCronSpec /解析cron表达式，loc 为空时使用本地时区
+func parseCronSpec(spec string, loc *time.Location) (*cronSchedule, error) {
+	spec = strings.TrimSpace(spec)
+	if loc == nil {
+		loc = time.Local
+	}
+
+	if strings.HasPrefix(spec, "CRON_TZ=") || strings.HasPrefix(spec, "TZ=") {
+		i := strings.IndexAny(spec, " \t")
+		if i == -1 {
+			return nil, fmt.Errorf("%w: missing fields after time zone in %q", ErrCronSpec, spec)
+		}
+		name := spec[strings.Index(spec, "=")+1 : i]
+		l, err := time.LoadLocation(name)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unknown time zone %q: %v", ErrCronSpec, name, err)
+		}
+		loc = l
+		spec = strings.TrimSpace(spec[i:])
+	}
+
+	if strings.HasPrefix(spec, "@") {
+		v, ok := cronDescriptors[strings.ToLower(spec)]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown descriptor %q", ErrCronSpec, spec)
+		}
+		spec = v
+	}
+
+	fields := strings.Fields(spec)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("%w: expected 5 or 6 fields, found %d in %q", ErrCronSpec, len(fields), spec)
+	}
+
+	s := &cronSchedule{location: loc}
+	var err error
+	if s.second, _, err = parseCronField(fields[0], cronSeconds); err != nil {
+		return nil, err
+	}
+	if s.minute, _, err = parseCronField(fields[1], cronMinutes); err != nil {
+		return nil, err
+	}
+	if s.hour, _, err = parseCronField(fields[2], cronHours); err != nil {
+		return nil, err
+	}
+	if s.dom, s.domStar, err = parseCronField(fields[3], cronDom); err != nil {
+		return nil, err
+	}
+	if s.month, _, err = parseCronField(fields[4], cronMonths); err != nil {
+		return nil, err
+	}
+	if s.dow, s.dowStar, err = parseCronField(fields[5], cronDow); err != nil {
+		return nil, err
+	}
+	// 7 视为周日
+	if s.dow&(1<<7) != 0 {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+	return s, nil
+}
+
+// parseCronField /解析单个字段，返回位图以及是否为 * 或 ?
+func parseCronField(field string, b cronBounds) (uint64, bool, error) {
+	var bits uint64
+	star := false
+	for _, expr := range strings.Split(field, ",") {
+		v, s, err := parseCronRange(expr, b)
+		if err != nil {
+			return 0, false, err
+		}
+		bits |= v
+		star = star || s
+	}
+	return bits, star, nil
+}
+
+// parseCronRange /解析 *、?、a、a-b、*/n、a/n、a-b/n
+func parseCronRange(expr string, b cronBounds) (uint64, bool, error) {
+	var (
+		start, end, step uint = 0, 0, 1
+		star                  = false
+		err              error
+	)
+
+	rangeAndStep := strings.Split(expr, "/")
+	if len(rangeAndStep) > 2 {
+		return 0, false, fmt.Errorf("%w: too many slashes in %q", ErrCronSpec, expr)
+	}
+	lowAndHigh := strings.Split(rangeAndStep[0], "-")
+	if len(lowAndHigh) > 2 {
+		return 0, false, fmt.Errorf("%w: too many hyphens in %q", ErrCronSpec, expr)
+	}
+
+	if lowAndHigh[0] == "*" || lowAndHigh[0] == "?" {
+		if len(lowAndHigh) != 1 {
+			return 0, false, fmt.Errorf("%w: invalid range %q", ErrCronSpec, expr)
+		}
+		start, end = b.min, b.max
+		star = len(rangeAndStep) == 1
+	} else {
+		if start, err = parseCronValue(lowAndHigh[0], b); err != nil {
+			return 0, false, err
+		}
+		end = start
+		if len(lowAndHigh) == 2 {
+			if end, err = parseCronValue(lowAndHigh[1], b); err != nil {
+				return 0, false, err
+			}
+		} else if len(rangeAndStep) == 2 {
+			// a/n 等价于 a-max/n
+			end = b.max
+		}
+	}
+
+	if len(rangeAndStep) == 2 {
+		n, err := strconv.ParseUint(rangeAndStep[1], 10, 32)
+		if err != nil || n == 0 {
+			return 0, false, fmt.Errorf("%w: invalid step in %q", ErrCronSpec, expr)
+		}
+		step = uint(n)
+	}
+
+	if start < b.min || end > b.max || start > end {
+		return 0, false, fmt.Errorf("%w: %q out of range [%d, %d]", ErrCronSpec, expr, b.min, b.max)
+	}
+
+	var bits uint64
+	for i := start; i <= end; i += step {
+		bits |= 1 << i
+	}
+	return bits, star, nil
+}
+
+func parseCronValue(value string, b cronBounds) (uint, error) {
+	if n, ok := b.names[strings.ToLower(value)]; ok {
+		return n, nil
+	}
+	n, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid value %q", ErrCronSpec, value)
+	}
+	return uint(n), nil
+}
+
+// next /返回严格晚于 t 的下一次触发时间，五年内无匹配返回零值
+func (s *cronSchedule) next(t time.Time) time.Time {
+	origin := t.In(s.location)
+	t = origin.Add(time.Second - time.Duration(origin.Nanosecond()))
+	yearLimit := t.Year() + 5
+
+WRAP:
+	if t.Year() > yearLimit {
+		return time.Time{}
+	}
+
+	for 1<<uint(t.Month())&s.month == 0 {
+		t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, s.location)
+		if t.Month() == time.January {
+			goto WRAP
+		}
+	}
+
+	for !s.dayMatches(t) {
+		t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, s.location)
+		if t.Day() == 1 {
+			goto WRAP
+		}
+	}
+
+	for 1<<uint(t.Hour())&s.hour == 0 {
+		// 按绝对时间推进，夏令时切换时保证单调递增
+		t = t.Add(time.Hour - time.Duration(t.Minute())*time.Minute - time.Duration(t.Second())*time.Second)
+		if t.Hour() == 0 {
+			goto WRAP
+		}
+	}
+
+	for 1<<uint(t.Minute())&s.minute == 0 {
+		t = t.Add(time.Minute - time.Duration(t.Second())*time.Second)
+		if t.Minute() == 0 {
+			goto WRAP
+		}
+	}
+
+	for 1<<uint(t.Second())&s.second == 0 {
+		t = t.Add(time.Second)
+		if t.Second() == 0 {
+			goto WRAP
+		}
+	}
+
+	return t
+}
+
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	domMatch := 1<<uint(t.Day())&s.dom != 0
+	dowMatch := 1<<uint(t.Weekday())&s.dow != 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
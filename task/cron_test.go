@@ -0,0 +1,89 @@
+package task
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCronNext(t *testing.T) {
+	loc := time.UTC
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, loc) // Monday
+
+	cases := []struct {
+		spec   string
+		expect time.Time
+	}{
+		{"* * * * *", time.Date(2024, 1, 1, 0, 1, 0, 0, loc)},
+		{"*/15 * * * * *", time.Date(2024, 1, 1, 0, 0, 15, 0, loc)},
+		{"30 9 * * mon-fri", time.Date(2024, 1, 1, 9, 30, 0, 0, loc)},
+		{"0 0 12 * * SAT", time.Date(2024, 1, 6, 12, 0, 0, 0, loc)},
+		{"0 0 1 FEB *", time.Date(2024, 2, 1, 0, 0, 0, 0, loc)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, loc)},
+		{"0 0 1,15 * *", time.Date(2024, 1, 15, 0, 0, 0, 0, loc)},
+		{"0 8-18/5 * * *", time.Date(2024, 1, 1, 8, 0, 0, 0, loc)},
+		{"0 0 * * 7", time.Date(2024, 1, 7, 0, 0, 0, 0, loc)},
+		{"@monthly", time.Date(2024, 2, 1, 0, 0, 0, 0, loc)},
+		// 日、周同时指定时取并集
+		{"0 0 13 * 5", time.Date(2024, 1, 5, 0, 0, 0, 0, loc)},
+	}
+
+	for _, c := range cases {
+		s, err := parseCronSpec(c.spec, loc)
+		if !assert.NoError(t, err, c.spec) {
+			continue
+		}
+		assert.Equal(t, c.expect, s.next(base), c.spec)
+	}
+}
+
+func TestCronTimeZone(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skip("tzdata not available")
+	}
+
+	s, err := parseCronSpec("CRON_TZ=Asia/Shanghai 0 0 * * *", time.UTC)
+	assert.NoError(t, err)
+	next := s.next(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2024, 1, 2, 0, 0, 0, 0, shanghai).Unix(), next.Unix())
+}
+
+func TestCronInvalidSpec(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* * 0 * *",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"* * * foo *",
+		"@every",
+		"TZ=Nowhere/City * * * * *",
+	} {
+		_, err := parseCronSpec(spec, time.UTC)
+		assert.ErrorIs(t, err, ErrCronSpec, spec)
+	}
+}
+
+func TestCronTrigger(t *testing.T) {
+	trigger, err := NewCronTriggerInLocation("* * * * * *", time.UTC, false)
+	assert.NoError(t, err)
+	assert.False(t, trigger.CanFilter())
+
+	next := trigger.NextFireTime()
+	assert.False(t, trigger.CanTrigger(next.Add(-time.Millisecond)))
+	assert.True(t, trigger.CanTrigger(next))
+	assert.False(t, trigger.CanTrigger(next))
+	assert.Equal(t, next.Add(time.Second), trigger.NextFireTime())
+	assert.True(t, trigger.CanPeriodic())
+
+	times := trigger.NextFireTimes(3)
+	assert.Equal(t, 3, len(times))
+	assert.Equal(t, times[0].Add(2*time.Second), times[2])
+
+	trigger, err = NewCronTrigger("0 * * * *", true)
+	assert.NoError(t, err)
+	assert.True(t, trigger.CanFilter())
+}
@@ -0,0 +1,266 @@
+package task
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+)
+
+// Delayed is implemented by items that must not be retrieved from a
+// DelayQueue before their due time.
+type Delayed interface {
+	DueTime() time.Time
+}
+
+// DelayQueue is a threadsafe queue whose items become visible only after
+// their due time, Get and Poll return due items ordered by due time.
+type DelayQueue struct {
+	waiters  waiters
+	items    *heapItems
+	seq      uint64
+	lock     sync.Mutex
+	disposed bool
+	ctx      context.Context
+}
+
+// NewDelayQueue is a constructor for a new threadsafe delay queue.
+func NewDelayQueue(hint int64) *DelayQueue {
+	return NewDelayQueueWithContext(hint, context.Background())
+}
+
+// NewDelayQueueWithContext is a constructor for a new threadsafe delay queue.
+func NewDelayQueueWithContext(hint int64, ctx context.Context) *DelayQueue {
+	return &DelayQueue{
+		items: &heapItems{items: make([]*heapItem, 0, hint)},
+		ctx:   ctx,
+	}
+}
+
+// Put will add the specified items to the queue, items that implement
+// Delayed become visible at their due time, others (and items with a zero
+// due time) immediately.
+func (q *DelayQueue) Put(items ...interface{}) error {
+	now := time.Now()
+	return q.put(func(item interface{}) time.Time {
+		if d, ok := item.(Delayed); ok {
+			if at := d.DueTime(); !at.IsZero() {
+				return at
+			}
+		}
+		return now
+	}, items)
+}
+
+// PutAt will add the specified items to the queue, they become visible at at.
+func (q *DelayQueue) PutAt(at time.Time, items ...interface{}) error {
+	return q.put(func(interface{}) time.Time { return at }, items)
+}
+
+// PutDelayed will add the specified items to the queue, they become visible
+// after delay.
+func (q *DelayQueue) PutDelayed(delay time.Duration, items ...interface{}) error {
+	return q.PutAt(time.Now().Add(delay), items...)
+}
+
+func (q *DelayQueue) put(dueTime func(interface{}) time.Time, items []interface{}) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	q.lock.Lock()
+
+	if q.disposed {
+		q.lock.Unlock()
+		return ErrDisposed
+	}
+
+	for _, item := range items {
+		q.seq++
+		heap.Push(q.items, &heapItem{value: item, seq: q.seq, at: dueTime(item)})
+	}
+
+	// always wake the first waiter so it can re-arm its timer for an earlier
+	// due time, wake the others only while there are due items
+	first := true
+	for first || q.hasDueLocked() {
+		first = false
+		sema := q.waiters.get()
+		if sema == nil {
+			break
+		}
+		sema.response.Add(1)
+		select {
+		case sema.ready <- true:
+			sema.response.Wait()
+		default:
+			// This semaphore timed out.
+		}
+	}
+
+	q.lock.Unlock()
+	return nil
+}
+
+func (q *DelayQueue) hasDueLocked() bool {
+	head, ok := q.items.peek()
+	return ok && q.due(head)
+}
+
+func (q *DelayQueue) due(item *heapItem) bool {
+	return !item.at.After(time.Now())
+}
+
+// Get retrieves due items from the queue.  If there are some due items in the
+// queue, get will return a number UP TO the number passed in as a
+// parameter.  If no items are due, this method will pause until an item
+// becomes due.
+func (q *DelayQueue) Get(number int64, items []interface{}) (int64, error) {
+	return q.Poll(number, items, 0)
+}
+
+// Poll retrieves due items from the queue.  If there are some due items in the
+// queue, Poll will return a number UP TO the number passed in as a parameter.
+// If no items are due, this method will pause until an item becomes due or
+// the provided timeout is reached.  A non-positive timeout will block until
+// an item becomes due.  If a timeout occurs, ErrTimeout is returned.
+func (q *DelayQueue) Poll(number int64, items []interface{}, timeout time.Duration) (int64, error) {
+	if number < 1 {
+		return 0, nil
+	}
+
+	var timeoutC <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		timeoutC = timer.C
+	}
+
+	q.lock.Lock()
+	for {
+		if q.disposed {
+			q.lock.Unlock()
+			return 0, ErrDisposed
+		}
+
+		if c := q.items.get(number, items, q.due); c > 0 {
+			q.lock.Unlock()
+			select {
+			case <-q.ctx.Done():
+				return 0, ErrDisposed
+			default:
+				return c, nil
+			}
+		}
+
+		// sleep until the earliest item is due
+		var delayC <-chan time.Time
+		delay := time.NewTimer(time.Hour)
+		delay.Stop()
+		if head, ok := q.items.peek(); ok {
+			delay.Reset(time.Until(head.at))
+			delayC = delay.C
+		}
+
+		sema := newSema()
+		q.waiters.put(sema)
+		q.lock.Unlock()
+
+		select {
+		case <-q.ctx.Done():
+			delay.Stop()
+			q.Dispose()
+			return 0, ErrDisposed
+		case <-sema.ready:
+			delay.Stop()
+			// we are now inside the put's lock
+			if q.disposed {
+				return 0, ErrDisposed
+			}
+			c := q.items.get(number, items, q.due)
+			sema.response.Done()
+			if c > 0 {
+				return c, nil
+			}
+		case <-delayC:
+			q.removeWaiter(sema)
+		case <-timeoutC:
+			delay.Stop()
+			q.removeWaiter(sema)
+			return 0, ErrTimeout
+		}
+		q.lock.Lock()
+	}
+}
+
+// removeWaiter cleanup the sema that was added to waiters
+func (q *DelayQueue) removeWaiter(sema *sema) {
+	select {
+	case sema.ready <- true:
+		// we called this before Put() could
+		q.lock.Lock()
+		q.waiters.remove(sema)
+		q.lock.Unlock()
+	default:
+		// Put() got it already, we need to call Done() so Put() can move on
+		sema.response.Done()
+	}
+}
+
+// Peek returns the item with the earliest due time without modifying the
+// queue, the item may not be due yet.
+func (q *DelayQueue) Peek() (interface{}, time.Time, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if q.disposed {
+		return nil, time.Time{}, ErrDisposed
+	}
+
+	item, ok := q.items.peek()
+	if !ok {
+		return nil, time.Time{}, ErrEmptyQueue
+	}
+	return item.value, item.at, nil
+}
+
+// Empty returns a bool indicating if this queue is empty.
+func (q *DelayQueue) Empty() bool {
+	return q.Len() == 0
+}
+
+// Len returns the number of items in this queue, including items not yet due.
+func (q *DelayQueue) Len() int64 {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return int64(q.items.Len())
+}
+
+// Disposed returns a bool indicating if this queue has had disposed called on it.
+func (q *DelayQueue) Disposed() bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return q.disposed
+}
+
+// Dispose will dispose of this queue and returns the items disposed. Any
+// subsequent calls to Get or Put will return an errors.
+func (q *DelayQueue) Dispose() []interface{} {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if q.disposed {
+		return nil
+	}
+
+	q.disposed = true
+	disposeWaiters(q.waiters)
+
+	disposedItems := q.items.values()
+	q.items.items = nil
+	q.waiters = nil
+
+	return disposedItems
+}
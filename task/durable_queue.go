@@ -0,0 +1,509 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/XingMenTech/common/redis"
+	"github.com/XingMenTech/common/utils"
+)
+
+var (
+	// ErrUnknownJobType is returned when a durable job type has no registered handler.
+	ErrUnknownJobType = errors.New(`durable queue: unknown job type`)
+)
+
+var (
+	defaultVisibilityTimeout = 30 * time.Second
+	defaultMaxAttempts       = 5
+	defaultBackoffBase       = time.Second
+	defaultBackoffMax        = 10 * time.Minute
+	defaultPollTimeout       = 1 // seconds, BRPOPLPUSH timeout
+	maintenanceBatch         = 100
+
+	durableHandlers     = make(map[string]func(json.RawMessage) error)
+	durableHandlersLock sync.RWMutex
+)
+
+var (
+	// reapScript re-delivers jobs whose visibility timeout expired and sets a
+	// deadline on jobs that were moved to processing without one (the consumer
+	// crashed right after BRPOPLPUSH). A taken job already counts its delivery
+	// in attempts, so an expired job that reached max attempts is moved to the
+	// dead-letter list instead; lastError is appended as a duplicate key, the
+	// last one wins when decoding.
+	reapScript = redis.NewScript(`
+local expired = redis.call("ZRANGEBYSCORE", KEYS[2], "-inf", ARGV[1], "LIMIT", 0, ARGV[2])
+for _, raw in ipairs(expired) do
+	redis.call("ZREM", KEYS[2], raw)
+	if redis.call("LREM", KEYS[1], 1, raw) > 0 then
+		local ok, job = pcall(cjson.decode, raw)
+		if ok and type(job) == "table" and (tonumber(job.attempts) or 0) < tonumber(ARGV[4]) then
+			redis.call("RPUSH", KEYS[3], raw)
+		else
+			redis.call("LPUSH", KEYS[4], string.sub(raw, 1, -2) .. ',"lastError":"visibility timeout expired"}')
+		end
+	end
+end
+local oldest = redis.call("LRANGE", KEYS[1], -tonumber(ARGV[2]), -1)
+for _, raw in ipairs(oldest) do
+	if not redis.call("ZSCORE", KEYS[2], raw) then
+		redis.call("ZADD", KEYS[2], ARGV[1] + ARGV[3], raw)
+	end
+end
+return #expired`)
+
+	// takeScript replaces a job in processing with its copy that counts the
+	// current delivery and sets its deadline.
+	takeScript = redis.NewScript(`
+if redis.call("LREM", KEYS[1], 1, ARGV[1]) == 0 then
+	return 0
+end
+redis.call("ZREM", KEYS[2], ARGV[1])
+redis.call("LPUSH", KEYS[1], ARGV[2])
+redis.call("ZADD", KEYS[2], ARGV[3], ARGV[2])
+return 1`)
+
+	// extendScript pushes back the deadline of a job that is still being
+	// executed, returns 0 if the job is no longer in processing.
+	extendScript = redis.NewScript(`
+if redis.call("ZSCORE", KEYS[1], ARGV[1]) then
+	redis.call("ZADD", KEYS[1], ARGV[2], ARGV[1])
+	return 1
+end
+return 0`)
+
+	// promoteScript moves delayed jobs that are due into the ready list.
+	promoteScript = redis.NewScript(`
+local due = redis.call("ZRANGEBYSCORE", KEYS[1], "-inf", ARGV[1], "LIMIT", 0, ARGV[2])
+for _, raw in ipairs(due) do
+	redis.call("ZREM", KEYS[1], raw)
+	redis.call("LPUSH", KEYS[2], raw)
+end
+return #due`)
+
+	// ackScript removes a job from processing.
+	ackScript = redis.NewScript(`
+redis.call("ZREM", KEYS[2], ARGV[1])
+return redis.call("LREM", KEYS[1], 1, ARGV[1])`)
+
+	// retryScript removes a job from processing and schedules its next attempt.
+	retryScript = redis.NewScript(`
+redis.call("ZREM", KEYS[2], ARGV[1])
+if redis.call("LREM", KEYS[1], 1, ARGV[1]) > 0 then
+	redis.call("ZADD", KEYS[3], ARGV[3], ARGV[2])
+	return 1
+end
+return 0`)
+
+	// buryScript removes a job from processing and pushes it to the dead-letter list.
+	buryScript = redis.NewScript(`
+redis.call("ZREM", KEYS[2], ARGV[1])
+if redis.call("LREM", KEYS[1], 1, ARGV[1]) > 0 then
+	redis.call("LPUSH", KEYS[3], ARGV[2])
+	return 1
+end
+return 0`)
+)
+
+// RegisterDurableJob registers the handler of a durable job type, the payload
+// is decoded from JSON into T before the handler is called.
+func RegisterDurableJob[T any](jobType string, fn func(arg T) error) {
+	durableHandlersLock.Lock()
+	defer durableHandlersLock.Unlock()
+	durableHandlers[jobType] = func(payload json.RawMessage) error {
+		var arg T
+		if err := json.Unmarshal(payload, &arg); err != nil {
+			return err
+		}
+		return fn(arg)
+	}
+}
+
+func getDurableHandler(jobType string) (func(json.RawMessage) error, bool) {
+	durableHandlersLock.RLock()
+	defer durableHandlersLock.RUnlock()
+	fn, ok := durableHandlers[jobType]
+	return fn, ok
+}
+
+// DurableJob is the serialized form of a job stored in redis
+type DurableJob struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Desc       string          `json:"desc,omitempty"`
+	Payload    json.RawMessage `json:"payload"`
+	Attempts   int             `json:"attempts"`
+	EnqueuedAt int64           `json:"enqueuedAt"`
+	LastError  string          `json:"lastError,omitempty"`
+}
+
+// DurableQueueOption is durable queue create option
+type DurableQueueOption func(*durableQueueOptions)
+
+type durableQueueOptions struct {
+	visibilityTimeout time.Duration
+	maxAttempts       int
+	backoffBase       time.Duration
+	backoffMax        time.Duration
+}
+
+// WithVisibilityTimeout returns an option that sets how long a taken job stays
+// invisible before it is delivered again
+func WithVisibilityTimeout(timeout time.Duration) DurableQueueOption {
+	return func(opts *durableQueueOptions) {
+		opts.visibilityTimeout = timeout
+	}
+}
+
+// WithMaxAttempts returns an option that sets how many times a job is executed
+// before it is moved to the dead-letter list
+func WithMaxAttempts(n int) DurableQueueOption {
+	return func(opts *durableQueueOptions) {
+		opts.maxAttempts = n
+	}
+}
+
+// WithBackoff returns an option that sets the exponential retry backoff
+func WithBackoff(base, max time.Duration) DurableQueueOption {
+	return func(opts *durableQueueOptions) {
+		opts.backoffBase = base
+		opts.backoffMax = max
+	}
+}
+
+// DurableQueue is a reliable job queue backed by redis lists. Jobs are moved
+// atomically from the ready list to a processing list and are only removed
+// after the handler returns, so delivery is at-least-once. While a handler is
+// running its visibility timeout is extended periodically, a job is only
+// delivered again when its consumer stopped extending it, e.g. crashed.
+type DurableQueue struct {
+	c             *redis.Client
+	name          string
+	readyKey      string
+	processingKey string
+	deadlinesKey  string
+	delayedKey    string
+	deadKey       string
+	opts          *durableQueueOptions
+}
+
+// NewDurableQueue returns a durable queue stored in c, the default redis
+// instance is used when c is nil, all keys are stored under
+// "<prefix>:queue:{<name>}:*"
+func NewDurableQueue(c *redis.Client, name string, opts ...DurableQueueOption) *DurableQueue {
+	qopts := &durableQueueOptions{
+		visibilityTimeout: defaultVisibilityTimeout,
+		maxAttempts:       defaultMaxAttempts,
+		backoffBase:       defaultBackoffBase,
+		backoffMax:        defaultBackoffMax,
+	}
+	for _, opt := range opts {
+		opt(qopts)
+	}
+
+	// hash tag keeps all keys of the queue in one cluster slot
+	base := "queue:{" + name + "}"
+	return &DurableQueue{
+		c:             c,
+		name:          name,
+		readyKey:      base + ":ready",
+		processingKey: base + ":processing",
+		deadlinesKey:  base + ":deadlines",
+		delayedKey:    base + ":delayed",
+		deadKey:       base + ":dead",
+		opts:          qopts,
+	}
+}
+
+// redis returns the client of the queue, resolved on use so the queue can be
+// created before InitRedisCache
+func (q *DurableQueue) redis() *redis.Client {
+	if q.c != nil {
+		return q.c
+	}
+	return redis.Default()
+}
+
+// Name returns the queue name
+func (q *DurableQueue) Name() string {
+	return q.name
+}
+
+// Enqueue adds a job to the queue and returns its id
+func (q *DurableQueue) Enqueue(ctx context.Context, desc, jobType string, arg interface{}) (string, error) {
+	job, raw, err := newDurableJob(desc, jobType, arg)
+	if err != nil {
+		return "", err
+	}
+	return job.ID, q.redis().LPush(ctx, q.readyKey, raw)
+}
+
+// EnqueueIn adds a job that becomes visible after delay
+func (q *DurableQueue) EnqueueIn(ctx context.Context, desc, jobType string, arg interface{}, delay time.Duration) (string, error) {
+	job, raw, err := newDurableJob(desc, jobType, arg)
+	if err != nil {
+		return "", err
+	}
+	return job.ID, q.redis().ZAddByScore(ctx, q.delayedKey, raw, float64(time.Now().Add(delay).UnixMilli()))
+}
+
+func newDurableJob(desc, jobType string, arg interface{}) (*DurableJob, string, error) {
+	payload, err := json.Marshal(arg)
+	if err != nil {
+		return nil, "", err
+	}
+	job := &DurableJob{
+		ID:         strconv.FormatInt(time.Now().UnixNano(), 36) + utils.RandomString(6),
+		Type:       jobType,
+		Desc:       desc,
+		Payload:    payload,
+		EnqueuedAt: time.Now().UnixMilli(),
+	}
+	raw, err := json.Marshal(job)
+	if err != nil {
+		return nil, "", err
+	}
+	return job, string(raw), nil
+}
+
+// Len returns the number of jobs waiting to be executed
+func (q *DurableQueue) Len(ctx context.Context) (int64, error) {
+	return q.redis().LLen(ctx, q.readyKey)
+}
+
+// ProcessingLen returns the number of jobs being executed
+func (q *DurableQueue) ProcessingLen(ctx context.Context) (int64, error) {
+	return q.redis().LLen(ctx, q.processingKey)
+}
+
+// DelayedLen returns the number of jobs waiting for delay or retry
+func (q *DurableQueue) DelayedLen(ctx context.Context) (int64, error) {
+	return q.redis().ZCard(ctx, q.delayedKey)
+}
+
+// DeadLetters returns jobs in the dead-letter list
+func (q *DurableQueue) DeadLetters(ctx context.Context, start, stop int64) ([]*DurableJob, error) {
+	// jobs are stored as JSON whatever the codec of the client is
+	arr, err := redis.As[string](q.redis()).LRange(ctx, q.deadKey, start, stop)
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]*DurableJob, 0, len(arr))
+	for _, raw := range arr {
+		job := &DurableJob{}
+		if err = json.Unmarshal([]byte(raw), job); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// RetryDeadLetters moves up to n dead jobs back to the ready list with their
+// attempts reset
+func (q *DurableQueue) RetryDeadLetters(ctx context.Context, n int) (int, error) {
+	c := q.redis()
+	moved := 0
+	for ; moved < n; moved++ {
+		raw, err := redis.As[string](c).RPop(ctx, q.deadKey)
+		if err == redis.Nil {
+			break
+		}
+		if err != nil {
+			return moved, err
+		}
+		job := &DurableJob{}
+		if err = json.Unmarshal([]byte(raw), job); err != nil {
+			return moved, err
+		}
+		job.Attempts = 0
+		job.LastError = ""
+		next, err := json.Marshal(job)
+		if err != nil {
+			return moved, err
+		}
+		if err = c.LPush(ctx, q.readyKey, string(next)); err != nil {
+			return moved, err
+		}
+	}
+	return moved, nil
+}
+
+// Consume takes jobs from the queue and executes them one by one until ctx is done
+func (q *DurableQueue) Consume(ctx context.Context) {
+	q.consume(ctx, newWorkerStats())
+}
+
+func (q *DurableQueue) consume(ctx context.Context, stats *workerStats) {
+	maintain := time.NewTicker(time.Second)
+	defer maintain.Stop()
+
+	q.maintain(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-maintain.C:
+			q.maintain(ctx)
+		default:
+		}
+
+		raw, err := q.redis().BRPopLPush(ctx, q.readyKey, q.processingKey, defaultPollTimeout)
+		if err != nil {
+			if err != redis.Nil {
+				// redis is unavailable, avoid a busy loop
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(time.Second):
+				}
+			}
+			continue
+		}
+		q.process(ctx, raw, stats)
+	}
+}
+
+// maintain promotes due delayed jobs and re-delivers expired jobs
+func (q *DurableQueue) maintain(ctx context.Context) {
+	c := q.redis()
+	now := time.Now().UnixMilli()
+	promoteScript.RunOn(ctx, c, []string{q.delayedKey, q.readyKey}, now, maintenanceBatch)
+	reapScript.RunOn(ctx, c, []string{q.processingKey, q.deadlinesKey, q.readyKey, q.deadKey},
+		now, maintenanceBatch, q.opts.visibilityTimeout.Milliseconds(), q.opts.maxAttempts)
+}
+
+func (q *DurableQueue) process(ctx context.Context, raw string, stats *workerStats) {
+	job, taken, fn := q.take(ctx, raw)
+	if job == nil {
+		return
+	}
+
+	// the job is still being executed, keep it invisible to the reaper
+	stop := q.keepAlive(taken)
+	start := stats.begin(job.Desc, time.UnixMilli(job.EnqueuedAt))
+	err := q.execute(fn, job)
+	stop()
+	// record the outcome even if the worker is being stopped
+	ctx = context.WithoutCancel(ctx)
+	if err != nil {
+		stats.end(start, Failed)
+		if job.Attempts >= q.opts.maxAttempts {
+			q.bury(ctx, taken, job, err)
+			return
+		}
+		q.retry(ctx, taken, job, err)
+		return
+	}
+
+	stats.end(start, Finished)
+	ackScript.RunOn(ctx, q.redis(), []string{q.processingKey, q.deadlinesKey}, taken)
+}
+
+// take counts the delivery of a job moved to processing and sets its
+// deadline, returns nil if the job can not be executed
+func (q *DurableQueue) take(ctx context.Context, raw string) (*DurableJob, string, func(json.RawMessage) error) {
+	job := &DurableJob{}
+	if err := json.Unmarshal([]byte(raw), job); err != nil {
+		// can never succeed, keep the raw data for inspection
+		buryScript.RunOn(ctx, q.redis(), []string{q.processingKey, q.deadlinesKey, q.deadKey}, raw, raw)
+		return nil, "", nil
+	}
+
+	fn, ok := getDurableHandler(job.Type)
+	if !ok {
+		q.bury(ctx, raw, job, ErrUnknownJobType)
+		return nil, "", nil
+	}
+
+	// count the delivery before executing, so a job that keeps crashing the
+	// consumer is buried by the reaper after max attempts
+	job.Attempts++
+	taken, err := json.Marshal(job)
+	if err != nil {
+		return nil, "", nil
+	}
+	deadline := time.Now().Add(q.opts.visibilityTimeout).UnixMilli()
+	n, err := takeScript.RunOn(ctx, q.redis(), []string{q.processingKey, q.deadlinesKey}, raw, string(taken), deadline).Int()
+	if err != nil || n == 0 {
+		// re-delivered by the reaper or redis is unavailable, the job stays in redis
+		return nil, "", nil
+	}
+	return job, string(taken), fn
+}
+
+// keepAlive extends the deadline of a running job every third of the
+// visibility timeout until the returned function is called
+func (q *DurableQueue) keepAlive(raw string) func() {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(q.opts.visibilityTimeout / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			deadline := time.Now().Add(q.opts.visibilityTimeout).UnixMilli()
+			n, err := extendScript.RunOn(context.Background(), q.redis(), []string{q.deadlinesKey}, raw, deadline).Int()
+			if err == nil && n == 0 {
+				// already re-delivered
+				return
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
+
+func (q *DurableQueue) execute(fn func(json.RawMessage) error, job *DurableJob) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("durable job %s panic: %v", job.ID, r)
+		}
+	}()
+	return fn(job.Payload)
+}
+
+func (q *DurableQueue) retry(ctx context.Context, raw string, job *DurableJob, cause error) {
+	job.LastError = cause.Error()
+	next, err := json.Marshal(job)
+	if err != nil {
+		return
+	}
+	at := time.Now().Add(q.backoff(job.Attempts)).UnixMilli()
+	retryScript.RunOn(ctx, q.redis(), []string{q.processingKey, q.deadlinesKey, q.delayedKey}, raw, string(next), at)
+}
+
+func (q *DurableQueue) bury(ctx context.Context, raw string, job *DurableJob, cause error) {
+	job.LastError = cause.Error()
+	dead, err := json.Marshal(job)
+	if err != nil {
+		dead = []byte(raw)
+	}
+	buryScript.RunOn(ctx, q.redis(), []string{q.processingKey, q.deadlinesKey, q.deadKey}, raw, string(dead))
+}
+
+// backoff returns base * 2^(attempts-1), capped at max
+func (q *DurableQueue) backoff(attempts int) time.Duration {
+	d := q.opts.backoffBase
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= q.opts.backoffMax {
+			return q.opts.backoffMax
+		}
+	}
+	return d
+}
@@ -0,0 +1,298 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/XingMenTech/common/redis"
+	"github.com/XingMenTech/common/redis/redistest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDurableQueueBackoff(t *testing.T) {
+	q := NewDurableQueue(nil, "backoff", WithBackoff(time.Second, 5*time.Second))
+	assert.Equal(t, time.Second, q.backoff(1))
+	assert.Equal(t, 2*time.Second, q.backoff(2))
+	assert.Equal(t, 4*time.Second, q.backoff(3))
+	assert.Equal(t, 5*time.Second, q.backoff(4))
+	assert.Equal(t, 5*time.Second, q.backoff(10))
+}
+
+func TestRegisterDurableJob(t *testing.T) {
+	type payout struct {
+		OrderNo string `json:"orderNo"`
+		Amount  int64  `json:"amount"`
+	}
+
+	var got payout
+	RegisterDurableJob("test-payout", func(arg payout) error {
+		got = arg
+		return nil
+	})
+
+	fn, ok := getDurableHandler("test-payout")
+	assert.True(t, ok)
+
+	payload, _ := json.Marshal(&payout{OrderNo: "A001", Amount: 100})
+	assert.NoError(t, fn(payload))
+	assert.Equal(t, payout{OrderNo: "A001", Amount: 100}, got)
+
+	_, ok = getDurableHandler("missing")
+	assert.False(t, ok)
+}
+
+// newTestDurableQueue returns a queue stored in a fresh in-process redis
+func newTestDurableQueue(t *testing.T, name string, opts ...DurableQueueOption) *DurableQueue {
+	srv := redistest.Run(t)
+	c, err := redis.NewClient(&redis.Config{Prefix: "durable", Host: srv.Addr()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return NewDurableQueue(c, name, opts...)
+}
+
+// takeOne moves the next ready job to processing like BRPOPLPUSH of a consumer
+func takeOne(t *testing.T, q *DurableQueue) string {
+	raw, err := redis.As[string](q.c).RPopLPush(context.Background(), q.readyKey, q.processingKey)
+	if err != nil {
+		t.Errorf("take from %s: %v", q.name, err)
+	}
+	return raw
+}
+
+// processOne takes the next ready job and executes it like a consumer
+func processOne(t *testing.T, q *DurableQueue, stats *workerStats) {
+	if raw := takeOne(t, q); raw != "" {
+		q.process(context.Background(), raw, stats)
+	}
+}
+
+func queueLen(q *DurableQueue, fn func(*DurableQueue, context.Context) (int64, error)) int64 {
+	n, _ := fn(q, context.Background())
+	return n
+}
+
+func TestDurableQueueConsume(t *testing.T) {
+	ctx := context.Background()
+	q := newTestDurableQueue(t, "test-consume")
+
+	got := make(chan string, 1)
+	RegisterDurableJob("test-consume-payout", func(arg map[string]string) error {
+		got <- arg["orderNo"]
+		return nil
+	})
+	id, err := q.Enqueue(ctx, "payout", "test-consume-payout", map[string]string{"orderNo": "A001"})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, id)
+	assert.Equal(t, int64(1), queueLen(q, (*DurableQueue).Len))
+
+	consumeCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		q.Consume(consumeCtx)
+		close(done)
+	}()
+	select {
+	case orderNo := <-got:
+		assert.Equal(t, "A001", orderNo)
+	case <-time.After(2 * time.Second):
+		t.Fatal("job not consumed")
+	}
+	cancel()
+	<-done
+
+	// acked jobs leave no trace
+	assert.Equal(t, int64(0), queueLen(q, (*DurableQueue).Len))
+	assert.Equal(t, int64(0), queueLen(q, (*DurableQueue).ProcessingLen))
+	n, _ := q.c.ZCard(ctx, q.deadlinesKey)
+	assert.Equal(t, int64(0), n)
+}
+
+func TestDurableQueueRetry(t *testing.T) {
+	ctx := context.Background()
+	q := newTestDurableQueue(t, "test-retry", WithBackoff(20*time.Millisecond, time.Second))
+
+	var calls int
+	RegisterDurableJob("test-retry-flaky", func(arg int) error {
+		if calls++; calls < 2 {
+			return errors.New("db down")
+		}
+		return nil
+	})
+	_, err := q.Enqueue(ctx, "flaky", "test-retry-flaky", 1)
+	assert.NoError(t, err)
+
+	stats := newWorkerStats()
+	processOne(t, q, stats)
+	assert.Equal(t, int64(0), queueLen(q, (*DurableQueue).ProcessingLen))
+	assert.Equal(t, int64(1), queueLen(q, (*DurableQueue).DelayedLen))
+
+	// not visible before the backoff elapsed
+	q.maintain(ctx)
+	assert.Equal(t, int64(0), queueLen(q, (*DurableQueue).Len))
+	time.Sleep(30 * time.Millisecond)
+	q.maintain(ctx)
+	assert.Equal(t, int64(1), queueLen(q, (*DurableQueue).Len))
+
+	processOne(t, q, stats)
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, int64(0), queueLen(q, (*DurableQueue).DelayedLen))
+	assert.Equal(t, int64(0), queueLen(q, (*DurableQueue).ProcessingLen))
+}
+
+func TestDurableQueueDeadLetters(t *testing.T) {
+	ctx := context.Background()
+	q := newTestDurableQueue(t, "test-dead", WithMaxAttempts(2), WithBackoff(time.Millisecond, time.Millisecond))
+
+	var calls int
+	RegisterDurableJob("test-dead-broken", func(arg int) error {
+		calls++
+		return errors.New("always fails")
+	})
+	_, err := q.Enqueue(ctx, "broken", "test-dead-broken", 1)
+	assert.NoError(t, err)
+	_, err = q.Enqueue(ctx, "unknown", "test-dead-unknown", 1)
+	assert.NoError(t, err)
+
+	stats := newWorkerStats()
+	processOne(t, q, stats)
+	processOne(t, q, stats)
+	time.Sleep(5 * time.Millisecond)
+	q.maintain(ctx)
+	processOne(t, q, stats)
+	assert.Equal(t, 2, calls)
+
+	dead, err := q.DeadLetters(ctx, 0, -1)
+	assert.NoError(t, err)
+	if assert.Equal(t, 2, len(dead)) {
+		assert.Equal(t, "test-dead-broken", dead[0].Type)
+		assert.Equal(t, 2, dead[0].Attempts)
+		assert.Equal(t, "always fails", dead[0].LastError)
+		assert.Equal(t, "test-dead-unknown", dead[1].Type)
+		assert.Equal(t, ErrUnknownJobType.Error(), dead[1].LastError)
+	}
+
+	// retried dead letters start over
+	moved, err := q.RetryDeadLetters(ctx, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, moved)
+	assert.Equal(t, int64(2), queueLen(q, (*DurableQueue).Len))
+	raws, _ := redis.As[string](q.c).LRange(ctx, q.readyKey, 0, -1)
+	for _, raw := range raws {
+		job := &DurableJob{}
+		assert.NoError(t, json.Unmarshal([]byte(raw), job))
+		assert.Equal(t, 0, job.Attempts)
+		assert.Empty(t, job.LastError)
+	}
+}
+
+func TestDurableQueueReap(t *testing.T) {
+	ctx := context.Background()
+	q := newTestDurableQueue(t, "test-reap", WithVisibilityTimeout(20*time.Millisecond), WithMaxAttempts(2))
+
+	RegisterDurableJob("test-reap-crash", func(arg int) error {
+		return nil
+	})
+	_, err := q.Enqueue(ctx, "crash", "test-reap-crash", 1)
+	assert.NoError(t, err)
+
+	// the consumer crashes after taking the job, the job is re-delivered
+	job, _, _ := q.take(ctx, takeOne(t, q))
+	assert.NotNil(t, job)
+	time.Sleep(30 * time.Millisecond)
+	q.maintain(ctx)
+	assert.Equal(t, int64(1), queueLen(q, (*DurableQueue).Len))
+	assert.Equal(t, int64(0), queueLen(q, (*DurableQueue).ProcessingLen))
+
+	// the second delivery also expires and reaches max attempts
+	job, _, _ = q.take(ctx, takeOne(t, q))
+	assert.NotNil(t, job)
+	time.Sleep(30 * time.Millisecond)
+	q.maintain(ctx)
+	assert.Equal(t, int64(0), queueLen(q, (*DurableQueue).Len))
+
+	dead, err := q.DeadLetters(ctx, 0, -1)
+	assert.NoError(t, err)
+	if assert.Equal(t, 1, len(dead)) {
+		assert.Equal(t, 2, dead[0].Attempts)
+		assert.Equal(t, "visibility timeout expired", dead[0].LastError)
+	}
+}
+
+func TestDurableQueueKeepAlive(t *testing.T) {
+	ctx := context.Background()
+	q := newTestDurableQueue(t, "test-keepalive", WithVisibilityTimeout(30*time.Millisecond))
+
+	var calls int32
+	RegisterDurableJob("test-keepalive-slow", func(arg int) error {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(150 * time.Millisecond)
+		return nil
+	})
+	_, err := q.Enqueue(ctx, "slow", "test-keepalive-slow", 1)
+	assert.NoError(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		processOne(t, q, newWorkerStats())
+		close(done)
+	}()
+
+	// a running job is not re-delivered although it outlives the visibility timeout
+	for i := 0; i < 10; i++ {
+		time.Sleep(15 * time.Millisecond)
+		q.maintain(ctx)
+		assert.Equal(t, int64(0), queueLen(q, (*DurableQueue).Len))
+	}
+	<-done
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	assert.Equal(t, int64(0), queueLen(q, (*DurableQueue).ProcessingLen))
+}
+
+func TestRunnerDurableWorker(t *testing.T) {
+	q := newTestDurableQueue(t, "test-runner")
+	runner := NewRunner()
+	defer runner.Stop()
+
+	got := make(chan int, 1)
+	RegisterDurableJob("test-runner-double", func(arg int) error {
+		got <- arg * 2
+		return nil
+	})
+	_, err := runner.AddDurableWorker("durable", q, nil)
+	assert.NoError(t, err)
+
+	assert.False(t, runner.IsNamedWorkerBusy("durable"))
+	assert.False(t, runner.IsNamedWorkerBusy("missing"))
+
+	// jobs waiting behind a running job keep the worker busy
+	started, release := make(chan struct{}), make(chan struct{})
+	RegisterDurableJob("test-runner-block", func(arg int) error {
+		close(started)
+		<-release
+		return nil
+	})
+	assert.NoError(t, runner.RunDurableJob("durable", "test-runner-block", 0))
+	<-started
+	assert.NoError(t, runner.RunDurableJob("durable", "test-runner-double", 21))
+	assert.True(t, runner.IsNamedWorkerBusy("durable"))
+	close(release)
+	select {
+	case n := <-got:
+		assert.Equal(t, 42, n)
+	case <-time.After(2 * time.Second):
+		t.Fatal("durable job not executed")
+	}
+
+	// closures can not be persisted and are never called
+	assert.Error(t, runner.RunJobWithNamedWorker("closure", "durable", func() error {
+		t.Error("closure called by a durable worker")
+		return nil
+	}))
+	assert.Error(t, runner.RunDurableJob("missing", "test-runner-double", 1))
+}
@@ -0,0 +1,281 @@
+// 跨进程事件桥
+// 将事件总线上选定类型的事件发布到redis频道，并把其他节点发布的事件重新投递到本地总线
+// 示例
+// bridge := task.NewRedisEventBridge(task.NewEventBus(), "cache-invalidation")
+// task.BridgeEvent[*CacheInvalidated](bridge, "")
+// bridge.Start()
+// 事件内容使用redis客户端的编码方式，消息外层固定为JSON，各节点须使用相同的编码方式
+
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/XingMenTech/common/logger"
+	"github.com/XingMenTech/common/redis"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	bridgeMinBackoff = 100 * time.Millisecond
+	bridgeMaxBackoff = 30 * time.Second
+)
+
+// bridgeEnvelope /频道消息，Origin 用于丢弃本节点发出的消息
+// Payload 为客户端编码后的事件，可能是二进制，以 []byte 保存在JSON中
+type bridgeEnvelope struct {
+	Origin  string `json:"origin"`
+	Event   string `json:"event"`
+	Payload []byte `json:"payload"`
+}
+
+type bridgedEvent struct {
+	key       reflect.Type
+	decode    func(redis.Codec, string) (interface{}, error)
+	forwarder *bridgeForwarder
+}
+
+// bridgeForwarder /注册到本地总线的监听器，将事件发布到redis
+type bridgeForwarder struct {
+	bridge *RedisEventBridge
+	name   string
+}
+
+func (f *bridgeForwarder) Notify(param interface{}) {
+	_ = f.notifyWithError(param)
+}
+
+func (f *bridgeForwarder) notifyWithError(param interface{}) error {
+	return f.bridge.forward(f.name, param)
+}
+
+// RedisEventBridge /基于redis发布订阅的事件桥
+type RedisEventBridge struct {
+	sync.RWMutex
+	bus      *EventBus
+	client   *redis.Client
+	channel  string
+	nodeID   string
+	events   map[string]*bridgedEvent
+	priority int
+	log      *logrus.Entry
+	publish  func(channel string, msg interface{}) error
+
+	pubSub    io.Closer
+	stopped   bool
+	startOnce sync.Once
+	stopOnce  sync.Once
+	done      chan struct{}
+	wg        *sync.WaitGroup
+}
+
+// NewRedisEventBridge /工厂方法，使用默认redis实例，channel 为各节点共用的频道
+func NewRedisEventBridge(bus *EventBus, channel string) *RedisEventBridge {
+	return NewRedisEventBridgeWithClient(nil, bus, channel)
+}
+
+// NewRedisEventBridgeWithClient /工厂方法，c 为空时使用默认redis实例
+func NewRedisEventBridgeWithClient(c *redis.Client, bus *EventBus, channel string) *RedisEventBridge {
+	object := &RedisEventBridge{
+		bus:      bus,
+		client:   c,
+		channel:  channel,
+		nodeID:   redis.NewNodeID(),
+		events:   make(map[string]*bridgedEvent),
+		priority: 1,
+		log:      logger.LOG.WithField("module", "RedisEventBridge"),
+		done:     make(chan struct{}),
+		wg:       &sync.WaitGroup{},
+	}
+	object.publish = func(channel string, msg interface{}) error {
+		return object.redis().Publish(context.Background(), channel, msg)
+	}
+	return object
+}
+
+// /redis实例，未指定时使用默认实例
+func (object *RedisEventBridge) redis() *redis.Client {
+	if nil != object.client {
+		return object.client
+	}
+	return redis.Default()
+}
+
+// BridgeEvent /转发类型为T的事件，name 为跨进程的事件名，为空时使用类型名
+// 各节点须以相同的 name 注册同一事件
+func BridgeEvent[T any](bridge *RedisEventBridge, name string) {
+	key := EventKey[T]()
+	if "" == name {
+		name = key.String()
+	}
+	forwarder := &bridgeForwarder{bridge: bridge, name: name}
+
+	bridge.Lock()
+	if _, ok := bridge.events[name]; ok {
+		bridge.Unlock()
+		panic(fmt.Sprintf("event %s already bridged", name))
+	}
+	bridge.events[name] = &bridgedEvent{
+		key: key,
+		decode: func(codec redis.Codec, payload string) (interface{}, error) {
+			return redis.DecodeWith[T](codec, payload)
+		},
+		forwarder: forwarder,
+	}
+	bridge.Unlock()
+
+	bridge.bus.Register(key, forwarder)
+}
+
+// NodeID /当前节点标识
+func (object *RedisEventBridge) NodeID() string {
+	return object.nodeID
+}
+
+// Start /订阅频道
+func (object *RedisEventBridge) Start() {
+	object.startOnce.Do(func() {
+		object.wg.Add(1)
+		go object.loop()
+	})
+}
+
+// Stop /取消订阅并停止转发
+func (object *RedisEventBridge) Stop() {
+	object.stopOnce.Do(func() {
+		object.Lock()
+		object.stopped = true
+		for _, event := range object.events {
+			object.bus.Unregister(event.key, event.forwarder)
+		}
+		if nil != object.pubSub {
+			object.pubSub.Close()
+		}
+		object.Unlock()
+		close(object.done)
+		object.wg.Wait()
+	})
+}
+
+// /将本地事件发布到频道
+func (object *RedisEventBridge) forward(name string, param interface{}) error {
+	payload, err := redis.EncodeWith(object.redis().Codec(), param)
+	if err != nil {
+		return fmt.Errorf("encode event %s: %w", name, err)
+	}
+	data, err := json.Marshal(&bridgeEnvelope{
+		Origin:  object.nodeID,
+		Event:   name,
+		Payload: []byte(payload),
+	})
+	if err != nil {
+		return err
+	}
+	return object.publish(object.channel, string(data))
+}
+
+// /订阅循环，连接断开后按指数退避重新订阅
+func (object *RedisEventBridge) loop() {
+	defer object.wg.Done()
+
+	backoff := bridgeMinBackoff
+	for {
+		pubSub := object.redis().Subscribe(context.Background(), object.channel)
+		if !object.setPubSub(pubSub) {
+			pubSub.Close()
+			return
+		}
+		for {
+			msg, err := pubSub.ReceiveMessage(context.Background())
+			if err != nil {
+				if !object.isStopped() {
+					object.log.Warnf("receive from %s failed, resubscribe in %v: %v", object.channel, backoff, err)
+				}
+				break
+			}
+			backoff = bridgeMinBackoff
+			object.handleMessage(msg.Payload)
+		}
+		pubSub.Close()
+
+		select {
+		case <-object.done:
+			return
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > bridgeMaxBackoff {
+			backoff = bridgeMaxBackoff
+		}
+	}
+}
+
+func (object *RedisEventBridge) setPubSub(pubSub io.Closer) bool {
+	object.Lock()
+	defer object.Unlock()
+	if object.stopped {
+		return false
+	}
+	object.pubSub = pubSub
+	return true
+}
+
+func (object *RedisEventBridge) isStopped() bool {
+	object.RLock()
+	defer object.RUnlock()
+	return object.stopped
+}
+
+// /将其他节点的事件投递到本地总线，不再转发回频道
+func (object *RedisEventBridge) handleMessage(data string) {
+	var envelope bridgeEnvelope
+	if err := json.Unmarshal([]byte(data), &envelope); err != nil {
+		object.log.Errorf("decode message from %s failed: %v", object.channel, err)
+		return
+	}
+	if envelope.Origin == object.nodeID {
+		return
+	}
+
+	object.RLock()
+	event, ok := object.events[envelope.Event]
+	object.RUnlock()
+	if !ok {
+		return
+	}
+
+	param, err := event.decode(object.redis().Codec(), string(envelope.Payload))
+	if err != nil {
+		object.bus.reportError(event.key, envelope.Payload, fmt.Errorf("decode event %s: %w", envelope.Event, err))
+		return
+	}
+	object.bus.notifyExcept(event.key, param, event.forwarder)
+}
+
+// Name /名字
+func (object *RedisEventBridge) Name() string {
+	return "RedisEventBridge"
+}
+
+// SetShutdownPriority /设置关闭优先级
+func (object *RedisEventBridge) SetShutdownPriority(priority int) {
+	object.priority = priority
+}
+
+// ShutdownPriority /关闭优先级，默认先于事件总线关闭
+func (object *RedisEventBridge) ShutdownPriority() int {
+	return object.priority
+}
+
+// BeforeShutdown /关闭之前
+func (object *RedisEventBridge) BeforeShutdown() {
+	object.Stop()
+}
+
+// AfterShutdown /关闭之后
+func (object *RedisEventBridge) AfterShutdown() {}
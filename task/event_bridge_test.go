@@ -0,0 +1,130 @@
+package task
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/XingMenTech/common/redis"
+	"github.com/XingMenTech/common/redis/redistest"
+	"github.com/stretchr/testify/assert"
+)
+
+type cacheInvalidated struct {
+	Key string `json:"key"`
+}
+
+func newBridgeClient(t *testing.T, codec redis.Codec) *redis.Client {
+	srv := redistest.Run(t)
+	c, err := redis.NewClient(&redis.Config{Prefix: "bridge", Host: srv.Addr()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	c.SetCodec(codec)
+	return c
+}
+
+func TestRedisEventBridge(t *testing.T) {
+	// two nodes connected by an in-memory channel instead of redis
+	localBus, remoteBus := newTestEventBus(), newTestEventBus()
+	defer localBus.stop()
+	defer remoteBus.stop()
+
+	c := newBridgeClient(t, redis.JSONCodec)
+	local := NewRedisEventBridgeWithClient(c, localBus, "test-bridge")
+	remote := NewRedisEventBridgeWithClient(c, remoteBus, "test-bridge")
+	var sent []string
+	local.publish = func(channel string, msg interface{}) error {
+		assert.Equal(t, "test-bridge", channel)
+		sent = append(sent, msg.(string))
+		return nil
+	}
+	remote.publish = func(string, interface{}) error {
+		t.Fatal("remote event forwarded again")
+		return nil
+	}
+	BridgeEvent[*cacheInvalidated](local, "")
+	BridgeEvent[*cacheInvalidated](remote, "")
+	assert.Panics(t, func() { BridgeEvent[*cacheInvalidated](local, "") })
+
+	localGot, remoteGot := make(chan string, 4), make(chan string, 4)
+	Subscribe(localBus, func(e *cacheInvalidated) error {
+		localGot <- e.Key
+		return nil
+	})
+	Subscribe(remoteBus, func(e *cacheInvalidated) error {
+		remoteGot <- e.Key
+		return nil
+	})
+
+	assert.NoError(t, PublishSync(localBus, &cacheInvalidated{Key: "user:1"}))
+	assert.Equal(t, "user:1", <-localGot)
+	assert.Equal(t, 1, len(sent))
+
+	var envelope bridgeEnvelope
+	assert.NoError(t, json.Unmarshal([]byte(sent[0]), &envelope))
+	assert.Equal(t, local.NodeID(), envelope.Origin)
+	assert.Equal(t, "*task.cacheInvalidated", envelope.Event)
+
+	// the origin node drops its own message
+	local.handleMessage(sent[0])
+	// other nodes deliver it locally without forwarding it again
+	remote.handleMessage(sent[0])
+	select {
+	case key := <-remoteGot:
+		assert.Equal(t, "user:1", key)
+	case <-time.After(time.Second):
+		t.Fatal("remote event not delivered")
+	}
+	select {
+	case key := <-localGot:
+		t.Fatalf("unexpected loop back of %s", key)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	remote.handleMessage(`{"origin":"other","event":"unknown","payload":"e30="}`)
+	remote.handleMessage("not json")
+	assert.Equal(t, 0, len(remoteGot))
+
+	// a stopped bridge no longer forwards
+	local.Stop()
+	assert.NoError(t, PublishSync(localBus, &cacheInvalidated{Key: "user:2"}))
+	assert.Equal(t, 1, len(sent))
+}
+
+// TestRedisEventBridgeCodec 通过redis频道在两个节点间转发msgpack编码的事件
+func TestRedisEventBridgeCodec(t *testing.T) {
+	localBus, remoteBus := newTestEventBus(), newTestEventBus()
+	defer localBus.stop()
+	defer remoteBus.stop()
+
+	c := newBridgeClient(t, redis.MsgpackCodec)
+	local := NewRedisEventBridgeWithClient(c, localBus, "test-bridge-codec")
+	remote := NewRedisEventBridgeWithClient(c, remoteBus, "test-bridge-codec")
+	BridgeEvent[*cacheInvalidated](local, "cache")
+	BridgeEvent[*cacheInvalidated](remote, "cache")
+	remote.Start()
+	defer remote.Stop()
+	defer local.Stop()
+
+	remoteGot := make(chan string, 1)
+	Subscribe(remoteBus, func(e *cacheInvalidated) error {
+		remoteGot <- e.Key
+		return nil
+	})
+
+	// 订阅建立前发布的消息会丢失，重复发布直到收到
+	deadline := time.After(2 * time.Second)
+	for {
+		assert.NoError(t, PublishSync(localBus, &cacheInvalidated{Key: "user:1"}))
+		select {
+		case key := <-remoteGot:
+			assert.Equal(t, "user:1", key)
+			return
+		case <-deadline:
+			t.Fatal("remote event not delivered")
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package task
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/XingMenTech/common/logger"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+type pingEvent struct {
+	Seq int
+}
+
+type pingHandler struct {
+	ch chan *pingEvent
+}
+
+func (h *pingHandler) Notify(param interface{}) {
+	if v, ok := param.(*pingEvent); ok {
+		h.ch <- v
+	}
+}
+
+func newTestEventBus() *EventBus {
+	if logger.LOG == nil {
+		logger.LOG = logrus.New()
+	}
+	bus := newEventBus()
+	bus.Start()
+	return bus
+}
+
+func TestTypedEventBus(t *testing.T) {
+	bus := newTestEventBus()
+	defer bus.stop()
+
+	typed := make(chan *pingEvent, 1)
+	sub := Subscribe(bus, func(e *pingEvent) error {
+		typed <- e
+		return nil
+	})
+	// the untyped API shares the event key with the typed one
+	legacy := &pingHandler{ch: make(chan *pingEvent, 1)}
+	bus.Register(reflect.TypeOf(&pingEvent{}), legacy)
+
+	Publish(bus, &pingEvent{Seq: 1})
+	assert.Equal(t, 1, (<-typed).Seq)
+	assert.Equal(t, 1, (<-legacy.ch).Seq)
+
+	bus.Notify(reflect.TypeOf(&pingEvent{}), &pingEvent{Seq: 2})
+	assert.Equal(t, 2, (<-typed).Seq)
+	assert.Equal(t, 2, (<-legacy.ch).Seq)
+
+	sub.Unsubscribe()
+	bus.Unregister(reflect.TypeOf(&pingEvent{}), legacy)
+	assert.NoError(t, PublishSync(bus, &pingEvent{Seq: 3}))
+	assert.Equal(t, 0, len(typed))
+	assert.Equal(t, 0, len(legacy.ch))
+}
+
+func TestEventBusListenerErrors(t *testing.T) {
+	bus := newTestEventBus()
+	defer bus.stop()
+
+	errBoom := errors.New("boom")
+	var called []string
+	Subscribe(bus, func(e *pingEvent) error {
+		called = append(called, "error")
+		return errBoom
+	})
+	Subscribe(bus, func(e *pingEvent) error {
+		called = append(called, "panic")
+		panic("listener panic")
+	})
+	Subscribe(bus, func(e *pingEvent) error {
+		called = append(called, "ok")
+		return nil
+	})
+
+	err := PublishSync(bus, &pingEvent{})
+	assert.ErrorIs(t, err, errBoom)
+	assert.ErrorIs(t, err, ErrListenerPanic)
+	assert.Equal(t, []string{"error", "panic", "ok"}, called)
+
+	reported := make(chan error, 3)
+	bus.SetErrorHandler(func(event, param interface{}, err error) {
+		assert.Equal(t, EventKey[*pingEvent](), event)
+		reported <- err
+	})
+	Publish(bus, &pingEvent{})
+	var errs []error
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-reported:
+			errs = append(errs, err)
+		case <-time.After(time.Second):
+			t.Fatal("listener error not reported")
+		}
+	}
+	assert.ErrorIs(t, errors.Join(errs...), errBoom)
+	assert.ErrorIs(t, errors.Join(errs...), ErrListenerPanic)
+
+	// a param of another type reaches typed listeners as ErrEventType
+	err = bus.syncNotify(EventKey[*pingEvent](), "ping")
+	assert.ErrorIs(t, err, ErrEventType)
+}
+
+func TestEventBusOrderedDelivery(t *testing.T) {
+	bus := newTestEventBus()
+
+	const n = 200
+	var lock sync.Mutex
+	var got []int
+	SetOrderedDelivery[*pingEvent](bus, true)
+	Subscribe(bus, func(e *pingEvent) error {
+		lock.Lock()
+		got = append(got, e.Seq)
+		lock.Unlock()
+		return nil
+	})
+	for i := 0; i < n; i++ {
+		Publish(bus, &pingEvent{Seq: i})
+	}
+	bus.stop()
+
+	assert.Equal(t, n, len(got))
+	for i, seq := range got {
+		assert.Equal(t, i, seq)
+	}
+}
+
+func TestEventBusOverflow(t *testing.T) {
+	newBus := func(opts ...EventBusOption) (*EventBus, chan int) {
+		if logger.LOG == nil {
+			logger.LOG = logrus.New()
+		}
+		bus := newEventBus(append(opts, WithEventBusWorkers(1), WithEventBusChanSize(2))...)
+		got := make(chan int, 16)
+		Subscribe(bus, func(e *pingEvent) error {
+			got <- e.Seq
+			return nil
+		})
+		return bus, got
+	}
+	publish := func(bus *EventBus, n int) {
+		for i := 0; i < n; i++ {
+			Publish(bus, &pingEvent{Seq: i})
+		}
+	}
+	received := func(bus *EventBus, got chan int) []int {
+		// workers start after the channel was filled, stop drains everything
+		bus.Start()
+		bus.stop()
+		close(got)
+		var seqs []int
+		for seq := range got {
+			seqs = append(seqs, seq)
+		}
+		return seqs
+	}
+
+	bus, got := newBus(WithOverflowPolicy(OverflowDropNewest))
+	publish(bus, 5)
+	assert.Equal(t, EventBusStats{Workers: 1, QueueLen: 2, QueueCap: 2, Dropped: 3}, bus.Stats())
+	assert.Equal(t, []int{0, 1}, received(bus, got))
+
+	bus, got = newBus(WithOverflowPolicy(OverflowDropOldest))
+	publish(bus, 5)
+	assert.Equal(t, uint64(3), bus.Stats().Dropped)
+	assert.Equal(t, []int{3, 4}, received(bus, got))
+
+	bus, got = newBus(WithBlockTimeout(20 * time.Millisecond))
+	start := time.Now()
+	publish(bus, 3)
+	assert.True(t, time.Since(start) >= 20*time.Millisecond)
+	assert.Equal(t, uint64(1), bus.Stats().Dropped)
+	assert.Equal(t, []int{0, 1}, received(bus, got))
+
+	bus, got = newBus(WithOverflowPolicy(OverflowSpill), WithSpillSize(4))
+	publish(bus, 8)
+	stats := bus.Stats()
+	assert.Equal(t, uint64(4), stats.SpillLen)
+	assert.Equal(t, uint64(4), stats.Spilled)
+	assert.Equal(t, uint64(2), stats.Dropped)
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5}, sortedInts(received(bus, got)))
+}
+
+func sortedInts(v []int) []int {
+	sort.Ints(v)
+	return v
+}
+
+func TestEventBusBlockAfterStop(t *testing.T) {
+	if logger.LOG == nil {
+		logger.LOG = logrus.New()
+	}
+	bus := newEventBus(WithOverflowPolicy(OverflowBlock))
+
+	// a publisher blocked on a lane that is no longer read returns when the bus stops
+	lane := make(chan *NotifyParam)
+	done := make(chan struct{})
+	go func() {
+		bus.block(lane, &NotifyParam{})
+		close(done)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	bus.cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("publisher still blocked after the bus stopped")
+	}
+
+	// publishers arriving after the bus stopped do not block
+	bus.block(lane, &NotifyParam{})
+	assert.Equal(t, uint64(2), bus.Stats().Dropped)
+}
@@ -0,0 +1,215 @@
+package task
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// OverflowPolicy /通知通道已满时的处理策略
+type OverflowPolicy int
+
+const (
+	// OverflowBlock 阻塞等待通道空闲，设置了 WithBlockTimeout 时超时后丢弃，总线停止后立即丢弃
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropNewest 丢弃当前通知
+	OverflowDropNewest
+	// OverflowDropOldest 丢弃通道中最早的通知
+	OverflowDropOldest
+	// OverflowSpill 暂存到 RingBuffer 由后台协程回填，RingBuffer 也满时丢弃
+	// 顺序投递的事件不暂存，按 OverflowBlock 处理以保证顺序
+	OverflowSpill
+)
+
+const (
+	DefaultSpillSize = 4096
+)
+
+// EventBusOption /事件总线配置项
+type EventBusOption func(*eventBusOptions)
+
+type eventBusOptions struct {
+	workers      int
+	chanSize     int
+	policy       OverflowPolicy
+	blockTimeout time.Duration
+	spillSize    uint64
+}
+
+func defaultEventBusOptions() *eventBusOptions {
+	return &eventBusOptions{
+		workers:   EventBusWorkerSize,
+		chanSize:  NotifyChanMaxSize,
+		policy:    OverflowBlock,
+		spillSize: DefaultSpillSize,
+	}
+}
+
+// WithEventBusWorkers /工作协程数，默认 EventBusWorkerSize
+func WithEventBusWorkers(n int) EventBusOption {
+	return func(opts *eventBusOptions) {
+		if n > 0 {
+			opts.workers = n
+		}
+	}
+}
+
+// WithEventBusChanSize /通知通道容量，默认 NotifyChanMaxSize
+func WithEventBusChanSize(size int) EventBusOption {
+	return func(opts *eventBusOptions) {
+		if size > 0 {
+			opts.chanSize = size
+		}
+	}
+}
+
+// WithOverflowPolicy /通道已满时的处理策略，默认 OverflowBlock
+func WithOverflowPolicy(policy OverflowPolicy) EventBusOption {
+	return func(opts *eventBusOptions) {
+		opts.policy = policy
+	}
+}
+
+// WithBlockTimeout /阻塞等待的最长时间，超时后丢弃通知，默认一直等待
+func WithBlockTimeout(timeout time.Duration) EventBusOption {
+	return func(opts *eventBusOptions) {
+		opts.blockTimeout = timeout
+	}
+}
+
+// WithSpillSize /OverflowSpill 暂存区容量，向上取整为2的幂，默认 DefaultSpillSize
+func WithSpillSize(size uint64) EventBusOption {
+	return func(opts *eventBusOptions) {
+		if size > 0 {
+			opts.spillSize = size
+		}
+	}
+}
+
+// EventBusStats /事件总线统计
+type EventBusStats struct {
+	Workers  int
+	QueueLen int
+	QueueCap int
+	// SpillLen 为暂存区中等待回填的通知数
+	SpillLen uint64
+	// Spilled 为累计暂存的通知数
+	Spilled uint64
+	// Dropped 为累计丢弃的通知数
+	Dropped uint64
+}
+
+// Stats /统计信息
+func (object *EventBus) Stats() EventBusStats {
+	stats := EventBusStats{
+		Workers:  object.opts.workers,
+		QueueLen: len(object.notifyCh),
+		QueueCap: cap(object.notifyCh),
+		Spilled:  atomic.LoadUint64(&object.spilled),
+		Dropped:  atomic.LoadUint64(&object.dropped),
+	}
+	if nil != object.spill {
+		stats.SpillLen = object.spill.Len()
+	}
+	return stats
+}
+
+// /按溢出策略将通知放入通道，spillable 为 false 时不使用暂存区
+func (object *EventBus) offer(ch chan *NotifyParam, notifyParam *NotifyParam, spillable bool) {
+	spill := spillable && OverflowSpill == object.opts.policy
+	//暂存区非空时继续暂存，尽量保持先后顺序
+	if spill && 0 != object.spill.Len() {
+		object.spillOrDrop(notifyParam)
+		return
+	}
+
+	select {
+	case ch <- notifyParam:
+		return
+	default:
+	}
+
+	switch object.opts.policy {
+	case OverflowDropNewest:
+		atomic.AddUint64(&object.dropped, 1)
+	case OverflowDropOldest:
+		for {
+			select {
+			case <-ch:
+				atomic.AddUint64(&object.dropped, 1)
+			default:
+			}
+			select {
+			case ch <- notifyParam:
+				return
+			default:
+			}
+		}
+	case OverflowSpill:
+		if spill {
+			object.spillOrDrop(notifyParam)
+			return
+		}
+		object.block(ch, notifyParam)
+	default:
+		object.block(ch, notifyParam)
+	}
+}
+
+// /阻塞等待通道空闲，总线停止后不再有协程读取通道，直接丢弃
+func (object *EventBus) block(ch chan *NotifyParam, notifyParam *NotifyParam) {
+	if nil != object.ctx.Err() {
+		atomic.AddUint64(&object.dropped, 1)
+		return
+	}
+	var timeout <-chan time.Time
+	if object.opts.blockTimeout > 0 {
+		timer := time.NewTimer(object.opts.blockTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case ch <- notifyParam:
+	case <-timeout:
+		atomic.AddUint64(&object.dropped, 1)
+	case <-object.ctx.Done():
+		atomic.AddUint64(&object.dropped, 1)
+	}
+}
+
+func (object *EventBus) spillOrDrop(notifyParam *NotifyParam) {
+	if ok, _ := object.spill.Offer(notifyParam); !ok {
+		atomic.AddUint64(&object.dropped, 1)
+		return
+	}
+	atomic.AddUint64(&object.spilled, 1)
+	select {
+	case object.spillSignal <- struct{}{}:
+	default:
+	}
+}
+
+// /将暂存区的通知回填到通知通道
+func (object *EventBus) drainSpill() {
+	defer object.wg.Done()
+	for {
+		select {
+		case <-object.ctx.Done():
+			return
+		case <-object.spillSignal:
+		}
+		for 0 != object.spill.Len() {
+			item, err := object.spill.Get()
+			if err != nil {
+				return
+			}
+			notifyParam := item.(*NotifyParam)
+			select {
+			case object.notifyCh <- notifyParam:
+			case <-object.ctx.Done():
+				//停止时剩余通知由 stop 直接投递
+				object.deliver(notifyParam)
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package task
+
+import (
+	"context"
+)
+
+// JobHandle is returned by RunContextJob, it is used to wait for the job
+// and read its result
+type JobHandle struct {
+	job *Job
+}
+
+// Job returns the underlying job
+func (h *JobHandle) Job() *Job {
+	return h.job
+}
+
+// Done returns a channel that is closed when the job is complete
+func (h *JobHandle) Done() <-chan struct{} {
+	return h.job.done
+}
+
+// Wait blocks until the job is complete and returns its error. A job that is
+// cancelled or whose runner is stopped before it starts returns ErrJobCancelled.
+func (h *JobHandle) Wait() error {
+	select {
+	case <-h.job.done:
+	case <-h.job.ctx.Done():
+		h.cancelIfNotStarted()
+		<-h.job.done
+	}
+	return h.job.GetError()
+}
+
+// WaitContext is like Wait but returns ctx.Err() if ctx is done first,
+// the job is not cancelled in that case
+func (h *JobHandle) WaitContext(ctx context.Context) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- h.Wait()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Cancel cancels the job
+func (h *JobHandle) Cancel() {
+	h.job.Cancel()
+}
+
+// Result returns the result of a finished job
+func (h *JobHandle) Result() interface{} {
+	return h.job.GetResult()
+}
+
+// Err returns the error of a completed job
+func (h *JobHandle) Err() error {
+	return h.job.GetError()
+}
+
+// cancelIfNotStarted completes a job that will never be picked up by its
+// worker, the worker skips jobs that are already cancelled
+func (h *JobHandle) cancelIfNotStarted() {
+	h.job.Lock()
+	if h.job.state == Pending || h.job.state == Cancelling {
+		h.job.completeLocked(Cancelled, ErrJobCancelled)
+	}
+	h.job.Unlock()
+}
@@ -0,0 +1,137 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextJobResult(t *testing.T) {
+	runner := NewRunner()
+	defer runner.Stop()
+
+	h, err := runner.RunContextJob("", 0, func(ctx context.Context) (interface{}, error) {
+		return 42, nil
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, h.Wait())
+	assert.Equal(t, 42, h.Result())
+	assert.True(t, h.Job().IsFinished())
+
+	failed := errors.New("failed")
+	h, err = runner.RunContextJob("", 0, func(ctx context.Context) (interface{}, error) {
+		return nil, failed
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, failed, h.Wait())
+	assert.True(t, h.Job().IsFailed())
+}
+
+func TestContextJobCancelRunning(t *testing.T) {
+	runner := NewRunner()
+	defer runner.Stop()
+
+	started := make(chan struct{})
+	h, err := runner.RunContextJob("", 0, func(ctx context.Context) (interface{}, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	assert.NoError(t, err)
+
+	<-started
+	h.Cancel()
+	assert.ErrorIs(t, h.Wait(), context.Canceled)
+	assert.True(t, h.Job().IsCancelled())
+}
+
+func TestContextJobCancelPending(t *testing.T) {
+	runner := NewRunner()
+	defer runner.Stop()
+
+	block := make(chan struct{})
+	runner.RunJob("", func() error {
+		<-block
+		return nil
+	})
+
+	h, err := runner.RunContextJob("", 0, func(ctx context.Context) (interface{}, error) {
+		return nil, nil
+	})
+	assert.NoError(t, err)
+
+	h.Cancel()
+	assert.Equal(t, ErrJobCancelled, h.Wait())
+	assert.True(t, h.Job().IsCancelled())
+	close(block)
+}
+
+func TestContextJobTimeout(t *testing.T) {
+	runner := NewRunner()
+	defer runner.Stop()
+
+	h, err := runner.RunContextJob("", 10*time.Millisecond, func(ctx context.Context) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	assert.NoError(t, err)
+	assert.ErrorIs(t, h.Wait(), context.DeadlineExceeded)
+	assert.True(t, h.Job().IsFailed())
+}
+
+func TestContextJobRunnerStop(t *testing.T) {
+	runner := NewRunner()
+
+	started := make(chan struct{})
+	h, err := runner.RunContextJob("", 0, func(ctx context.Context) (interface{}, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	assert.NoError(t, err)
+
+	<-started
+	_, err = runner.StopWithTimeout(time.Second)
+	assert.NoError(t, err)
+
+	select {
+	case <-h.Done():
+	case <-time.After(time.Second):
+		t.Fatal("job not complete after runner stopped")
+	}
+	assert.True(t, h.Job().IsCancelled())
+}
+
+func TestContextJobQueuedOnRunnerStop(t *testing.T) {
+	runner := NewRunner()
+
+	started := make(chan struct{})
+	running, err := runner.RunContextJob("", 0, func(ctx context.Context) (interface{}, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	assert.NoError(t, err)
+	queued, err := runner.RunContextJob("", 0, func(ctx context.Context) (interface{}, error) {
+		t.Error("queued job executed after the runner stopped")
+		return nil, nil
+	})
+	assert.NoError(t, err)
+
+	<-started
+	_, err = runner.StopWithTimeout(time.Second)
+	assert.NoError(t, err)
+
+	for _, h := range []*JobHandle{running, queued} {
+		select {
+		case <-h.Done():
+		case <-time.After(time.Second):
+			t.Fatal("job not complete after runner stopped")
+		}
+		assert.True(t, h.Job().IsCancelled())
+	}
+	assert.Equal(t, ErrJobCancelled, queued.Err())
+}
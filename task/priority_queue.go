@@ -0,0 +1,353 @@
+package task
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+)
+
+// Prioritized is implemented by items that carry a priority, items with a
+// higher priority are retrieved first from a PriorityQueue.
+type Prioritized interface {
+	Priority() int
+}
+
+type heapItem struct {
+	value interface{}
+	key   interface{}
+	seq   uint64
+	at    time.Time
+	index int
+}
+
+// heapItems is a binary heap ordered by less or by due time when less is
+// nil, items that are equal are ordered by insertion so the heap is stable.
+type heapItems struct {
+	items []*heapItem
+	less  func(a, b interface{}) bool
+	keys  map[interface{}]*heapItem
+}
+
+func (h *heapItems) Len() int { return len(h.items) }
+
+func (h *heapItems) Less(i, j int) bool {
+	a, b := h.items[i], h.items[j]
+	if h.less == nil {
+		if !a.at.Equal(b.at) {
+			return a.at.Before(b.at)
+		}
+	} else if h.less(a.value, b.value) {
+		return true
+	} else if h.less(b.value, a.value) {
+		return false
+	}
+	return a.seq < b.seq
+}
+
+func (h *heapItems) Swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+	h.items[i].index = i
+	h.items[j].index = j
+}
+
+func (h *heapItems) Push(x interface{}) {
+	item := x.(*heapItem)
+	item.index = len(h.items)
+	h.items = append(h.items, item)
+}
+
+func (h *heapItems) Pop() interface{} {
+	n := len(h.items)
+	item := h.items[n-1]
+	h.items[n-1] = nil
+	h.items = h.items[:n-1]
+	item.index = -1
+	if item.key != nil {
+		delete(h.keys, item.key)
+	}
+	return item
+}
+
+func (h *heapItems) peek() (*heapItem, bool) {
+	if len(h.items) == 0 {
+		return nil, false
+	}
+	return h.items[0], true
+}
+
+// get pops up to number items that satisfy ready
+func (h *heapItems) get(number int64, returnItems []interface{}, ready func(*heapItem) bool) int64 {
+	index := int64(0)
+	for index < number && len(h.items) > 0 && ready(h.items[0]) {
+		returnItems[index] = heap.Pop(h).(*heapItem).value
+		index++
+	}
+	return index
+}
+
+func (h *heapItems) values() []interface{} {
+	values := make([]interface{}, len(h.items))
+	for i, item := range h.items {
+		values[i] = item.value
+	}
+	return values
+}
+
+func alwaysReady(*heapItem) bool {
+	return true
+}
+
+// PriorityLess orders Prioritized items by descending priority, other items
+// are treated as priority 0
+func PriorityLess(a, b interface{}) bool {
+	return priorityOf(a) > priorityOf(b)
+}
+
+func priorityOf(v interface{}) int {
+	if p, ok := v.(Prioritized); ok {
+		return p.Priority()
+	}
+	return 0
+}
+
+// PriorityQueue is a heap based threadsafe queue, Get and Poll return items
+// in priority order and items with equal priority in FIFO order.
+type PriorityQueue struct {
+	waiters  waiters
+	items    *heapItems
+	seq      uint64
+	lock     sync.Mutex
+	disposed bool
+	ctx      context.Context
+}
+
+// NewPriorityQueue is a constructor for a new threadsafe priority queue,
+// a nil less orders items with PriorityLess.
+func NewPriorityQueue(hint int64, less func(a, b interface{}) bool) *PriorityQueue {
+	return NewPriorityQueueWithContext(hint, less, context.Background())
+}
+
+// NewPriorityQueueWithContext is a constructor for a new threadsafe priority queue.
+func NewPriorityQueueWithContext(hint int64, less func(a, b interface{}) bool, ctx context.Context) *PriorityQueue {
+	if less == nil {
+		less = PriorityLess
+	}
+	return &PriorityQueue{
+		items: &heapItems{
+			items: make([]*heapItem, 0, hint),
+			less:  less,
+			keys:  make(map[interface{}]*heapItem),
+		},
+		ctx: ctx,
+	}
+}
+
+// Put will add the specified items to the queue.
+func (q *PriorityQueue) Put(items ...interface{}) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	q.lock.Lock()
+
+	if q.disposed {
+		q.lock.Unlock()
+		return ErrDisposed
+	}
+
+	for _, item := range items {
+		q.seq++
+		heap.Push(q.items, &heapItem{value: item, seq: q.seq})
+	}
+	q.notifyLocked()
+
+	q.lock.Unlock()
+	return nil
+}
+
+// PutOrUpdate will add item to the queue, or replace the queued item with the
+// same key and restore the heap order in O(log n).
+func (q *PriorityQueue) PutOrUpdate(key, item interface{}) error {
+	q.lock.Lock()
+
+	if q.disposed {
+		q.lock.Unlock()
+		return ErrDisposed
+	}
+
+	if old, ok := q.items.keys[key]; ok {
+		old.value = item
+		heap.Fix(q.items, old.index)
+	} else {
+		q.seq++
+		hi := &heapItem{value: item, key: key, seq: q.seq}
+		q.items.keys[key] = hi
+		heap.Push(q.items, hi)
+	}
+	q.notifyLocked()
+
+	q.lock.Unlock()
+	return nil
+}
+
+func (q *PriorityQueue) notifyLocked() {
+	for {
+		sema := q.waiters.get()
+		if sema == nil {
+			break
+		}
+		sema.response.Add(1)
+		select {
+		case sema.ready <- true:
+			sema.response.Wait()
+		default:
+			// This semaphore timed out.
+		}
+		if q.items.Len() == 0 {
+			break
+		}
+	}
+}
+
+// Get retrieves items from the queue.  If there are some items in the
+// queue, get will return a number UP TO the number passed in as a
+// parameter.  If no items are in the queue, this method will pause
+// until items are added to the queue.
+func (q *PriorityQueue) Get(number int64, items []interface{}) (int64, error) {
+	return q.Poll(number, items, 0)
+}
+
+// Poll retrieves items from the queue.  If there are some items in the queue,
+// Poll will return a number UP TO the number passed in as a parameter.  If no
+// items are in the queue, this method will pause until items are added to the
+// queue or the provided timeout is reached.  A non-positive timeout will block
+// until items are added.  If a timeout occurs, ErrTimeout is returned.
+func (q *PriorityQueue) Poll(number int64, items []interface{}, timeout time.Duration) (int64, error) {
+	if number < 1 {
+		return 0, nil
+	}
+
+	q.lock.Lock()
+
+	if q.disposed {
+		q.lock.Unlock()
+		return 0, ErrDisposed
+	}
+
+	if q.items.Len() == 0 {
+		sema := newSema()
+		q.waiters.put(sema)
+		q.lock.Unlock()
+
+		var timeoutC <-chan time.Time
+		if timeout > 0 {
+			timeoutC = time.After(timeout)
+		}
+		select {
+		case <-q.ctx.Done():
+			q.Dispose()
+			return 0, ErrDisposed
+		case <-sema.ready:
+			// we are now inside the put's lock
+			if q.disposed {
+				return 0, ErrDisposed
+			}
+			c := q.items.get(number, items, alwaysReady)
+			sema.response.Done()
+			return c, nil
+		case <-timeoutC:
+			// cleanup the sema that was added to waiters
+			select {
+			case sema.ready <- true:
+				q.lock.Lock()
+				q.waiters.remove(sema)
+				q.lock.Unlock()
+			default:
+				// Put() got it already, we need to call Done() so Put() can move on
+				sema.response.Done()
+			}
+			return 0, ErrTimeout
+		}
+	}
+
+	c := q.items.get(number, items, alwaysReady)
+	q.lock.Unlock()
+
+	select {
+	case <-q.ctx.Done():
+		return 0, ErrDisposed
+	default:
+		return c, nil
+	}
+}
+
+// Peek returns the item with the highest priority without modifying the queue.
+func (q *PriorityQueue) Peek() (interface{}, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if q.disposed {
+		return nil, ErrDisposed
+	}
+
+	item, ok := q.items.peek()
+	if !ok {
+		return nil, ErrEmptyQueue
+	}
+	return item.value, nil
+}
+
+// Empty returns a bool indicating if this queue is empty.
+func (q *PriorityQueue) Empty() bool {
+	return q.Len() == 0
+}
+
+// Len returns the number of items in this queue.
+func (q *PriorityQueue) Len() int64 {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return int64(q.items.Len())
+}
+
+// Disposed returns a bool indicating if this queue has had disposed called on it.
+func (q *PriorityQueue) Disposed() bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return q.disposed
+}
+
+// Dispose will dispose of this queue and returns the items disposed in heap
+// order. Any subsequent calls to Get or Put will return an errors.
+func (q *PriorityQueue) Dispose() []interface{} {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if q.disposed {
+		return nil
+	}
+
+	q.disposed = true
+	disposeWaiters(q.waiters)
+
+	disposedItems := q.items.values()
+	q.items.items = nil
+	q.items.keys = nil
+	q.waiters = nil
+
+	return disposedItems
+}
+
+func disposeWaiters(ws waiters) {
+	for _, waiter := range ws {
+		waiter.response.Add(1)
+		select {
+		case waiter.ready <- true:
+			// release Poll immediately
+		default:
+			// ignore if it's a timeout or in the get
+		}
+	}
+}
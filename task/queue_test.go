@@ -3,6 +3,7 @@ package task
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -45,3 +46,94 @@ func TestQueueWithContext(t *testing.T) {
 	assert.Equal(t, int64(0), n)
 	assert.Equal(t, int64(0), q.Len())
 }
+
+type priorityItem struct {
+	name     string
+	priority int
+}
+
+func (i *priorityItem) Priority() int {
+	return i.priority
+}
+
+func TestPriorityQueue(t *testing.T) {
+	q := NewPriorityQueue(2, nil)
+	a, b, c, d := &priorityItem{"a", 1}, &priorityItem{"b", 5}, &priorityItem{"c", 1}, &priorityItem{"d", 3}
+	assert.NoError(t, q.Put(a, b, c, d))
+	assert.Equal(t, int64(4), q.Len())
+
+	peek, err := q.Peek()
+	assert.NoError(t, err)
+	assert.Equal(t, b, peek)
+
+	items := make([]interface{}, 4)
+	n, err := q.Get(4, items)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), n)
+	// equal priorities keep FIFO order
+	assert.Equal(t, []interface{}{b, d, a, c}, items)
+
+	assert.NoError(t, q.PutOrUpdate("k", &priorityItem{"e", 1}))
+	assert.NoError(t, q.Put(&priorityItem{"f", 2}))
+	assert.NoError(t, q.PutOrUpdate("k", &priorityItem{"e", 9}))
+	assert.Equal(t, int64(2), q.Len())
+	n, err = q.Get(1, items)
+	assert.NoError(t, err)
+	assert.Equal(t, "e", items[0].(*priorityItem).name)
+
+	_, err = q.Poll(1, items, 10*time.Millisecond)
+	assert.NoError(t, err)
+	_, err = q.Poll(1, items, 10*time.Millisecond)
+	assert.Equal(t, ErrTimeout, err)
+
+	done := make(chan int64)
+	go func() {
+		n, _ := q.Get(1, make([]interface{}, 1))
+		done <- n
+	}()
+	time.Sleep(10 * time.Millisecond)
+	assert.NoError(t, q.Put(a))
+	assert.Equal(t, int64(1), <-done)
+
+	q.Put(a, b)
+	assert.Equal(t, 2, len(q.Dispose()))
+	assert.Equal(t, ErrDisposed, q.Put(a))
+}
+
+func TestDelayQueue(t *testing.T) {
+	q := NewDelayQueue(2)
+	assert.NoError(t, q.PutDelayed(60*time.Millisecond, "late"))
+	assert.NoError(t, q.PutDelayed(20*time.Millisecond, "early"))
+	assert.Equal(t, int64(2), q.Len())
+
+	items := make([]interface{}, 2)
+	_, err := q.Poll(2, items, 5*time.Millisecond)
+	assert.Equal(t, ErrTimeout, err)
+
+	start := time.Now()
+	n, err := q.Get(2, items)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	assert.Equal(t, "early", items[0])
+	assert.True(t, time.Since(start) >= 10*time.Millisecond)
+
+	// an earlier item put while waiting wakes the waiter
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		q.Put("now")
+	}()
+	n, err = q.Get(2, items)
+	assert.NoError(t, err)
+	assert.Equal(t, "now", items[0])
+
+	n, err = q.Get(2, items)
+	assert.NoError(t, err)
+	assert.Equal(t, "late", items[0])
+
+	ctx, cancel := context.WithCancel(context.Background())
+	q = NewDelayQueueWithContext(1, ctx)
+	q.PutDelayed(time.Hour, "never")
+	go cancel()
+	_, err = q.Get(1, items)
+	assert.Equal(t, ErrDisposed, err)
+}
@@ -0,0 +1,124 @@
+package task
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoutinePoolFuture(t *testing.T) {
+	pool := NewRoutinePoolWithOptions(WithMinWorkers(1), WithMaxWorkers(4))
+	defer pool.BeforeShutdown()
+
+	f, err := pool.PostTask(func(params []interface{}) interface{} {
+		return params[0].(int) * 2
+	}, 21)
+	assert.NoError(t, err)
+	v, err := f.Wait()
+	assert.NoError(t, err)
+	assert.Equal(t, 42, v)
+}
+
+func TestRoutinePoolPanic(t *testing.T) {
+	var recovered interface{}
+	pool := NewRoutinePoolWithOptions(WithMinWorkers(1), WithMaxWorkers(1),
+		WithPanicHandler(func(r interface{}, stack []byte) {
+			recovered = r
+		}))
+	defer pool.BeforeShutdown()
+
+	f, _ := pool.PostTask(func(params []interface{}) interface{} {
+		panic("boom")
+	})
+	_, err := f.Wait()
+	assert.True(t, errors.Is(err, ErrTaskPanic))
+	assert.Equal(t, "boom", recovered)
+
+	//panic 后协程仍可执行任务
+	f, _ = pool.PostTask(func(params []interface{}) interface{} {
+		return "ok"
+	})
+	v, err := f.Wait()
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", v)
+	assert.Equal(t, 1, pool.Running())
+}
+
+func TestRoutinePoolReject(t *testing.T) {
+	pool := NewRoutinePoolWithOptions(WithMinWorkers(0), WithMaxWorkers(1), WithQueueSize(1),
+		WithRejectPolicy(RejectAbort))
+	defer pool.BeforeShutdown()
+
+	release := make(chan struct{})
+	block := func(params []interface{}) interface{} {
+		<-release
+		return nil
+	}
+	_, err := pool.PostTask(block)
+	assert.NoError(t, err)
+	_, err = pool.PostTask(block)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, pool.Waiting())
+
+	_, err = pool.PostTask(block)
+	assert.Equal(t, ErrPoolOverload, err)
+	close(release)
+}
+
+func TestRoutinePoolCallerRuns(t *testing.T) {
+	pool := NewRoutinePoolWithOptions(WithMinWorkers(0), WithMaxWorkers(1), WithQueueSize(0),
+		WithRejectPolicy(RejectCallerRuns))
+	defer pool.BeforeShutdown()
+
+	release := make(chan struct{})
+	_, _ = pool.PostTask(func(params []interface{}) interface{} {
+		<-release
+		return nil
+	})
+	f, err := pool.PostTask(func(params []interface{}) interface{} {
+		return "caller"
+	})
+	assert.NoError(t, err)
+	//在调用方执行，返回时已完成
+	select {
+	case <-f.Done():
+	default:
+		t.Error("task not run by caller")
+	}
+	close(release)
+}
+
+func TestRoutinePoolIdleReap(t *testing.T) {
+	pool := NewRoutinePoolWithOptions(WithMinWorkers(1), WithMaxWorkers(8), WithIdleTimeout(20*time.Millisecond))
+	defer pool.BeforeShutdown()
+
+	var wg sync.WaitGroup
+	release := make(chan struct{})
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		_, _ = pool.PostTask(func(params []interface{}) interface{} {
+			defer wg.Done()
+			<-release
+			return nil
+		})
+	}
+	assert.Equal(t, 8, pool.Running())
+	close(release)
+	wg.Wait()
+
+	assert.Eventually(t, func() bool {
+		return 1 == pool.Running()
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestRoutinePoolShutdown(t *testing.T) {
+	pool := NewRoutinePoolWithOptions()
+	pool.BeforeShutdown()
+	_, err := pool.PostTask(func(params []interface{}) interface{} {
+		return nil
+	})
+	assert.Equal(t, ErrPoolClosed, err)
+}
@@ -0,0 +1,38 @@
+package task
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type staticElector bool
+
+func (e staticElector) IsLeader() bool {
+	return bool(e)
+}
+
+type timePointTask struct {
+	*TimePointTrigger
+}
+
+func (t *timePointTask) Trigger() {}
+
+func TestSchedulerMasterFilter(t *testing.T) {
+	scheduler := &TaskScheduler{allTriggers: make(map[Trigger]interface{})}
+
+	masterOnly := &timePointTask{NewTimePointTrigger(time.Now().Unix(), true)}
+	everyNode := &timePointTask{NewTimePointTrigger(time.Now().Unix(), false)}
+
+	assert.True(t, scheduler.canRun(masterOnly))
+	assert.True(t, scheduler.canRun(everyNode))
+
+	scheduler.SetLeaderElector(staticElector(false))
+	assert.False(t, scheduler.canRun(masterOnly))
+	assert.True(t, scheduler.canRun(everyNode))
+
+	scheduler.SetLeaderElector(staticElector(true))
+	assert.True(t, scheduler.canRun(masterOnly))
+	assert.True(t, scheduler.canRun(everyNode))
+}
@@ -0,0 +1,195 @@
+package task
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// defaultLatencyBuckets are the upper bounds of latency histogram buckets,
+	// the last bucket counts everything above them
+	defaultLatencyBuckets = []time.Duration{
+		time.Millisecond,
+		5 * time.Millisecond,
+		10 * time.Millisecond,
+		50 * time.Millisecond,
+		100 * time.Millisecond,
+		500 * time.Millisecond,
+		time.Second,
+		5 * time.Second,
+		10 * time.Second,
+		time.Minute,
+	}
+)
+
+// LatencyHistogram is a snapshot of a latency distribution
+type LatencyHistogram struct {
+	// Buckets are the upper bounds, Counts has one more element for +Inf
+	Buckets []time.Duration
+	Counts  []uint64
+	Count   uint64
+	Sum     time.Duration
+	Max     time.Duration
+}
+
+// Mean returns the average latency
+func (h LatencyHistogram) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Sum / time.Duration(h.Count)
+}
+
+// Quantile returns the upper bound of the bucket that contains quantile q,
+// for the +Inf bucket Max is returned
+func (h LatencyHistogram) Quantile(q float64) time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	rank := uint64(math.Ceil(q * float64(h.Count)))
+	if rank == 0 {
+		rank = 1
+	}
+	var seen uint64
+	for i, c := range h.Counts {
+		seen += c
+		if seen >= rank {
+			if i < len(h.Buckets) {
+				return h.Buckets[i]
+			}
+			return h.Max
+		}
+	}
+	return h.Max
+}
+
+type histogram struct {
+	sync.Mutex
+	counts []uint64
+	count  uint64
+	sum    time.Duration
+	max    time.Duration
+}
+
+func newHistogram() *histogram {
+	return &histogram{counts: make([]uint64, len(defaultLatencyBuckets)+1)}
+}
+
+func (h *histogram) observe(d time.Duration) {
+	i := 0
+	for i < len(defaultLatencyBuckets) && d > defaultLatencyBuckets[i] {
+		i++
+	}
+	h.Lock()
+	h.counts[i]++
+	h.count++
+	h.sum += d
+	if d > h.max {
+		h.max = d
+	}
+	h.Unlock()
+}
+
+func (h *histogram) snapshot() LatencyHistogram {
+	h.Lock()
+	defer h.Unlock()
+	counts := make([]uint64, len(h.counts))
+	copy(counts, h.counts)
+	return LatencyHistogram{
+		Buckets: defaultLatencyBuckets,
+		Counts:  counts,
+		Count:   h.count,
+		Sum:     h.sum,
+		Max:     h.max,
+	}
+}
+
+// WorkerStats is a snapshot of a named worker
+type WorkerStats struct {
+	Name    string
+	Durable bool
+	// QueueLen is the number of jobs waiting in the worker queue
+	QueueLen int64
+	// InFlight is the desc of the running job, empty if the worker is idle
+	InFlight      string
+	InFlightSince time.Time
+	// Processed counts all completed jobs, including failed and cancelled ones
+	Processed uint64
+	Failed    uint64
+	Cancelled uint64
+	// WaitLatency is the time between enqueue and start
+	WaitLatency LatencyHistogram
+	// RunLatency is the execution time
+	RunLatency LatencyHistogram
+}
+
+type workerStats struct {
+	sync.RWMutex
+	inFlight      string
+	inFlightSince time.Time
+
+	processed uint64
+	failed    uint64
+	cancelled uint64
+	wait      *histogram
+	run       *histogram
+}
+
+func newWorkerStats() *workerStats {
+	return &workerStats{
+		wait: newHistogram(),
+		run:  newHistogram(),
+	}
+}
+
+func (ws *workerStats) begin(desc string, enqueuedAt time.Time) time.Time {
+	now := time.Now()
+	if !enqueuedAt.IsZero() {
+		ws.wait.observe(now.Sub(enqueuedAt))
+	}
+	ws.Lock()
+	ws.inFlight = desc
+	ws.inFlightSince = now
+	ws.Unlock()
+	return now
+}
+
+func (ws *workerStats) end(start time.Time, state JobState) {
+	ws.run.observe(time.Since(start))
+	ws.Lock()
+	ws.inFlight = ""
+	ws.inFlightSince = time.Time{}
+	ws.Unlock()
+
+	atomic.AddUint64(&ws.processed, 1)
+	switch state {
+	case Failed:
+		atomic.AddUint64(&ws.failed, 1)
+	case Cancelled:
+		atomic.AddUint64(&ws.cancelled, 1)
+	}
+}
+
+// skip records a job that was cancelled before it started
+func (ws *workerStats) skip() {
+	atomic.AddUint64(&ws.processed, 1)
+	atomic.AddUint64(&ws.cancelled, 1)
+}
+
+func (ws *workerStats) snapshot(name string) WorkerStats {
+	ws.RLock()
+	inFlight, since := ws.inFlight, ws.inFlightSince
+	ws.RUnlock()
+	return WorkerStats{
+		Name:          name,
+		InFlight:      inFlight,
+		InFlightSince: since,
+		Processed:     atomic.LoadUint64(&ws.processed),
+		Failed:        atomic.LoadUint64(&ws.failed),
+		Cancelled:     atomic.LoadUint64(&ws.cancelled),
+		WaitLatency:   ws.wait.snapshot(),
+		RunLatency:    ws.run.snapshot(),
+	}
+}
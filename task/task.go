@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 )
@@ -64,6 +65,10 @@ func releaseJob(job *Job) {
 	jobPool.Put(job)
 }
 
+// ContextJobFunc is a job that observes ctx, ctx is cancelled on Job.Cancel,
+// on job timeout or when the runner is stopped
+type ContextJobFunc func(ctx context.Context) (interface{}, error)
+
 // Job is do for something with state
 type Job struct {
 	sync.RWMutex
@@ -73,6 +78,16 @@ type Job struct {
 	state       JobState
 	result      interface{}
 	needRelease bool
+	enqueuedAt  time.Time
+	priority    int
+	dueTime     time.Time
+
+	ctxFun  ContextJobFunc
+	ctx     context.Context
+	cancel  context.CancelFunc
+	timeout time.Duration
+	done    chan struct{}
+	err     error
 }
 
 func newJob(desc string, fun func() error) *Job {
@@ -85,6 +100,20 @@ func newJob(desc string, fun func() error) *Job {
 	return job
 }
 
+func newContextJob(ctx context.Context, desc string, timeout time.Duration, fun ContextJobFunc) *Job {
+	job := acquireJob()
+	job.ctxFun = fun
+	job.ctx, job.cancel = context.WithCancel(ctx)
+	job.timeout = timeout
+	job.done = make(chan struct{})
+	job.state = Pending
+	job.desc = desc
+	// the job is referenced by its handle, never put it back to the pool
+	job.needRelease = false
+
+	return job
+}
+
 func (job *Job) reset() {
 	job.Lock()
 	job.fun = nil
@@ -92,9 +121,86 @@ func (job *Job) reset() {
 	job.desc = ""
 	job.result = nil
 	job.needRelease = true
+	job.enqueuedAt = time.Time{}
+	job.priority = 0
+	job.dueTime = time.Time{}
+	job.ctxFun = nil
+	job.ctx = nil
+	job.cancel = nil
+	job.timeout = 0
+	job.done = nil
+	job.err = nil
+	job.Unlock()
+}
+
+// execute runs the job if it is pending, called by the worker goroutine
+func (job *Job) execute(stats *workerStats) {
+	job.Lock()
+
+	switch job.state {
+	case Pending:
+		job.setState(Running)
+		desc, enqueuedAt := job.desc, job.enqueuedAt
+		job.Unlock()
+		start := stats.begin(desc, enqueuedAt)
+		err := job.call()
+		job.Lock()
+		if err != nil {
+			if err == ErrJobCancelled ||
+				(job.ctx != nil && job.ctx.Err() != nil && errors.Is(err, context.Canceled)) {
+				job.completeLocked(Cancelled, err)
+			} else {
+				job.completeLocked(Failed, err)
+			}
+		} else {
+			if job.state == Cancelling {
+				job.completeLocked(Cancelled, ErrJobCancelled)
+			} else {
+				job.completeLocked(Finished, nil)
+			}
+		}
+		stats.end(start, job.state)
+	case Cancelling:
+		job.completeLocked(Cancelled, ErrJobCancelled)
+		stats.skip()
+	}
+
 	job.Unlock()
 }
 
+func (job *Job) call() error {
+	if job.ctxFun == nil {
+		return job.fun()
+	}
+
+	ctx := job.ctx
+	if job.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, job.timeout)
+		defer cancel()
+	}
+	result, err := job.ctxFun(ctx)
+	if err == nil {
+		job.SetResult(result)
+	}
+	return err
+}
+
+func (job *Job) completeLocked(state JobState, err error) {
+	job.setState(state)
+	job.err = err
+	if job.cancel != nil {
+		job.cancel()
+	}
+	if job.done != nil {
+		select {
+		case <-job.done:
+		default:
+			close(job.done)
+		}
+	}
+}
+
 // IsComplete return true means the job is complete.
 func (job *Job) IsComplete() bool {
 	return !job.IsNotComplete()
@@ -124,15 +230,43 @@ func (job *Job) GetResult() interface{} {
 	return r
 }
 
-// Cancel cancel the job
+// Cancel cancel the job, a running job created by RunContextJob observes
+// the cancellation through its context
 func (job *Job) Cancel() {
 	job.Lock()
 	if job.state == Pending {
 		job.state = Cancelling
 	}
+	if job.cancel != nil && (job.state == Cancelling || job.state == Running) {
+		job.cancel()
+	}
 	job.Unlock()
 }
 
+// Priority returns the job priority, used by PriorityQueue
+func (job *Job) Priority() int {
+	job.RLock()
+	p := job.priority
+	job.RUnlock()
+	return p
+}
+
+// DueTime returns the earliest start time of the job, used by DelayQueue
+func (job *Job) DueTime() time.Time {
+	job.RLock()
+	t := job.dueTime
+	job.RUnlock()
+	return t
+}
+
+// GetError returns the error of a completed job
+func (job *Job) GetError() error {
+	job.RLock()
+	err := job.err
+	job.RUnlock()
+	return err
+}
+
 // IsRunning returns true if job state is Running
 func (job *Job) IsRunning() bool {
 	return job.isSpecState(Running)
@@ -175,6 +309,15 @@ func (job *Job) setState(state JobState) {
 	job.state = state
 }
 
+// JobQueue is the queue behind a named worker, implemented by Queue,
+// PriorityQueue and DelayQueue
+type JobQueue interface {
+	Put(items ...interface{}) error
+	Get(number int64, items []interface{}) (int64, error)
+	Len() int64
+	Dispose() []interface{}
+}
+
 // Runner TODO
 type Runner struct {
 	sync.RWMutex
@@ -184,7 +327,11 @@ type Runner struct {
 	lastID     uint64
 	cancels    map[uint64]context.CancelFunc
 	state      state
-	namedQueue map[string]*Queue
+	namedQueue map[string]JobQueue
+	namedCtx   map[string]context.Context
+	namedIDs   map[string]uint64
+	namedStats map[string]*workerStats
+	durable    map[string]*DurableQueue
 	tasks      map[string]bool
 }
 
@@ -193,7 +340,11 @@ func NewRunner() *Runner {
 	t := &Runner{
 		stopC:      make(chan struct{}),
 		state:      running,
-		namedQueue: make(map[string]*Queue),
+		namedQueue: make(map[string]JobQueue),
+		namedCtx:   make(map[string]context.Context),
+		namedIDs:   make(map[string]uint64),
+		namedStats: make(map[string]*workerStats),
+		durable:    make(map[string]*DurableQueue),
 		cancels:    make(map[uint64]context.CancelFunc),
 		tasks:      make(map[string]bool),
 	}
@@ -211,67 +362,247 @@ func (s *Runner) AddNamedWorker(name string, stopped func()) (uint64, error) {
 		return 0, errUnavailable
 	}
 
-	if _, ok := s.namedQueue[name]; ok {
+	if s.workerExistsLocked(name) {
+		return 0, fmt.Errorf("%s worker already added", name)
+	}
+
+	id, ctx := s.allocCtxLocked()
+	s.addNamedWorkerLocked(id, ctx, name, NewWithContext(128, ctx), stopped)
+	return id, nil
+}
+
+// AddNamedWorkerWithQueue add a named worker backed by q, e.g. a PriorityQueue
+// or a DelayQueue, q is disposed when the worker is stopped
+func (s *Runner) AddNamedWorkerWithQueue(name string, q JobQueue, stopped func()) (uint64, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.state != running {
+		return 0, errUnavailable
+	}
+
+	if s.workerExistsLocked(name) {
 		return 0, fmt.Errorf("%s worker already added", name)
 	}
 
 	id, ctx := s.allocCtxLocked()
-	q := NewWithContext(128, ctx)
+	go func() {
+		<-ctx.Done()
+		q.Dispose()
+	}()
+	s.addNamedWorkerLocked(id, ctx, name, q, stopped)
+	return id, nil
+}
+
+func (s *Runner) addNamedWorkerLocked(id uint64, ctx context.Context, name string, q JobQueue, stopped func()) {
+	stats := newWorkerStats()
 	s.namedQueue[name] = q
-	s.startWorkerLocked(ctx, name, q, stopped)
+	s.namedCtx[name] = ctx
+	s.namedIDs[name] = id
+	s.namedStats[name] = stats
+	s.startWorkerLocked(ctx, name, q, stats, stopped)
+}
+
+// AddDurableWorker add a named worker that consumes jobs from a redis backed durable queue,
+// jobs are submitted by RunDurableJob, survive restarts and are executed at least once
+func (s *Runner) AddDurableWorker(name string, q *DurableQueue, stopped func()) (uint64, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.state != running {
+		return 0, errUnavailable
+	}
+
+	if s.workerExistsLocked(name) {
+		return 0, fmt.Errorf("%s worker already added", name)
+	}
+
+	id, ctx := s.allocCtxLocked()
+	stats := newWorkerStats()
+	s.durable[name] = q
+	s.namedIDs[name] = id
+	s.namedStats[name] = stats
+	s.doRunCancelableTaskLocked(ctx, name, func(ctx context.Context) {
+		if stopped != nil {
+			defer stopped()
+		}
+		q.consume(ctx, stats)
+	})
 	return id, nil
 }
 
-// IsNamedWorkerBusy returns true if named queue is not empty
-func (s *Runner) IsNamedWorkerBusy(worker string) bool {
+// RunDurableJob run a job type registered by RegisterDurableJob in a durable worker,
+// arg must be serializable with encoding/json
+// Example:
+//
+//	task.RegisterDurableJob("payout", func(order Order) error {
+//		return pay(order)
+//	})
+//	runner.AddDurableWorker("payout", task.NewDurableQueue(nil, "payout"), nil)
+//	runner.RunDurableJob("payout", "payout", order)
+func (s *Runner) RunDurableJob(worker, jobType string, arg interface{}) error {
+	s.RLock()
+	if s.state != running {
+		s.RUnlock()
+		return errUnavailable
+	}
+	q, ok := s.durable[worker]
+	s.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("durable worker %s is not exists", worker)
+	}
+	_, err := q.Enqueue(context.Background(), jobType, jobType, arg)
+	return err
+}
+
+// RemoveNamedWorker stop and remove a named or durable worker, the running job is
+// cancelled through its context and jobs still in the queue are completed as
+// Cancelled, jobs of a durable worker stay in redis
+func (s *Runner) RemoveNamedWorker(name string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.state != running {
+		return errUnavailable
+	}
+
+	if name == defaultQueueName {
+		return errors.New("default worker can not be removed")
+	}
+
+	id, ok := s.namedIDs[name]
+	if !ok {
+		return fmt.Errorf("named worker %s is not exists", name)
+	}
+
+	// dispose before cancelling, otherwise the worker disposes the queue and
+	// the queued jobs are lost without being completed
+	if q, ok := s.namedQueue[name]; ok {
+		cancelDrainedJobs(q.Dispose())
+	}
+	if cancel, ok := s.cancels[id]; ok {
+		delete(s.cancels, id)
+		cancel()
+	}
+	delete(s.namedQueue, name)
+	delete(s.namedCtx, name)
+	delete(s.durable, name)
+	delete(s.namedIDs, name)
+	delete(s.namedStats, name)
+	return nil
+}
+
+// cancelDrainedJobs completes jobs removed from a disposed queue, handles
+// waiting for them return ErrJobCancelled
+func cancelDrainedJobs(items []interface{}) {
+	for _, item := range items {
+		job, ok := item.(*Job)
+		if !ok {
+			continue
+		}
+		job.Lock()
+		if job.state == Pending || job.state == Cancelling {
+			job.completeLocked(Cancelled, ErrJobCancelled)
+		}
+		release := job.needRelease
+		job.Unlock()
+		if release {
+			releaseJob(job)
+		}
+	}
+}
+
+// Workers returns the names of all registered named and durable workers
+func (s *Runner) Workers() []string {
 	s.RLock()
 	defer s.RUnlock()
 
-	return s.getNamedQueueLocked(worker).Len() > 0
+	names := make([]string, 0, len(s.namedIDs))
+	for name := range s.namedIDs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
 
-func (s *Runner) startWorkerLocked(ctx context.Context, name string, q *Queue, stopped func()) {
+// Stats returns a snapshot of all named and durable workers sorted by name
+func (s *Runner) Stats() []WorkerStats {
+	s.RLock()
+	result := make([]WorkerStats, 0, len(s.namedStats))
+	durable := make(map[string]*DurableQueue, len(s.durable))
+	for name, stats := range s.namedStats {
+		ws := stats.snapshot(name)
+		if q, ok := s.namedQueue[name]; ok {
+			ws.QueueLen = q.Len()
+		}
+		if q, ok := s.durable[name]; ok {
+			ws.Durable = true
+			durable[name] = q
+		}
+		result = append(result, ws)
+	}
+	s.RUnlock()
+
+	// query redis outside the lock
+	for i := range result {
+		if q, ok := durable[result[i].Name]; ok {
+			result[i].QueueLen, _ = q.Len(context.Background())
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// IsNamedWorkerBusy returns true if named queue is not empty, for a durable
+// worker the jobs waiting in redis are counted, false for unknown workers
+func (s *Runner) IsNamedWorkerBusy(worker string) bool {
+	s.RLock()
+	q := s.getNamedQueueLocked(worker)
+	dq := s.durable[worker]
+	s.RUnlock()
+
+	if q != nil {
+		return q.Len() > 0
+	}
+	if dq != nil {
+		// query redis outside the lock
+		n, _ := dq.Len(context.Background())
+		return n > 0
+	}
+	return false
+}
+
+func (s *Runner) startWorkerLocked(ctx context.Context, name string, q JobQueue, stats *workerStats, stopped func()) {
+	// ordered queues are read one job at a time, so a job put while a batch
+	// is running can still overtake the rest of the batch
+	size := batch
+	if _, ok := q.(*Queue); !ok {
+		size = 1
+	}
+
 	s.doRunCancelableTaskLocked(ctx, name, func(ctx context.Context) {
-		jobs := make([]interface{}, batch)
+		jobs := make([]interface{}, size)
 		if stopped != nil {
 			defer stopped()
 		}
 
 		for {
-			n, err := q.Get(batch, jobs)
+			n, err := q.Get(size, jobs)
 			if err != nil {
 				return
 			}
 
 			for i := int64(0); i < n; i++ {
-				job := jobs[i].(*Job)
-
-				job.Lock()
-
-				switch job.state {
-				case Pending:
-					job.setState(Running)
-					job.Unlock()
-					err := job.fun()
-					job.Lock()
-					if err != nil {
-						if err == ErrJobCancelled {
-							job.setState(Cancelled)
-						} else {
-							job.setState(Failed)
-						}
-					} else {
-						if job.state == Cancelling {
-							job.setState(Cancelled)
-						} else {
-							job.setState(Finished)
-						}
-					}
-				case Cancelling:
-					job.setState(Cancelled)
+				// the rest of the batch will never start once the worker is stopped
+				if ctx.Err() != nil {
+					cancelDrainedJobs(jobs[i:n])
+					return
 				}
-
-				job.Unlock()
+				job := jobs[i].(*Job)
+				job.execute(stats)
 
 				if job.needRelease {
 					releaseJob(job)
@@ -293,6 +624,34 @@ func (s *Runner) RunJobWithNamedWorker(desc, worker string, task func() error) e
 
 // RunJobWithNamedWorkerWithCB run a job in a named worker
 func (s *Runner) RunJobWithNamedWorkerWithCB(desc, worker string, task func() error, cb func(*Job)) error {
+	return s.doRunJob(desc, worker, task, cb, nil)
+}
+
+// RunPriorityJobWithNamedWorker run a job in a named worker backed by a PriorityQueue,
+// jobs with higher priority run first
+func (s *Runner) RunPriorityJobWithNamedWorker(desc, worker string, priority int, task func() error) error {
+	return s.doRunJob(desc, worker, task, nil, func(q JobQueue, job *Job) error {
+		if _, ok := q.(*PriorityQueue); !ok {
+			return fmt.Errorf("named worker %s is not a priority worker", worker)
+		}
+		job.priority = priority
+		return nil
+	})
+}
+
+// RunDelayedJobWithNamedWorker run a job in a named worker backed by a DelayQueue,
+// the job does not start before delay elapsed
+func (s *Runner) RunDelayedJobWithNamedWorker(desc, worker string, delay time.Duration, task func() error) error {
+	return s.doRunJob(desc, worker, task, nil, func(q JobQueue, job *Job) error {
+		if _, ok := q.(*DelayQueue); !ok {
+			return fmt.Errorf("named worker %s is not a delay worker", worker)
+		}
+		job.dueTime = time.Now().Add(delay)
+		return nil
+	})
+}
+
+func (s *Runner) doRunJob(desc, worker string, task func() error, cb func(*Job), setup func(JobQueue, *Job) error) error {
 	s.Lock()
 
 	if s.state != running {
@@ -300,24 +659,82 @@ func (s *Runner) RunJobWithNamedWorkerWithCB(desc, worker string, task func() er
 		return errUnavailable
 	}
 
-	job := newJob(desc, task)
+	if _, ok := s.durable[worker]; ok {
+		s.Unlock()
+		return fmt.Errorf("durable worker %s only runs jobs submitted by RunDurableJob", worker)
+	}
+
 	q := s.getNamedQueueLocked(worker)
 	if q == nil {
 		s.Unlock()
 		return fmt.Errorf("named worker %s is not exists", worker)
 	}
 
+	job := newJob(desc, task)
+	if setup != nil {
+		if err := setup(q, job); err != nil {
+			s.Unlock()
+			releaseJob(job)
+			return err
+		}
+	}
+
 	if cb != nil {
 		job.needRelease = false
 		cb(job)
 	}
 
+	job.enqueuedAt = time.Now()
 	q.Put(job)
 
 	s.Unlock()
 	return nil
 }
 
+// RunContextJob run a context aware job in the default worker, a non-positive
+// timeout means no timeout
+func (s *Runner) RunContextJob(desc string, timeout time.Duration, task ContextJobFunc) (*JobHandle, error) {
+	return s.RunContextJobWithNamedWorker(desc, defaultQueueName, timeout, task)
+}
+
+// RunContextJobWithNamedWorker run a context aware job in a named worker, the
+// returned handle can be used to wait for the job and read its result
+// Example:
+//
+//	h, err := s.RunContextJobWithNamedWorker("report", "report", time.Minute, func(ctx context.Context) (interface{}, error) {
+//		return buildReport(ctx)
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	if err := h.Wait(); err != nil {
+//		return err
+//	}
+//	report := h.Result()
+func (s *Runner) RunContextJobWithNamedWorker(desc, worker string, timeout time.Duration, task ContextJobFunc) (*JobHandle, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.state != running {
+		return nil, errUnavailable
+	}
+
+	q := s.getNamedQueueLocked(worker)
+	if q == nil {
+		return nil, fmt.Errorf("named worker %s is not exists", worker)
+	}
+
+	job := newContextJob(s.namedCtx[worker], desc, timeout, task)
+	job.enqueuedAt = time.Now()
+	if err := q.Put(job); err != nil {
+		job.Lock()
+		job.completeLocked(Cancelled, err)
+		job.Unlock()
+		return nil, err
+	}
+	return &JobHandle{job: job}, nil
+}
+
 // RunCancelableTask run a task that can be cancelled
 // Example:
 //
@@ -411,6 +828,11 @@ func (s *Runner) doStop(timeout time.Duration) ([]string, error) {
 	}
 	s.state = stopping
 
+	// complete jobs that will never start, dispose before cancelling the
+	// workers, otherwise the workers dispose the queues and the jobs are lost
+	for _, q := range s.namedQueue {
+		cancelDrainedJobs(q.Dispose())
+	}
 	for _, cancel := range s.cancels {
 		cancel()
 	}
@@ -457,7 +879,13 @@ func (s *Runner) doRunCancelableTaskLocked(ctx context.Context, name string, tas
 	}()
 }
 
-func (s *Runner) getNamedQueueLocked(name string) *Queue {
+func (s *Runner) workerExistsLocked(name string) bool {
+	_, named := s.namedQueue[name]
+	_, durable := s.durable[name]
+	return named || durable
+}
+
+func (s *Runner) getNamedQueueLocked(name string) JobQueue {
 	return s.namedQueue[name]
 }
 
@@ -208,3 +208,122 @@ func TestStopWithTimeoutWorkers(t *testing.T) {
 	assert.Equal(t, "w-1", timeoutWorkers[1])
 	assert.Equal(t, "w-3", timeoutWorkers[2])
 }
+
+func TestRunnerStats(t *testing.T) {
+	runner := NewRunner()
+	defer runner.Stop()
+
+	_, err := runner.AddNamedWorker("stats", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{defaultQueueName, "stats"}, runner.Workers())
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	runner.RunJobWithNamedWorker("ok", "stats", func() error {
+		defer wg.Done()
+		return nil
+	})
+	runner.RunJobWithNamedWorker("failed", "stats", func() error {
+		defer wg.Done()
+		return fmt.Errorf("failed")
+	})
+	wg.Wait()
+
+	assert.Eventually(t, func() bool {
+		for _, ws := range runner.Stats() {
+			if ws.Name == "stats" {
+				return ws.Processed == 2 && ws.Failed == 1 &&
+					ws.RunLatency.Count == 2 && ws.WaitLatency.Count == 2
+			}
+		}
+		return false
+	}, time.Second, 10*time.Millisecond)
+
+	assert.NoError(t, runner.RemoveNamedWorker("stats"))
+	assert.Equal(t, []string{defaultQueueName}, runner.Workers())
+	assert.Error(t, runner.RunJobWithNamedWorker("", "stats", func() error { return nil }))
+	assert.Error(t, runner.RemoveNamedWorker("stats"))
+	assert.Error(t, runner.RemoveNamedWorker(defaultQueueName))
+}
+
+func TestRemoveNamedWorkerCancelsQueuedJobs(t *testing.T) {
+	runner := NewRunner()
+	defer runner.Stop()
+
+	_, err := runner.AddNamedWorker("remove", nil)
+	assert.NoError(t, err)
+
+	started, block := make(chan struct{}), make(chan struct{})
+	runner.RunJobWithNamedWorker("blocking", "remove", func() error {
+		close(started)
+		<-block
+		return nil
+	})
+	<-started
+
+	var queued *Job
+	assert.NoError(t, runner.RunJobWithNamedWorkerWithCB("queued", "remove", func() error {
+		t.Error("queued job executed after the worker is removed")
+		return nil
+	}, func(job *Job) {
+		queued = job
+	}))
+	h, err := runner.RunContextJobWithNamedWorker("handle", "remove", 0, func(ctx context.Context) (interface{}, error) {
+		t.Error("queued context job executed after the worker is removed")
+		return nil, nil
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, runner.RemoveNamedWorker("remove"))
+	assert.True(t, queued.IsCancelled())
+	assert.Equal(t, ErrJobCancelled, queued.GetError())
+	select {
+	case <-h.Done():
+		assert.Equal(t, ErrJobCancelled, h.Err())
+	case <-time.After(time.Second):
+		t.Fatal("handle of a drained job not completed")
+	}
+	close(block)
+}
+
+func TestPriorityAndDelayWorkers(t *testing.T) {
+	runner := NewRunner()
+	defer runner.Stop()
+
+	_, err := runner.AddNamedWorkerWithQueue("priority", NewPriorityQueue(16, nil), nil)
+	assert.NoError(t, err)
+	_, err = runner.AddNamedWorkerWithQueue("delay", NewDelayQueue(16), nil)
+	assert.NoError(t, err)
+
+	block := make(chan struct{})
+	var order []int
+	var wg sync.WaitGroup
+	wg.Add(4)
+	runner.RunPriorityJobWithNamedWorker("", "priority", 0, func() error {
+		<-block
+		wg.Done()
+		return nil
+	})
+	for _, p := range []int{1, 3, 2} {
+		p := p
+		assert.NoError(t, runner.RunPriorityJobWithNamedWorker("", "priority", p, func() error {
+			order = append(order, p)
+			wg.Done()
+			return nil
+		}))
+	}
+	close(block)
+	wg.Wait()
+	assert.Equal(t, []int{3, 2, 1}, order)
+
+	start := time.Now()
+	ran := make(chan time.Duration, 1)
+	assert.NoError(t, runner.RunDelayedJobWithNamedWorker("", "delay", 30*time.Millisecond, func() error {
+		ran <- time.Since(start)
+		return nil
+	}))
+	assert.True(t, <-ran >= 30*time.Millisecond)
+
+	assert.Error(t, runner.RunDelayedJobWithNamedWorker("", "priority", time.Second, func() error { return nil }))
+	assert.Error(t, runner.RunPriorityJobWithNamedWorker("", defaultQueueName, 1, func() error { return nil }))
+}
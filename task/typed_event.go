@@ -0,0 +1,89 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+)
+
+var (
+	// ErrEventType is reported when a typed listener receives a param of another type
+	ErrEventType = errors.New("event bus: unexpected event type")
+)
+
+// EventKey returns the key typed listeners of T are registered under, it is
+// reflect.TypeOf of a T value so Register(reflect.TypeOf(&PingEvent{}), ...)
+// and Subscribe[*PingEvent] share the same event
+func EventKey[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// Subscription is returned by Subscribe, it is used to remove the listener
+type Subscription struct {
+	id    uint64
+	event interface{}
+	bus   *EventBus
+}
+
+// Unsubscribe removes the listener, notifications already queued may still
+// be delivered
+func (s *Subscription) Unsubscribe() {
+	s.bus.Lock()
+	s.bus.unregisterLocked(s.event, func(n Notifiable) bool {
+		t, ok := n.(interface{ subscriptionID() uint64 })
+		return ok && t.subscriptionID() == s.id
+	})
+	s.bus.Unlock()
+}
+
+type typedNotifiable[T any] struct {
+	id      uint64
+	handler func(T) error
+}
+
+func (n *typedNotifiable[T]) subscriptionID() uint64 {
+	return n.id
+}
+
+func (n *typedNotifiable[T]) Notify(param interface{}) {
+	_ = n.notifyWithError(param)
+}
+
+func (n *typedNotifiable[T]) notifyWithError(param interface{}) error {
+	v, ok := param.(T)
+	if !ok {
+		return fmt.Errorf("%w: want %v, got %T", ErrEventType, EventKey[T](), param)
+	}
+	return n.handler(v)
+}
+
+// Subscribe registers handler for events of type T, errors returned by
+// handler go to the bus error handler when notified asynchronously
+func Subscribe[T any](bus *EventBus, handler func(T) error) *Subscription {
+	n := &typedNotifiable[T]{
+		id:      atomic.AddUint64(&bus.lastSubID, 1),
+		handler: handler,
+	}
+	event := EventKey[T]()
+	bus.Register(event, n)
+	return &Subscription{id: n.id, event: event, bus: bus}
+}
+
+// Publish notifies the listeners of T asynchronously
+func Publish[T any](bus *EventBus, event T) {
+	bus.Notify(EventKey[T](), event)
+}
+
+// PublishSync notifies the listeners of T in the calling goroutine and
+// returns their errors joined, a panicking listener yields ErrListenerPanic
+// and does not stop the others
+func PublishSync[T any](bus *EventBus, event T) error {
+	return bus.syncNotify(EventKey[T](), event)
+}
+
+// SetOrderedDelivery makes the asynchronous notifications of T be delivered
+// one by one in publish order
+func SetOrderedDelivery[T any](bus *EventBus, ordered bool) {
+	bus.SetOrderedDelivery(EventKey[T](), ordered)
+}
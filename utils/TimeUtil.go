@@ -732,6 +732,16 @@ func EndByTime(t time.Time) time.Time {
 	return time.Date(t.Year(), t.Month(), t.Day(), 23, 59, 59, 0, t.Location())
 }
 
+// WeekStartByTime 获得指定时间所在周的第一天(周日)
+func WeekStartByTime(t time.Time) time.Time {
+	return StartByTime(t).AddDate(0, 0, -int(t.Weekday()))
+}
+
+// MonthStartByTime 获得指定时间所在月的一号
+func MonthStartByTime(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
 // IsCurDay 是否是今天
 func IsCurDay(t time.Time) bool {
 	cur := CurTodayStart()